@@ -0,0 +1,471 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+type GraphNodeKind string
+
+const (
+	GraphNodeKindComponent GraphNodeKind = "component"
+	GraphNodeKindLocation  GraphNodeKind = "location"
+	GraphNodeKindHost      GraphNodeKind = "host"
+	GraphNodeKindInstance  GraphNodeKind = "instance"
+)
+
+type GraphEdgeRelation string
+
+const (
+	// A location contains a sub location or a host, and a host contains instances.
+	GraphEdgeRelationContains GraphEdgeRelation = "contains"
+	// An instance belongs to a component.
+	GraphEdgeRelationInstanceOf GraphEdgeRelation = "instance_of"
+	// A component sends requests to another component.
+	GraphEdgeRelationDependsOn GraphEdgeRelation = "depends_on"
+)
+
+type GraphNode struct {
+	ID    string        `json:"id"`
+	Kind  GraphNodeKind `json:"kind"`
+	Label string        `json:"label"`
+
+	// Fields below are only available for instances
+	Component string                    `json:"component,omitempty"`
+	Address   string                    `json:"address,omitempty"`
+	Version   string                    `json:"version,omitempty"`
+	Status    *topology.ComponentStatus `json:"status,omitempty"`
+	Labels    map[string]string         `json:"labels,omitempty"`
+}
+
+type GraphEdge struct {
+	Source   string            `json:"source"`
+	Target   string            `json:"target"`
+	Relation GraphEdgeRelation `json:"relation"`
+}
+
+type TopologyGraph struct {
+	LocationLabels []string             `json:"location_labels"`
+	Nodes          []GraphNode          `json:"nodes"`
+	Edges          []GraphEdge          `json:"edges"`
+	Errors         []rest.ErrorResponse `json:"errors"`
+}
+
+// componentDependencies are the relationships between components. Edges are only added when both ends are deployed.
+var componentDependencies = []struct {
+	source string
+	target string
+}{
+	{"tidb", "pd"},
+	{"tidb", "tikv"},
+	{"tidb", "tiflash"},
+	{"tikv", "pd"},
+	{"tiflash", "pd"},
+	{"tiflash", "tikv"},
+	{"ticdc", "pd"},
+	{"ticdc", "tikv"},
+	{"tiproxy", "tidb"},
+	{"tiproxy", "pd"},
+	{"tidb", "tso"},
+	{"tso", "pd"},
+	{"tikv", "scheduling"},
+	{"scheduling", "pd"},
+	{"grafana", "prometheus"},
+	{"prometheus", "alertmanager"},
+}
+
+func componentDisplayName(component string) string {
+	switch component {
+	case "pd":
+		return distro.R().PD
+	case "tso":
+		return distro.R().TSO
+	case "scheduling":
+		return distro.R().Scheduling
+	case "tikv":
+		return distro.R().TiKV
+	case "tiflash":
+		return distro.R().TiFlash
+	case "tidb":
+		return distro.R().TiDB
+	case "ticdc":
+		return distro.R().TiCDC
+	case "tiproxy":
+		return distro.R().TiProxy
+	case "prometheus":
+		return "Prometheus"
+	case "grafana":
+		return "Grafana"
+	case "alertmanager":
+		return "AlertManager"
+	default:
+		return component
+	}
+}
+
+type graphInstance struct {
+	component string
+	ip        string
+	port      uint
+	version   string
+	status    topology.ComponentStatus
+	labels    map[string]string
+}
+
+func (i graphInstance) address() string {
+	return net.JoinHostPort(i.ip, strconv.Itoa(int(i.port)))
+}
+
+func locationNodeID(path []string) string {
+	return "location:" + strings.Join(path, ",")
+}
+
+// buildTopologyGraph builds the graph from instances. Stores are placed under locations according to their location
+// labels, and the hierarchy stops at the first missing label.
+func buildTopologyGraph(locationLabels []string, instances []graphInstance) *TopologyGraph {
+	g := &TopologyGraph{
+		LocationLabels: locationLabels,
+		Nodes:          make([]GraphNode, 0),
+		Edges:          make([]GraphEdge, 0),
+		Errors:         make([]rest.ErrorResponse, 0),
+	}
+	nodes := make(map[string]struct{})
+	edges := make(map[GraphEdge]struct{})
+	addNode := func(n GraphNode) {
+		if _, ok := nodes[n.ID]; ok {
+			return
+		}
+		nodes[n.ID] = struct{}{}
+		g.Nodes = append(g.Nodes, n)
+	}
+	addEdge := func(e GraphEdge) {
+		if _, ok := edges[e]; ok {
+			return
+		}
+		edges[e] = struct{}{}
+		g.Edges = append(g.Edges, e)
+	}
+
+	deployed := make(map[string]struct{})
+	for _, instance := range instances {
+		if instance.status == topology.ComponentStatusTombstone {
+			continue
+		}
+		deployed[instance.component] = struct{}{}
+		componentID := "component:" + instance.component
+		hostID := "host:" + instance.ip
+		instanceID := "instance:" + instance.component + ":" + instance.address()
+		status := instance.status
+
+		addNode(GraphNode{ID: componentID, Kind: GraphNodeKindComponent, Label: componentDisplayName(instance.component)})
+		addNode(GraphNode{ID: hostID, Kind: GraphNodeKindHost, Label: instance.ip})
+		addNode(GraphNode{
+			ID:        instanceID,
+			Kind:      GraphNodeKindInstance,
+			Label:     instance.address(),
+			Component: instance.component,
+			Address:   instance.address(),
+			Version:   instance.version,
+			Status:    &status,
+			Labels:    instance.labels,
+		})
+		addEdge(GraphEdge{Source: hostID, Target: instanceID, Relation: GraphEdgeRelationContains})
+		addEdge(GraphEdge{Source: instanceID, Target: componentID, Relation: GraphEdgeRelationInstanceOf})
+
+		parentID := ""
+		path := make([]string, 0, len(locationLabels))
+		for _, key := range locationLabels {
+			value, ok := instance.labels[key]
+			if !ok || value == "" {
+				break
+			}
+			path = append(path, key+"="+value)
+			id := locationNodeID(path)
+			addNode(GraphNode{ID: id, Kind: GraphNodeKindLocation, Label: key + ": " + value})
+			if parentID != "" {
+				addEdge(GraphEdge{Source: parentID, Target: id, Relation: GraphEdgeRelationContains})
+			}
+			parentID = id
+		}
+		if parentID != "" {
+			addEdge(GraphEdge{Source: parentID, Target: hostID, Relation: GraphEdgeRelationContains})
+		}
+	}
+
+	for _, d := range componentDependencies {
+		_, sourceOk := deployed[d.source]
+		_, targetOk := deployed[d.target]
+		if sourceOk && targetOk {
+			addEdge(GraphEdge{Source: "component:" + d.source, Target: "component:" + d.target, Relation: GraphEdgeRelationDependsOn})
+		}
+	}
+
+	sort.SliceStable(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return g.Edges[i].Source < g.Edges[j].Source
+		}
+		return g.Edges[i].Target < g.Edges[j].Target
+	})
+	return g
+}
+
+func (s *Service) fetchTopologyGraph(ctx context.Context) *TopologyGraph {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	instances := make([]graphInstance, 0)
+	errs := make([]rest.ErrorResponse, 0)
+	locationLabels := make([]string, 0)
+	fetch := func(f func() ([]graphInstance, error)) {
+		wg.Go(func() {
+			r, err := f()
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, rest.NewErrorResponse(err))
+				return
+			}
+			instances = append(instances, r...)
+		})
+	}
+
+	fetch(func() ([]graphInstance, error) {
+		info, err := topology.FetchPDTopology(s.params.PDClient)
+		r := make([]graphInstance, 0, len(info))
+		for _, i := range info {
+			r = append(r, graphInstance{component: "pd", ip: i.IP, port: i.Port, version: i.Version, status: i.Status})
+		}
+		return r, err
+	})
+	fetch(func() ([]graphInstance, error) {
+		location, err := topology.FetchStoreLocation(s.params.PDClient)
+		if err != nil {
+			return nil, err
+		}
+		labels := make([]string, 0, len(location.LocationLabels))
+		for _, l := range location.LocationLabels {
+			if l = strings.TrimSpace(l); l != "" {
+				labels = append(labels, l)
+			}
+		}
+		locationLabels = labels
+		return nil, nil
+	})
+	fetch(func() ([]graphInstance, error) {
+		tikvInfo, tiflashInfo, err := topology.FetchStoreTopology(s.params.PDClient)
+		r := make([]graphInstance, 0, len(tikvInfo)+len(tiflashInfo))
+		for _, i := range tikvInfo {
+			r = append(r, graphInstance{component: "tikv", ip: i.IP, port: i.Port, version: i.Version, status: i.Status, labels: i.Labels})
+		}
+		for _, i := range tiflashInfo {
+			r = append(r, graphInstance{component: "tiflash", ip: i.IP, port: i.Port, version: i.Version, status: i.Status, labels: i.Labels})
+		}
+		return r, err
+	})
+	fetch(func() ([]graphInstance, error) {
+		info, err := topology.FetchTiDBTopology(ctx, s.params.EtcdClient)
+		r := make([]graphInstance, 0, len(info))
+		for _, i := range info {
+			r = append(r, graphInstance{component: "tidb", ip: i.IP, port: i.Port, version: i.Version, status: i.Status})
+		}
+		return r, err
+	})
+	fetch(func() ([]graphInstance, error) {
+		info, err := topology.FetchTiCDCTopology(ctx, s.params.EtcdClient)
+		r := make([]graphInstance, 0, len(info))
+		for _, i := range info {
+			r = append(r, graphInstance{component: "ticdc", ip: i.IP, port: i.Port, version: i.Version, status: i.Status})
+		}
+		return r, err
+	})
+	fetch(func() ([]graphInstance, error) {
+		info, err := topology.FetchTiProxyTopology(ctx, s.params.EtcdClient)
+		r := make([]graphInstance, 0, len(info))
+		for _, i := range info {
+			r = append(r, graphInstance{component: "tiproxy", ip: i.IP, port: i.Port, version: i.Version, status: i.Status})
+		}
+		return r, err
+	})
+	fetch(func() ([]graphInstance, error) {
+		info, err := topology.FetchTSOTopology(ctx, s.params.PDClient)
+		if err != nil && strings.Contains(err.Error(), "status code 404") {
+			// TSO is not deployed as a microservice
+			return nil, nil
+		}
+		r := make([]graphInstance, 0, len(info))
+		for _, i := range info {
+			r = append(r, graphInstance{component: "tso", ip: i.IP, port: i.Port, version: i.Version, status: i.Status})
+		}
+		return r, err
+	})
+	fetch(func() ([]graphInstance, error) {
+		info, err := topology.FetchSchedulingTopology(ctx, s.params.PDClient)
+		if err != nil && strings.Contains(err.Error(), "status code 404") {
+			// Scheduling is not deployed as a microservice
+			return nil, nil
+		}
+		r := make([]graphInstance, 0, len(info))
+		for _, i := range info {
+			r = append(r, graphInstance{component: "scheduling", ip: i.IP, port: i.Port, version: i.Version, status: i.Status})
+		}
+		return r, err
+	})
+	fetch(func() ([]graphInstance, error) {
+		r := make([]graphInstance, 0, 3)
+		readyPaths := make([]string, 0, 3)
+		monitor := func(component string, info topology.StandardComponentInfo, readyPath string) {
+			r = append(r, graphInstance{component: component, ip: info.IP, port: info.Port})
+			readyPaths = append(readyPaths, readyPath)
+		}
+		prometheus, err := topology.FetchPrometheusTopology(ctx, s.params.EtcdClient)
+		if err != nil {
+			return nil, err
+		}
+		if prometheus != nil {
+			monitor("prometheus", prometheus.StandardComponentInfo, "/-/ready")
+		}
+		grafana, err := topology.FetchGrafanaTopology(ctx, s.params.EtcdClient)
+		if err != nil {
+			return nil, err
+		}
+		if grafana != nil {
+			monitor("grafana", grafana.StandardComponentInfo, "/api/health")
+		}
+		alertManager, err := topology.FetchAlertManagerTopology(ctx, s.params.EtcdClient)
+		if err != nil {
+			return nil, err
+		}
+		if alertManager != nil {
+			monitor("alertmanager", alertManager.StandardComponentInfo, "/-/ready")
+		}
+		// Monitoring components are not registered with their status, so that they are probed.
+		var probeWg sync.WaitGroup
+		for i := range r {
+			probeWg.Go(func() {
+				r[i].status = topology.ComponentStatusUp
+				uri := fmt.Sprintf("http://%s%s", r[i].address(), readyPaths[i])
+				if err := s.probe(ctx, uri, componentDisplayName(r[i].component)); err != nil {
+					r[i].status = topology.ComponentStatusUnreachable
+				}
+			})
+		}
+		probeWg.Wait()
+		return r, nil
+	})
+	wg.Wait()
+
+	g := buildTopologyGraph(locationLabels, instances)
+	g.Errors = errs
+	return g
+}
+
+func statusName(status topology.ComponentStatus) string {
+	switch status {
+	case topology.ComponentStatusUp:
+		return "up"
+	case topology.ComponentStatusTombstone:
+		return "tombstone"
+	case topology.ComponentStatusOffline:
+		return "offline"
+	case topology.ComponentStatusDown:
+		return "down"
+	default:
+		return "unreachable"
+	}
+}
+
+func nodeDescription(n GraphNode) string {
+	if n.Kind != GraphNodeKindInstance {
+		return n.Label
+	}
+	desc := componentDisplayName(n.Component) + " " + n.Address
+	if n.Version != "" {
+		desc += " " + n.Version
+	}
+	if n.Status != nil && *n.Status != topology.ComponentStatusUp {
+		desc += " (" + statusName(*n.Status) + ")"
+	}
+	return desc
+}
+
+func dotQuote(s string) string {
+	return strconv.Quote(s)
+}
+
+var dotNodeShapes = map[GraphNodeKind]string{
+	GraphNodeKindComponent: "component",
+	GraphNodeKindLocation:  "folder",
+	GraphNodeKindHost:      "box3d",
+	GraphNodeKindInstance:  "box",
+}
+
+// RenderDOT renders the graph in Graphviz DOT language.
+func (g *TopologyGraph) RenderDOT() string {
+	var b strings.Builder
+	b.WriteString("digraph topology {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(nodeDescription(n)), dotNodeShapes[n.Kind])
+	}
+	for _, e := range g.Edges {
+		style := "solid"
+		if e.Relation == GraphEdgeRelationInstanceOf {
+			style = "dotted"
+		} else if e.Relation == GraphEdgeRelationDependsOn {
+			style = "bold"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, style=%s];\n", dotQuote(e.Source), dotQuote(e.Target), dotQuote(string(e.Relation)), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+// RenderMermaid renders the graph as a Mermaid flowchart. Mermaid node IDs can only contain a limited set of
+// characters, so nodes are numbered instead.
+func (g *TopologyGraph) RenderMermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes {
+		id := "n" + strconv.Itoa(i)
+		ids[n.ID] = id
+		text := mermaidEscape(nodeDescription(n))
+		switch n.Kind {
+		case GraphNodeKindComponent:
+			fmt.Fprintf(&b, "  %s{{\"%s\"}}\n", id, text)
+		case GraphNodeKindLocation:
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", id, text)
+		case GraphNodeKindHost:
+			fmt.Fprintf(&b, "  %s(\"%s\")\n", id, text)
+		default:
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, text)
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Relation == GraphEdgeRelationInstanceOf {
+			arrow = "-.->"
+		} else if e.Relation == GraphEdgeRelationDependsOn {
+			arrow = "==>"
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[e.Source], arrow, e.Relation, ids[e.Target])
+	}
+	return b.String()
+}
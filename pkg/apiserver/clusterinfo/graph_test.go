@@ -0,0 +1,64 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func TestBuildTopologyGraph(t *testing.T) {
+	g := buildTopologyGraph([]string{"zone", "rack"}, []graphInstance{
+		{component: "pd", ip: "10.0.0.1", port: 2379, status: topology.ComponentStatusUp},
+		{component: "tikv", ip: "10.0.0.1", port: 20160, status: topology.ComponentStatusUp, labels: map[string]string{"zone": "z1", "rack": "r1"}},
+		{component: "tikv", ip: "10.0.0.2", port: 20160, status: topology.ComponentStatusDown, labels: map[string]string{"zone": "z1"}},
+		{component: "tikv", ip: "10.0.0.3", port: 20160, status: topology.ComponentStatusTombstone, labels: map[string]string{"zone": "z2"}},
+		{component: "tidb", ip: "10.0.0.2", port: 4000, status: topology.ComponentStatusUp},
+		{component: "tso", ip: "10.0.0.1", port: 3379, status: topology.ComponentStatusUp},
+	})
+
+	nodes := make(map[string]GraphNode)
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+	require.Len(t, nodes, 13)
+	require.Contains(t, nodes, "host:10.0.0.1")
+	require.NotContains(t, nodes, "host:10.0.0.3")
+	require.NotContains(t, nodes, "location:zone=z2")
+	require.Equal(t, "rack: r1", nodes["location:zone=z1,rack=r1"].Label)
+	require.Equal(t, topology.ComponentStatusDown, *nodes["instance:tikv:10.0.0.2:20160"].Status)
+
+	edges := make(map[GraphEdge]struct{})
+	for _, e := range g.Edges {
+		edges[e] = struct{}{}
+	}
+	for _, e := range []GraphEdge{
+		{Source: "location:zone=z1", Target: "location:zone=z1,rack=r1", Relation: GraphEdgeRelationContains},
+		{Source: "location:zone=z1,rack=r1", Target: "host:10.0.0.1", Relation: GraphEdgeRelationContains},
+		{Source: "location:zone=z1", Target: "host:10.0.0.2", Relation: GraphEdgeRelationContains},
+		{Source: "host:10.0.0.2", Target: "instance:tidb:10.0.0.2:4000", Relation: GraphEdgeRelationContains},
+		{Source: "instance:pd:10.0.0.1:2379", Target: "component:pd", Relation: GraphEdgeRelationInstanceOf},
+		{Source: "component:tidb", Target: "component:tikv", Relation: GraphEdgeRelationDependsOn},
+		{Source: "component:tikv", Target: "component:pd", Relation: GraphEdgeRelationDependsOn},
+		{Source: "component:tidb", Target: "component:tso", Relation: GraphEdgeRelationDependsOn},
+		{Source: "component:tso", Target: "component:pd", Relation: GraphEdgeRelationDependsOn},
+	} {
+		require.Contains(t, edges, e)
+	}
+	require.NotContains(t, edges, GraphEdge{Source: "component:tidb", Target: "component:tiflash", Relation: GraphEdgeRelationDependsOn})
+
+	dot := g.RenderDOT()
+	require.True(t, strings.HasPrefix(dot, "digraph topology {\n"))
+	require.Contains(t, dot, `"instance:tikv:10.0.0.2:20160" [label="TiKV 10.0.0.2:20160 (down)", shape=box];`)
+	require.Contains(t, dot, `"component:tidb" -> "component:tikv" [label="depends_on", style=bold];`)
+
+	mermaid := g.RenderMermaid()
+	require.True(t, strings.HasPrefix(mermaid, "flowchart LR\n"))
+	require.Contains(t, mermaid, `{{"TiDB"}}`)
+	require.Contains(t, mermaid, `(["zone: z1"])`)
+	require.Equal(t, len(g.Nodes)+len(g.Edges)+1, strings.Count(mermaid, "\n"))
+}
@@ -0,0 +1,426 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+)
+
+const (
+	healthProbeTimeout = 3 * time.Second
+	// Public health checks are cached, so that unauthenticated requests cannot flood the cluster with probes.
+	publicHealthCacheTTL = 10 * time.Second
+)
+
+type HealthVerdict string
+
+const (
+	// Verdicts are ordered by severity.
+	HealthVerdictUnknown   HealthVerdict = "unknown"
+	HealthVerdictHealthy   HealthVerdict = "healthy"
+	HealthVerdictDegraded  HealthVerdict = "degraded"
+	HealthVerdictUnhealthy HealthVerdict = "unhealthy"
+)
+
+func (v HealthVerdict) severity() int {
+	switch v {
+	case HealthVerdictHealthy:
+		return 1
+	case HealthVerdictDegraded:
+		return 2
+	case HealthVerdictUnhealthy:
+		return 3
+	default:
+		return 0
+	}
+}
+
+type HealthReasonCode string
+
+const (
+	HealthReasonFetchFailed         HealthReasonCode = "fetch_failed"
+	HealthReasonNotDeployed         HealthReasonCode = "not_deployed"
+	HealthReasonNoAliveInstance     HealthReasonCode = "no_alive_instance"
+	HealthReasonQuorumLost          HealthReasonCode = "quorum_lost"
+	HealthReasonInstanceDown        HealthReasonCode = "instance_down"
+	HealthReasonInstanceUnreachable HealthReasonCode = "instance_unreachable"
+	HealthReasonInstanceOffline     HealthReasonCode = "instance_offline"
+	HealthReasonProbeFailed         HealthReasonCode = "probe_failed"
+	HealthReasonHostInfoUnavailable HealthReasonCode = "host_info_unavailable"
+	HealthReasonMemoryPressure      HealthReasonCode = "memory_pressure"
+	HealthReasonDiskPressure        HealthReasonCode = "disk_pressure"
+)
+
+type HealthReason struct {
+	Code     HealthReasonCode `json:"code"`
+	Instance string           `json:"instance,omitempty"`
+	Message  string           `json:"message"`
+}
+
+type ComponentHealth struct {
+	Component string         `json:"component"`
+	Verdict   HealthVerdict  `json:"verdict"`
+	Total     int            `json:"total"`
+	Healthy   int            `json:"healthy"`
+	Reasons   []HealthReason `json:"reasons"`
+}
+
+type ClusterHealth struct {
+	Verdict    HealthVerdict     `json:"verdict"`
+	CheckedAt  int64             `json:"checked_at"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// The cluster is unhealthy if any of these components is unhealthy, otherwise it is only degraded.
+var criticalComponents = map[string]struct{}{
+	"pd":   {},
+	"tikv": {},
+	"tidb": {},
+}
+
+type instanceState struct {
+	address  string
+	status   topology.ComponentStatus
+	probeErr error
+}
+
+type evaluateOptions struct {
+	component string
+	// Whether a majority of instances must be healthy, like PD
+	quorum bool
+	// Whether the component is checked even if no instance is deployed
+	required bool
+}
+
+func fetchFailedHealth(component string, err error) ComponentHealth {
+	verdict := HealthVerdictUnknown
+	if _, ok := criticalComponents[component]; ok {
+		verdict = HealthVerdictUnhealthy
+	}
+	return ComponentHealth{
+		Component: component,
+		Verdict:   verdict,
+		Reasons:   []HealthReason{{Code: HealthReasonFetchFailed, Message: err.Error()}},
+	}
+}
+
+func evaluateInstances(op evaluateOptions, instances []instanceState) ComponentHealth {
+	h := ComponentHealth{Component: op.component, Reasons: make([]HealthReason, 0)}
+	for _, instance := range instances {
+		if instance.status == topology.ComponentStatusTombstone {
+			continue
+		}
+		h.Total++
+		switch {
+		case instance.status == topology.ComponentStatusDown:
+			h.Reasons = append(h.Reasons, HealthReason{Code: HealthReasonInstanceDown, Instance: instance.address, Message: "Instance is down"})
+		case instance.status == topology.ComponentStatusUnreachable:
+			h.Reasons = append(h.Reasons, HealthReason{Code: HealthReasonInstanceUnreachable, Instance: instance.address, Message: "Instance is unreachable"})
+		case instance.probeErr != nil:
+			h.Reasons = append(h.Reasons, HealthReason{Code: HealthReasonProbeFailed, Instance: instance.address, Message: instance.probeErr.Error()})
+		case instance.status == topology.ComponentStatusOffline:
+			// Offline stores are being removed, which is expected during scaling in.
+			h.Healthy++
+			h.Reasons = append(h.Reasons, HealthReason{Code: HealthReasonInstanceOffline, Instance: instance.address, Message: "Instance is being removed"})
+		default:
+			h.Healthy++
+		}
+	}
+
+	switch {
+	case h.Total == 0 && !op.required:
+		h.Verdict = HealthVerdictUnknown
+		h.Reasons = append(h.Reasons, HealthReason{Code: HealthReasonNotDeployed, Message: "No instance is deployed"})
+	case h.Healthy == 0:
+		h.Verdict = HealthVerdictUnhealthy
+		h.Reasons = append(h.Reasons, HealthReason{Code: HealthReasonNoAliveInstance, Message: "No instance is alive"})
+	case op.quorum && h.Healthy*2 <= h.Total:
+		h.Verdict = HealthVerdictUnhealthy
+		h.Reasons = append(h.Reasons, HealthReason{Code: HealthReasonQuorumLost, Message: fmt.Sprintf("Only %d of %d instances are healthy", h.Healthy, h.Total)})
+	case h.Healthy < h.Total:
+		h.Verdict = HealthVerdictDegraded
+	default:
+		h.Verdict = HealthVerdictHealthy
+	}
+	return h
+}
+
+const (
+	memoryPressureRatio       = 0.9
+	diskPressureRatio         = 0.9
+	diskCriticalPressureRatio = 0.95
+)
+
+func evaluateHosts(hosts []*hostinfo.Info) ComponentHealth {
+	h := ComponentHealth{Component: "host", Verdict: HealthVerdictHealthy, Reasons: make([]HealthReason, 0)}
+	for _, host := range hosts {
+		h.Total++
+		verdict := HealthVerdictHealthy
+		if host.MemoryUsage != nil && host.MemoryUsage.Total > 0 {
+			ratio := float64(host.MemoryUsage.Used) / float64(host.MemoryUsage.Total)
+			if ratio >= memoryPressureRatio {
+				verdict = HealthVerdictDegraded
+				h.Reasons = append(h.Reasons, HealthReason{
+					Code:     HealthReasonMemoryPressure,
+					Instance: host.Host,
+					Message:  fmt.Sprintf("%.0f%% of memory is used", ratio*100),
+				})
+			}
+		}
+		paths := make([]string, 0, len(host.Partitions))
+		for path := range host.Partitions {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			partition := host.Partitions[path]
+			if partition.Total <= 0 {
+				continue
+			}
+			ratio := 1 - float64(partition.Free)/float64(partition.Total)
+			if ratio < diskPressureRatio {
+				continue
+			}
+			if ratio >= diskCriticalPressureRatio {
+				verdict = HealthVerdictUnhealthy
+			} else if verdict != HealthVerdictUnhealthy {
+				verdict = HealthVerdictDegraded
+			}
+			h.Reasons = append(h.Reasons, HealthReason{
+				Code:     HealthReasonDiskPressure,
+				Instance: host.Host,
+				Message:  fmt.Sprintf("%.0f%% of %s is used", ratio*100, partition.Path),
+			})
+		}
+		if verdict == HealthVerdictHealthy {
+			h.Healthy++
+		}
+		if verdict.severity() > h.Verdict.severity() {
+			h.Verdict = verdict
+		}
+	}
+	return h
+}
+
+// overallVerdict is the worst verdict of critical components. Other components can only degrade the cluster.
+func overallVerdict(components []ComponentHealth) HealthVerdict {
+	verdict := HealthVerdictHealthy
+	for _, c := range components {
+		v := c.Verdict
+		if _, ok := criticalComponents[c.Component]; !ok && v == HealthVerdictUnhealthy {
+			v = HealthVerdictDegraded
+		}
+		if v.severity() > verdict.severity() {
+			verdict = v
+		}
+	}
+	return verdict
+}
+
+func (s *Service) probe(ctx context.Context, uri string, component string) error {
+	_, err := s.params.HTTPClient.WithTimeout(healthProbeTimeout).
+		SendRequest(ctx, uri, http.MethodGet, nil, ErrHealthCheckFailed, component)
+	return err
+}
+
+func (s *Service) checkPDHealth() ComponentHealth {
+	pdInfo, err := topology.FetchPDTopology(s.params.PDClient)
+	if err != nil {
+		return fetchFailedHealth("pd", err)
+	}
+	instances := make([]instanceState, 0, len(pdInfo))
+	for _, i := range pdInfo {
+		instances = append(instances, instanceState{address: net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port))), status: i.Status})
+	}
+	return evaluateInstances(evaluateOptions{component: "pd", quorum: true, required: true}, instances)
+}
+
+func (s *Service) checkStoreHealth() []ComponentHealth {
+	tikvInfo, tiflashInfo, err := topology.FetchStoreTopology(s.params.PDClient)
+	if err != nil {
+		return []ComponentHealth{fetchFailedHealth("tikv", err), fetchFailedHealth("tiflash", err)}
+	}
+	toStates := func(stores []topology.StoreInfo) []instanceState {
+		instances := make([]instanceState, 0, len(stores))
+		for _, i := range stores {
+			instances = append(instances, instanceState{address: net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port))), status: i.Status})
+		}
+		return instances
+	}
+	return []ComponentHealth{
+		evaluateInstances(evaluateOptions{component: "tikv", required: true}, toStates(tikvInfo)),
+		evaluateInstances(evaluateOptions{component: "tiflash"}, toStates(tiflashInfo)),
+	}
+}
+
+func (s *Service) checkTiDBHealth(ctx context.Context) ComponentHealth {
+	tidbInfo, err := topology.FetchTiDBTopology(ctx, s.params.EtcdClient)
+	if err != nil {
+		return fetchFailedHealth("tidb", err)
+	}
+	instances := make([]instanceState, 0, len(tidbInfo))
+	for _, i := range tidbInfo {
+		instances = append(instances, instanceState{address: net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port))), status: i.Status})
+	}
+	return evaluateInstances(evaluateOptions{component: "tidb", required: true}, instances)
+}
+
+// probeInstances probes alive instances concurrently.
+func probeInstances(instances []instanceState, probe func(i int) error) {
+	var wg sync.WaitGroup
+	for i := range instances {
+		if instances[i].status != topology.ComponentStatusUp {
+			continue
+		}
+		wg.Go(func() {
+			instances[i].probeErr = probe(i)
+		})
+	}
+	wg.Wait()
+}
+
+func (s *Service) checkTiCDCHealth(ctx context.Context) ComponentHealth {
+	ticdcInfo, err := topology.FetchTiCDCTopology(ctx, s.params.EtcdClient)
+	if err != nil {
+		return fetchFailedHealth("ticdc", err)
+	}
+	instances := make([]instanceState, 0, len(ticdcInfo))
+	for _, i := range ticdcInfo {
+		instances = append(instances, instanceState{address: net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port))), status: i.Status})
+	}
+	probeInstances(instances, func(i int) error {
+		_, err := s.params.TiCDCClient.WithTimeout(healthProbeTimeout).
+			SendGetRequest(ticdcInfo[i].IP, int(ticdcInfo[i].StatusPort), "/status")
+		return err
+	})
+	return evaluateInstances(evaluateOptions{component: "ticdc"}, instances)
+}
+
+func (s *Service) checkTiProxyHealth(ctx context.Context) ComponentHealth {
+	tiproxyInfo, err := topology.FetchTiProxyTopology(ctx, s.params.EtcdClient)
+	if err != nil {
+		return fetchFailedHealth("tiproxy", err)
+	}
+	instances := make([]instanceState, 0, len(tiproxyInfo))
+	for _, i := range tiproxyInfo {
+		instances = append(instances, instanceState{address: net.JoinHostPort(i.IP, strconv.Itoa(int(i.Port))), status: i.Status})
+	}
+	probeInstances(instances, func(i int) error {
+		_, err := s.params.TiProxyClient.WithTimeout(healthProbeTimeout).
+			SendGetRequest(tiproxyInfo[i].IP, int(tiproxyInfo[i].StatusPort), "/api/debug/health")
+		return err
+	})
+	return evaluateInstances(evaluateOptions{component: "tiproxy"}, instances)
+}
+
+// checkPrometheusHealth probes the Prometheus used by the metrics, which may be customized by users.
+func (s *Service) checkPrometheusHealth(ctx context.Context) ComponentHealth {
+	addr, err := s.params.Metrics.ResolvePromAddress()
+	if err != nil {
+		return fetchFailedHealth("prometheus", err)
+	}
+	instances := make([]instanceState, 0, 1)
+	if addr != "" {
+		instances = append(instances, instanceState{address: addr, status: topology.ComponentStatusUp})
+		probeInstances(instances, func(i int) error {
+			return s.probe(ctx, instances[i].address+"/-/ready", "Prometheus")
+		})
+	}
+	return evaluateInstances(evaluateOptions{component: "prometheus"}, instances)
+}
+
+// checkHostHealth checks disk and memory pressure of hosts. Host info is read through SQL, so it is only
+// available when the database connection is provided.
+func (s *Service) checkHostHealth(db *gorm.DB, dbErr error) ComponentHealth {
+	if db == nil {
+		message := fmt.Sprintf("Host info is read from %s with SQL credentials", distro.R().TiDB)
+		if dbErr != nil {
+			message = dbErr.Error()
+		}
+		return ComponentHealth{
+			Component: "host",
+			Verdict:   HealthVerdictUnknown,
+			Reasons:   []HealthReason{{Code: HealthReasonHostInfoUnavailable, Message: message}},
+		}
+	}
+	hosts, err := s.fetchAllHostsInfo(db)
+	if err != nil && hosts == nil {
+		return fetchFailedHealth("host", err)
+	}
+	return evaluateHosts(hosts)
+}
+
+func (s *Service) checkHealth(ctx context.Context, db *gorm.DB, dbErr error) *ClusterHealth {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	components := make([]ComponentHealth, 0)
+	check := func(f func() []ComponentHealth) {
+		wg.Go(func() {
+			r := f()
+			mu.Lock()
+			components = append(components, r...)
+			mu.Unlock()
+		})
+	}
+	check(func() []ComponentHealth { return []ComponentHealth{s.checkPDHealth()} })
+	check(s.checkStoreHealth)
+	check(func() []ComponentHealth { return []ComponentHealth{s.checkTiDBHealth(ctx)} })
+	check(func() []ComponentHealth { return []ComponentHealth{s.checkTiCDCHealth(ctx)} })
+	check(func() []ComponentHealth { return []ComponentHealth{s.checkTiProxyHealth(ctx)} })
+	check(func() []ComponentHealth { return []ComponentHealth{s.checkPrometheusHealth(ctx)} })
+	check(func() []ComponentHealth { return []ComponentHealth{s.checkHostHealth(db, dbErr)} })
+	wg.Wait()
+
+	sortComponentHealth(components)
+	return &ClusterHealth{
+		Verdict:    overallVerdict(components),
+		CheckedAt:  time.Now().Unix(),
+		Components: components,
+	}
+}
+
+// checkPublicHealth checks the health without a SQL connection, and only reports the overall verdict.
+func (s *Service) checkPublicHealth(ctx context.Context) *ClusterHealth {
+	s.publicHealthMu.Lock()
+	defer s.publicHealthMu.Unlock()
+	if s.publicHealth != nil && time.Since(time.Unix(s.publicHealth.CheckedAt, 0)) < publicHealthCacheTTL {
+		return s.publicHealth
+	}
+	// The result is shared by requests, so that it should not be affected by the cancellation of this one.
+	health := s.checkHealth(context.WithoutCancel(ctx), nil, nil)
+	s.publicHealth = &ClusterHealth{
+		Verdict:    health.Verdict,
+		CheckedAt:  health.CheckedAt,
+		Components: []ComponentHealth{},
+	}
+	return s.publicHealth
+}
+
+var componentOrder = []string{"pd", "tikv", "tiflash", "tidb", "ticdc", "tiproxy", "prometheus", "host"}
+
+func sortComponentHealth(components []ComponentHealth) {
+	index := func(component string) int {
+		for i, c := range componentOrder {
+			if c == component {
+				return i
+			}
+		}
+		return len(componentOrder)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return index(components[i].Component) < index(components[j].Component)
+	})
+}
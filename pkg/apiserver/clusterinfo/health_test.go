@@ -0,0 +1,97 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func TestEvaluateInstances(t *testing.T) {
+	pd := evaluateOptions{component: "pd", quorum: true, required: true}
+	h := evaluateInstances(pd, []instanceState{
+		{address: "pd-1", status: topology.ComponentStatusUp},
+		{address: "pd-2", status: topology.ComponentStatusUp},
+		{address: "pd-3", status: topology.ComponentStatusUnreachable},
+	})
+	require.Equal(t, HealthVerdictDegraded, h.Verdict)
+	require.Equal(t, 3, h.Total)
+	require.Equal(t, 2, h.Healthy)
+	require.Equal(t, []HealthReason{{Code: HealthReasonInstanceUnreachable, Instance: "pd-3", Message: "Instance is unreachable"}}, h.Reasons)
+
+	h = evaluateInstances(pd, []instanceState{
+		{address: "pd-1", status: topology.ComponentStatusUp},
+		{address: "pd-2", status: topology.ComponentStatusDown},
+	})
+	require.Equal(t, HealthVerdictUnhealthy, h.Verdict)
+	require.Equal(t, HealthReasonQuorumLost, h.Reasons[len(h.Reasons)-1].Code)
+
+	h = evaluateInstances(evaluateOptions{component: "tikv", required: true}, []instanceState{
+		{address: "tikv-1", status: topology.ComponentStatusUp},
+		{address: "tikv-2", status: topology.ComponentStatusOffline},
+		{address: "tikv-3", status: topology.ComponentStatusTombstone},
+	})
+	require.Equal(t, HealthVerdictHealthy, h.Verdict)
+	require.Equal(t, 2, h.Total)
+	require.Equal(t, HealthReasonInstanceOffline, h.Reasons[0].Code)
+
+	h = evaluateInstances(evaluateOptions{component: "ticdc"}, []instanceState{
+		{address: "ticdc-1", status: topology.ComponentStatusUp, probeErr: errors.New("timeout")},
+	})
+	require.Equal(t, HealthVerdictUnhealthy, h.Verdict)
+	require.Equal(t, HealthReason{Code: HealthReasonProbeFailed, Instance: "ticdc-1", Message: "timeout"}, h.Reasons[0])
+
+	h = evaluateInstances(evaluateOptions{component: "tiproxy"}, nil)
+	require.Equal(t, HealthVerdictUnknown, h.Verdict)
+	require.Equal(t, HealthReasonNotDeployed, h.Reasons[0].Code)
+
+	h = evaluateInstances(evaluateOptions{component: "tidb", required: true}, nil)
+	require.Equal(t, HealthVerdictUnhealthy, h.Verdict)
+	require.Equal(t, HealthReasonNoAliveInstance, h.Reasons[0].Code)
+}
+
+func TestEvaluateHosts(t *testing.T) {
+	healthy := hostinfo.NewHostInfo("10.0.0.1")
+	healthy.MemoryUsage = &hostinfo.MemoryUsageInfo{Total: 100, Used: 50}
+	healthy.Partitions["/data"] = &hostinfo.PartitionInfo{Path: "/data", Total: 100, Free: 50}
+	busy := hostinfo.NewHostInfo("10.0.0.2")
+	busy.MemoryUsage = &hostinfo.MemoryUsageInfo{Total: 100, Used: 95}
+	busy.Partitions["/data"] = &hostinfo.PartitionInfo{Path: "/data", Total: 100, Free: 8}
+
+	h := evaluateHosts([]*hostinfo.Info{healthy, busy})
+	require.Equal(t, HealthVerdictDegraded, h.Verdict)
+	require.Equal(t, 2, h.Total)
+	require.Equal(t, 1, h.Healthy)
+	require.Len(t, h.Reasons, 2)
+	require.Equal(t, HealthReasonMemoryPressure, h.Reasons[0].Code)
+	require.Equal(t, HealthReasonDiskPressure, h.Reasons[1].Code)
+
+	busy.Partitions["/data"].Free = 2
+	h = evaluateHosts([]*hostinfo.Info{healthy, busy})
+	require.Equal(t, HealthVerdictUnhealthy, h.Verdict)
+}
+
+func TestOverallVerdict(t *testing.T) {
+	components := []ComponentHealth{
+		{Component: "host", Verdict: HealthVerdictUnknown},
+		{Component: "ticdc", Verdict: HealthVerdictUnhealthy},
+		{Component: "pd", Verdict: HealthVerdictHealthy},
+		{Component: "tikv", Verdict: HealthVerdictHealthy},
+	}
+	require.Equal(t, HealthVerdictDegraded, overallVerdict(components))
+
+	components = append(components, ComponentHealth{Component: "tidb", Verdict: HealthVerdictUnhealthy})
+	require.Equal(t, HealthVerdictUnhealthy, overallVerdict(components))
+
+	sortComponentHealth(components)
+	order := make([]string, 0, len(components))
+	for _, c := range components {
+		order = append(order, c.Component)
+	}
+	require.Equal(t, []string{"pd", "tikv", "tidb", "ticdc", "host"}, order)
+}
@@ -0,0 +1,343 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+)
+
+const (
+	historySampleInitialDelay = time.Minute
+	// Hosts are sampled through the diagnostics service of instances, since the dashboard does not keep SQL
+	// credentials. Stores are sampled along with hosts.
+	historySampleInterval = 10 * time.Minute
+	historyRetention      = 90 * 24 * time.Hour
+)
+
+// HostSampleModel is the CPU and memory of a host at a time.
+type HostSampleModel struct {
+	ID           uint    `json:"-" gorm:"primary_key"`
+	Host         string  `json:"host" gorm:"size:255;index"`
+	SampledAt    int64   `json:"sampled_at" gorm:"index"`
+	LogicalCores int     `json:"logical_cores"`
+	CPUUsage     float64 `json:"cpu_usage"` // Ratio of non-idle CPU time
+	MemoryTotal  int     `json:"memory_total"`
+	MemoryUsed   int     `json:"memory_used"`
+}
+
+func (HostSampleModel) TableName() string {
+	return "clusterinfo_host_samples"
+}
+
+// PartitionSampleModel is the disk usage of a partition of a host at a time.
+type PartitionSampleModel struct {
+	ID        uint   `json:"-" gorm:"primary_key"`
+	Host      string `json:"host" gorm:"size:255;index"`
+	Path      string `json:"path"`
+	SampledAt int64  `json:"sampled_at" gorm:"index"`
+	Total     int    `json:"total"`
+	Free      int    `json:"free"`
+}
+
+func (PartitionSampleModel) TableName() string {
+	return "clusterinfo_partition_samples"
+}
+
+// StoreSampleModel is the capacity of a TiKV or TiFlash store reported to PD at a time.
+type StoreSampleModel struct {
+	ID        uint   `json:"-" gorm:"primary_key"`
+	StoreID   uint64 `json:"store_id" gorm:"index"`
+	Address   string `json:"address"`
+	SampledAt int64  `json:"sampled_at" gorm:"index"`
+	Capacity  uint64 `json:"capacity"`
+	Available uint64 `json:"available"`
+}
+
+func (StoreSampleModel) TableName() string {
+	return "clusterinfo_store_samples"
+}
+
+func autoMigrate(db *dbstore.DB) error {
+	return db.AutoMigrate(&HostSampleModel{}, &PartitionSampleModel{}, &StoreSampleModel{})
+}
+
+func recordHostsInfo(store *dbstore.DB, hosts []*hostinfo.Info, sampledAt time.Time) error {
+	return store.Transaction(func(tx *gorm.DB) error {
+		for _, host := range hosts {
+			if host.MemoryUsage == nil && host.CPUUsage == nil && len(host.Partitions) == 0 {
+				// Hardware info of the host is missing
+				continue
+			}
+			sample := HostSampleModel{Host: host.Host, SampledAt: sampledAt.Unix()}
+			if host.CPUInfo != nil {
+				sample.LogicalCores = host.CPUInfo.LogicalCores
+			}
+			if host.CPUUsage != nil {
+				sample.CPUUsage = 1 - host.CPUUsage.Idle
+			}
+			if host.MemoryUsage != nil {
+				sample.MemoryTotal = host.MemoryUsage.Total
+				sample.MemoryUsed = host.MemoryUsage.Used
+			}
+			if err := tx.Create(&sample).Error; err != nil {
+				return err
+			}
+			for _, partition := range host.Partitions {
+				if err := tx.Create(&PartitionSampleModel{
+					Host:      host.Host,
+					Path:      partition.Path,
+					SampledAt: sampledAt.Unix(),
+					Total:     partition.Total,
+					Free:      partition.Free,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) recordHosts(ctx context.Context) error {
+	now := time.Now()
+	hosts, err := hostinfo.FetchAllHostsInfoByDiagnostics(ctx, s.params.PDClient, s.params.EtcdClient, s.params.Config.ClusterTLSConfig)
+	if hosts == nil {
+		return err
+	}
+	if err != nil {
+		// Hosts failed to be fetched are skipped.
+		log.Warn("Failed to fetch some hosts info", zap.Error(err))
+	}
+	return recordHostsInfo(s.params.LocalStore, hosts, now)
+}
+
+var storeSizeRE = regexp.MustCompile(`^([0-9.]+)\s*([KMGTPE]?)i?B$`)
+
+// parseStoreSize parses sizes reported by PD, like `1.8TiB`.
+func parseStoreSize(size string) uint64 {
+	m := storeSizeRE.FindStringSubmatch(strings.TrimSpace(size))
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0
+	}
+	exp := strings.Index("KMGTPE", m[2]) + 1
+	if m[2] == "" {
+		exp = 0
+	}
+	return uint64(n * math.Pow(1024, float64(exp)))
+}
+
+func fetchStoreSamples(pdClient *pd.Client, sampledAt time.Time) ([]StoreSampleModel, error) {
+	data, err := pdClient.SendGetRequest("/stores")
+	if err != nil {
+		return nil, err
+	}
+	resp := struct {
+		Stores []struct {
+			Store struct {
+				ID        uint64 `json:"id"`
+				Address   string `json:"address"`
+				StateName string `json:"state_name"`
+			} `json:"store"`
+			Status struct {
+				Capacity  string `json:"capacity"`
+				Available string `json:"available"`
+			} `json:"status"`
+		} `json:"stores"`
+	}{}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, ErrInvalidData.Wrap(err, "%s stores API unmarshal failed", distro.R().PD)
+	}
+	samples := make([]StoreSampleModel, 0, len(resp.Stores))
+	for _, s := range resp.Stores {
+		if strings.EqualFold(s.Store.StateName, "tombstone") {
+			continue
+		}
+		samples = append(samples, StoreSampleModel{
+			StoreID:   s.Store.ID,
+			Address:   s.Store.Address,
+			SampledAt: sampledAt.Unix(),
+			Capacity:  parseStoreSize(s.Status.Capacity),
+			Available: parseStoreSize(s.Status.Available),
+		})
+	}
+	return samples, nil
+}
+
+func (s *Service) recordStores() error {
+	samples, err := fetchStoreSamples(s.params.PDClient, time.Now())
+	if err != nil || len(samples) == 0 {
+		return err
+	}
+	return s.params.LocalStore.Create(&samples).Error
+}
+
+func removeExpiredSamples(store *dbstore.DB, before time.Time) error {
+	for _, model := range []interface{}{&HostSampleModel{}, &PartitionSampleModel{}, &StoreSampleModel{}} {
+		if err := store.Where("sampled_at < ?", before.Unix()).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) historyLoop(ctx context.Context) {
+	timer := time.NewTimer(historySampleInitialDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if err := s.recordHosts(ctx); err != nil {
+			log.Warn("Failed to record hosts info", zap.Error(err))
+		}
+		if err := s.recordStores(); err != nil {
+			log.Warn("Failed to record store capacity", zap.Error(err))
+		}
+		if err := removeExpiredSamples(s.params.LocalStore, time.Now().Add(-historyRetention)); err != nil {
+			log.Warn("Failed to remove expired host history", zap.Error(err))
+		}
+		timer.Reset(historySampleInterval)
+	}
+}
+
+type TrendSample struct {
+	SampledAt int64  `json:"sampled_at"`
+	Used      uint64 `json:"used"`
+	Total     uint64 `json:"total"`
+}
+
+type CapacityTrend struct {
+	Samples []TrendSample `json:"samples"`
+	// Least squares fit of the used space, in bytes per day
+	GrowthPerDay float64 `json:"growth_per_day"`
+	// Days until the used space reaches the total space of the latest sample. Absent if it is not growing.
+	DaysUntilFull *float64 `json:"days_until_full,omitempty"`
+}
+
+func newCapacityTrend(samples []TrendSample) CapacityTrend {
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].SampledAt < samples[j].SampledAt
+	})
+	trend := CapacityTrend{Samples: samples}
+	if len(samples) < 2 || samples[0].SampledAt == samples[len(samples)-1].SampledAt {
+		return trend
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	base := samples[0].SampledAt
+	for _, sample := range samples {
+		x := float64(sample.SampledAt-base) / (24 * 3600)
+		y := float64(sample.Used)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	trend.GrowthPerDay = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+
+	latest := samples[len(samples)-1]
+	if trend.GrowthPerDay > 0 && latest.Total > latest.Used {
+		days := float64(latest.Total-latest.Used) / trend.GrowthPerDay
+		trend.DaysUntilFull = &days
+	}
+	return trend
+}
+
+type PartitionTrend struct {
+	Host string `json:"host"`
+	Path string `json:"path"`
+	CapacityTrend
+}
+
+type StoreTrend struct {
+	StoreID uint64 `json:"store_id"`
+	Address string `json:"address"`
+	CapacityTrend
+}
+
+type CapacityTrendResponse struct {
+	Partitions []PartitionTrend `json:"partitions"`
+	Stores     []StoreTrend     `json:"stores"`
+}
+
+func buildCapacityTrends(partitionSamples []PartitionSampleModel, storeSamples []StoreSampleModel) *CapacityTrendResponse {
+	type partitionKey struct {
+		host string
+		path string
+	}
+	partitions := make(map[partitionKey][]TrendSample)
+	for _, s := range partitionSamples {
+		key := partitionKey{host: s.Host, path: s.Path}
+		partitions[key] = append(partitions[key], TrendSample{
+			SampledAt: s.SampledAt,
+			Used:      uint64(max(s.Total-s.Free, 0)),
+			Total:     uint64(max(s.Total, 0)),
+		})
+	}
+	stores := make(map[uint64][]TrendSample)
+	storeAddresses := make(map[uint64]string)
+	for _, s := range storeSamples {
+		used := uint64(0)
+		if s.Capacity > s.Available {
+			used = s.Capacity - s.Available
+		}
+		stores[s.StoreID] = append(stores[s.StoreID], TrendSample{SampledAt: s.SampledAt, Used: used, Total: s.Capacity})
+		storeAddresses[s.StoreID] = s.Address
+	}
+
+	resp := &CapacityTrendResponse{
+		Partitions: make([]PartitionTrend, 0, len(partitions)),
+		Stores:     make([]StoreTrend, 0, len(stores)),
+	}
+	for key, samples := range partitions {
+		resp.Partitions = append(resp.Partitions, PartitionTrend{Host: key.host, Path: key.path, CapacityTrend: newCapacityTrend(samples)})
+	}
+	sort.Slice(resp.Partitions, func(i, j int) bool {
+		if resp.Partitions[i].Host != resp.Partitions[j].Host {
+			return resp.Partitions[i].Host < resp.Partitions[j].Host
+		}
+		return resp.Partitions[i].Path < resp.Partitions[j].Path
+	})
+	for id, samples := range stores {
+		resp.Stores = append(resp.Stores, StoreTrend{StoreID: id, Address: storeAddresses[id], CapacityTrend: newCapacityTrend(samples)})
+	}
+	sort.Slice(resp.Stores, func(i, j int) bool {
+		return resp.Stores[i].StoreID < resp.Stores[j].StoreID
+	})
+	return resp
+}
+
+func (s *Service) getCapacityTrends(since time.Time) (*CapacityTrendResponse, error) {
+	var partitionSamples []PartitionSampleModel
+	if err := s.params.LocalStore.Where("sampled_at >= ?", since.Unix()).Find(&partitionSamples).Error; err != nil {
+		return nil, err
+	}
+	var storeSamples []StoreSampleModel
+	if err := s.params.LocalStore.Where("sampled_at >= ?", since.Unix()).Find(&storeSamples).Error; err != nil {
+		return nil, err
+	}
+	return buildCapacityTrends(partitionSamples, storeSamples), nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+const day = 24 * 3600
+
+func TestParseStoreSize(t *testing.T) {
+	require.Equal(t, uint64(0), parseStoreSize("0B"))
+	require.Equal(t, uint64(512), parseStoreSize("512B"))
+	require.Equal(t, uint64(1536<<20), parseStoreSize("1.5GiB"))
+	require.Equal(t, uint64(2<<40), parseStoreSize("2TiB"))
+	require.Equal(t, uint64(0), parseStoreSize("unknown"))
+}
+
+func TestCapacityTrend(t *testing.T) {
+	trend := newCapacityTrend([]TrendSample{
+		{SampledAt: 2 * day, Used: 300, Total: 1000},
+		{SampledAt: 0, Used: 100, Total: 1000},
+		{SampledAt: day, Used: 200, Total: 1000},
+	})
+	require.Equal(t, int64(0), trend.Samples[0].SampledAt)
+	require.InDelta(t, 100, trend.GrowthPerDay, 1e-6)
+	require.NotNil(t, trend.DaysUntilFull)
+	require.InDelta(t, 7, *trend.DaysUntilFull, 1e-6)
+
+	trend = newCapacityTrend([]TrendSample{
+		{SampledAt: 0, Used: 300, Total: 1000},
+		{SampledAt: day, Used: 200, Total: 1000},
+	})
+	require.Less(t, trend.GrowthPerDay, 0.0)
+	require.Nil(t, trend.DaysUntilFull)
+
+	trend = newCapacityTrend([]TrendSample{{SampledAt: 0, Used: 300, Total: 1000}})
+	require.Zero(t, trend.GrowthPerDay)
+	require.Nil(t, trend.DaysUntilFull)
+}
+
+func TestHostHistory(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open(path.Join(t.TempDir(), "test.sqlite.db")), &gorm.Config{})
+	require.NoError(t, err)
+	store := &dbstore.DB{DB: gormDB}
+	require.NoError(t, autoMigrate(store))
+
+	host := hostinfo.NewHostInfo("10.0.0.1")
+	host.MemoryUsage = &hostinfo.MemoryUsageInfo{Total: 64 << 30, Used: 16 << 30}
+	host.CPUUsage = &hostinfo.CPUUsageInfo{Idle: 0.75}
+	missing := hostinfo.NewHostInfo("10.0.0.2")
+	for i := 0; i < 3; i++ {
+		host.Partitions["/data"] = &hostinfo.PartitionInfo{Path: "/data", Total: 1000, Free: 900 - 100*i}
+		require.NoError(t, recordHostsInfo(store, []*hostinfo.Info{host, missing}, time.Unix(int64(i*day), 0)))
+	}
+	require.NoError(t, store.Create(&[]StoreSampleModel{
+		{StoreID: 1, Address: "10.0.0.1:20160", SampledAt: 0, Capacity: 1000, Available: 500},
+		{StoreID: 1, Address: "10.0.0.1:20160", SampledAt: day, Capacity: 1000, Available: 450},
+	}).Error)
+
+	var hostSamples []HostSampleModel
+	require.NoError(t, store.Find(&hostSamples).Error)
+	require.Len(t, hostSamples, 3)
+	require.Equal(t, "10.0.0.1", hostSamples[0].Host)
+	require.InDelta(t, 0.25, hostSamples[0].CPUUsage, 1e-6)
+
+	s := &Service{params: ServiceParams{LocalStore: store}}
+	trends, err := s.getCapacityTrends(time.Unix(0, 0))
+	require.NoError(t, err)
+	require.Len(t, trends.Partitions, 1)
+	require.Equal(t, "/data", trends.Partitions[0].Path)
+	require.InDelta(t, 7, *trends.Partitions[0].DaysUntilFull, 1e-6)
+	require.Len(t, trends.Stores, 1)
+	require.InDelta(t, 50, trends.Stores[0].GrowthPerDay, 1e-6)
+	require.InDelta(t, 9, *trends.Stores[0].DaysUntilFull, 1e-6)
+
+	require.NoError(t, removeExpiredSamples(store, time.Unix(day, 0)))
+	require.NoError(t, store.Find(&hostSamples).Error)
+	require.Len(t, hostSamples, 2)
+	var storeSamples []StoreSampleModel
+	require.NoError(t, store.Find(&storeSamples).Error)
+	require.Len(t, storeSamples, 1)
+}
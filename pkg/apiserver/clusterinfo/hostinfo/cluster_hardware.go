@@ -44,50 +44,55 @@ func FillFromClusterHardwareTable(db *gorm.DB, m InfoMap) error {
 	}
 
 	for _, row := range rows {
-		hostname, _, err := netutil.ParseHostAndPortFromAddress(row.Instance)
+		fillFromHardwareRow(m, row)
+	}
+
+	return nil
+}
+
+// fillFromHardwareRow fills the hardware of a device reported by an instance into the host of the instance.
+func fillFromHardwareRow(m InfoMap, row clusterTableModel) {
+	hostname, _, err := netutil.ParseHostAndPortFromAddress(row.Instance)
+	if err != nil {
+		return
+	}
+	if _, ok := m[hostname]; !ok {
+		m[hostname] = NewHostInfo(hostname)
+	}
+
+	switch {
+	case row.DeviceType == "cpu" && row.DeviceName == "cpu":
+		if m[hostname].CPUInfo != nil {
+			return
+		}
+		var v clusterHardwareCPUInfoModel
+		err := json.Unmarshal([]byte(row.JSONValue), &v)
 		if err != nil {
-			continue
+			return
 		}
-		if _, ok := m[hostname]; !ok {
-			m[hostname] = NewHostInfo(hostname)
+		m[hostname].CPUInfo = &CPUInfo{
+			Arch:          v.Arch,
+			LogicalCores:  v.LogicalCores,
+			PhysicalCores: v.PhysicalCores,
 		}
-
-		switch {
-		case row.DeviceType == "cpu" && row.DeviceName == "cpu":
-			if m[hostname].CPUInfo != nil {
-				continue
-			}
-			var v clusterHardwareCPUInfoModel
-			err := json.Unmarshal([]byte(row.JSONValue), &v)
-			if err != nil {
-				continue
-			}
-			m[hostname].CPUInfo = &CPUInfo{
-				Arch:          v.Arch,
-				LogicalCores:  v.LogicalCores,
-				PhysicalCores: v.PhysicalCores,
-			}
-		case row.DeviceType == "disk":
-			if m[hostname].PartitionProviderType != "" && m[hostname].PartitionProviderType != row.Type {
-				// Another instance on the same host has already provided disk information, skip.
-				continue
-			}
-			var v clusterHardwareDiskModel
-			err := json.Unmarshal([]byte(row.JSONValue), &v)
-			if err != nil {
-				continue
-			}
-			if m[hostname].PartitionProviderType == "" {
-				m[hostname].PartitionProviderType = row.Type
-			}
-			m[hostname].Partitions[strings.ToLower(v.Path)] = &PartitionInfo{
-				Path:   v.Path,
-				FSType: v.FSType,
-				Free:   v.Free,
-				Total:  v.Total,
-			}
+	case row.DeviceType == "disk":
+		if m[hostname].PartitionProviderType != "" && m[hostname].PartitionProviderType != row.Type {
+			// Another instance on the same host has already provided disk information, skip.
+			return
+		}
+		var v clusterHardwareDiskModel
+		err := json.Unmarshal([]byte(row.JSONValue), &v)
+		if err != nil {
+			return
+		}
+		if m[hostname].PartitionProviderType == "" {
+			m[hostname].PartitionProviderType = row.Type
+		}
+		m[hostname].Partitions[strings.ToLower(v.Path)] = &PartitionInfo{
+			Path:   v.Path,
+			FSType: v.FSType,
+			Free:   v.Free,
+			Total:  v.Total,
 		}
 	}
-
-	return nil
 }
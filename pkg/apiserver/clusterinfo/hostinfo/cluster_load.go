@@ -40,42 +40,47 @@ func FillFromClusterLoadTable(db *gorm.DB, m InfoMap) error {
 	}
 
 	for _, row := range rows {
-		hostname, _, err := netutil.ParseHostAndPortFromAddress(row.Instance)
+		fillFromLoadRow(m, row)
+	}
+	return nil
+}
+
+// fillFromLoadRow fills the load of a device reported by an instance into the host of the instance.
+func fillFromLoadRow(m InfoMap, row clusterTableModel) {
+	hostname, _, err := netutil.ParseHostAndPortFromAddress(row.Instance)
+	if err != nil {
+		return
+	}
+	if _, ok := m[hostname]; !ok {
+		m[hostname] = NewHostInfo(hostname)
+	}
+
+	switch {
+	case row.DeviceType == "memory" && row.DeviceName == "virtual":
+		if m[hostname].MemoryUsage != nil {
+			return
+		}
+		var v clusterLoadMemoryVirtualModel
+		err := json.Unmarshal([]byte(row.JSONValue), &v)
 		if err != nil {
-			continue
+			return
 		}
-		if _, ok := m[hostname]; !ok {
-			m[hostname] = NewHostInfo(hostname)
+		m[hostname].MemoryUsage = &MemoryUsageInfo{
+			Used:  v.Used,
+			Total: v.Total,
 		}
-
-		switch {
-		case row.DeviceType == "memory" && row.DeviceName == "virtual":
-			if m[hostname].MemoryUsage != nil {
-				continue
-			}
-			var v clusterLoadMemoryVirtualModel
-			err := json.Unmarshal([]byte(row.JSONValue), &v)
-			if err != nil {
-				continue
-			}
-			m[hostname].MemoryUsage = &MemoryUsageInfo{
-				Used:  v.Used,
-				Total: v.Total,
-			}
-		case row.DeviceType == "cpu" && row.DeviceName == "usage":
-			if m[hostname].CPUUsage != nil {
-				continue
-			}
-			var v clusterLoadCPUUsageModel
-			err := json.Unmarshal([]byte(row.JSONValue), &v)
-			if err != nil {
-				continue
-			}
-			m[hostname].CPUUsage = &CPUUsageInfo{
-				Idle:   v.Idle,
-				System: v.System,
-			}
+	case row.DeviceType == "cpu" && row.DeviceName == "usage":
+		if m[hostname].CPUUsage != nil {
+			return
+		}
+		var v clusterLoadCPUUsageModel
+		err := json.Unmarshal([]byte(row.JSONValue), &v)
+		if err != nil {
+			return
+		}
+		m[hostname].CPUUsage = &CPUUsageInfo{
+			Idle:   v.Idle,
+			System: v.System,
 		}
 	}
-	return nil
 }
@@ -0,0 +1,159 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package hostinfo
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/diagnosticspb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+const diagnosticsTimeout = 10 * time.Second
+
+// diagnosticsTarget is an instance serving the diagnostics gRPC service, which is the source of the
+// INFORMATION_SCHEMA.CLUSTER_HARDWARE and INFORMATION_SCHEMA.CLUSTER_LOAD tables.
+type diagnosticsTarget struct {
+	Type    string
+	IP      string
+	Address string
+}
+
+// fetchDiagnosticsTargets picks an instance for each host. Like the cluster tables, disks are preferred to be
+// reported by TiKV and TiFlash, whose data directories are the concern.
+func fetchDiagnosticsTargets(ctx context.Context, pdClient *pd.Client, etcdClient *clientv3.Client) ([]diagnosticsTarget, error) {
+	targets := make([]diagnosticsTarget, 0)
+	add := func(typ, ip string, port uint, status topology.ComponentStatus) {
+		if status == topology.ComponentStatusTombstone {
+			return
+		}
+		targets = append(targets, diagnosticsTarget{Type: typ, IP: ip, Address: net.JoinHostPort(ip, strconv.Itoa(int(port)))})
+	}
+
+	tikvInfo, tiFlashInfo, err := topology.FetchStoreTopology(pdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range tikvInfo {
+		add("tikv", i.IP, i.Port, i.Status)
+	}
+	for _, i := range tiFlashInfo {
+		add("tiflash", i.IP, i.Port, i.Status)
+	}
+	pdInfo, err := topology.FetchPDTopology(pdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range pdInfo {
+		add("pd", i.IP, i.Port, i.Status)
+	}
+	tidbInfo, err := topology.FetchTiDBTopology(ctx, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range tidbInfo {
+		// TiDB serves the diagnostics service on the status port.
+		add("tidb", i.IP, i.StatusPort, i.Status)
+	}
+
+	picked := make(map[string]struct{})
+	r := make([]diagnosticsTarget, 0)
+	for _, target := range targets {
+		if _, ok := picked[target.IP]; ok {
+			continue
+		}
+		picked[target.IP] = struct{}{}
+		r = append(r, target)
+	}
+	return r, nil
+}
+
+// fillFromDiagnostics requests the hardware and load of the host of an instance.
+func fillFromDiagnostics(ctx context.Context, tlsConfig *tls.Config, target diagnosticsTarget, m InfoMap) error {
+	secureOpt := grpc.WithTransportCredentials(insecure.NewCredentials())
+	if tlsConfig != nil {
+		secureOpt = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+	conn, err := grpc.NewClient(target.Address, secureOpt)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := diagnosticspb.NewDiagnosticsClient(conn)
+
+	for _, tp := range []diagnosticspb.ServerInfoType{diagnosticspb.ServerInfoType_HardwareInfo, diagnosticspb.ServerInfoType_LoadInfo} {
+		reqCtx, cancel := context.WithTimeout(ctx, diagnosticsTimeout)
+		resp, err := client.ServerInfo(reqCtx, &diagnosticspb.ServerInfoRequest{Tp: tp})
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, item := range resp.Items {
+			values := make(map[string]string, len(item.Pairs))
+			for _, pair := range item.Pairs {
+				values[pair.Key] = pair.Value
+			}
+			data, err := json.Marshal(values)
+			if err != nil {
+				return err
+			}
+			row := clusterTableModel{
+				Type:       target.Type,
+				Instance:   target.Address,
+				DeviceType: item.Tp,
+				DeviceName: item.Name,
+				JSONValue:  string(data),
+			}
+			if tp == diagnosticspb.ServerInfoType_HardwareInfo {
+				fillFromHardwareRow(m, row)
+			} else {
+				fillFromLoadRow(m, row)
+			}
+		}
+	}
+	return nil
+}
+
+// FetchAllHostsInfoByDiagnostics fetches the hardware and load of all hosts through the diagnostics service of
+// instances. Unlike FetchAllHostsInfo, it does not need a SQL connection, so that it can be used in background,
+// but instances of hosts are not filled.
+// Note: The returned data and error may both exist.
+func FetchAllHostsInfoByDiagnostics(ctx context.Context, pdClient *pd.Client, etcdClient *clientv3.Client, tlsConfig *tls.Config) ([]*Info, error) {
+	targets, err := fetchDiagnosticsTargets(ctx, pdClient, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(InfoMap)
+	for _, target := range targets {
+		if e := fillFromDiagnostics(ctx, tlsConfig, target, m); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	r := make([]*Info, 0, len(targets))
+	for _, target := range targets {
+		if info, ok := m[target.IP]; ok {
+			r = append(r, info)
+		} else {
+			// Missing item
+			r = append(r, NewHostInfo(target.IP))
+		}
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Host < r[j].Host
+	})
+	return r, err
+}
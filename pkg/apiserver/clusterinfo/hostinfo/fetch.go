@@ -0,0 +1,128 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package hostinfo
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/log"
+	"github.com/samber/lo"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+// fetchAllInstanceHosts fetches all hosts in the cluster and return in ascending order.
+func fetchAllInstanceHosts(ctx context.Context, pdClient *pd.Client, etcdClient *clientv3.Client) ([]string, error) {
+	allHostsMap := make(map[string]struct{})
+	pdInfo, err := topology.FetchPDTopology(pdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range pdInfo {
+		allHostsMap[i.IP] = struct{}{}
+	}
+
+	tikvInfo, tiFlashInfo, err := topology.FetchStoreTopology(pdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range tikvInfo {
+		allHostsMap[i.IP] = struct{}{}
+	}
+	for _, i := range tiFlashInfo {
+		allHostsMap[i.IP] = struct{}{}
+	}
+
+	tidbInfo, err := topology.FetchTiDBTopology(ctx, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range tidbInfo {
+		allHostsMap[i.IP] = struct{}{}
+	}
+
+	ticdcInfo, err := topology.FetchTiCDCTopology(ctx, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range ticdcInfo {
+		allHostsMap[i.IP] = struct{}{}
+	}
+
+	tiproxyInfo, err := topology.FetchTiProxyTopology(ctx, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range tiproxyInfo {
+		allHostsMap[i.IP] = struct{}{}
+	}
+
+	tsoInfo, err := topology.FetchTSOTopology(ctx, pdClient)
+	if err != nil {
+		if strings.Contains(err.Error(), "status code 404") {
+			tsoInfo = []topology.TSOInfo{}
+		} else {
+			return nil, err
+		}
+	}
+	for _, i := range tsoInfo {
+		allHostsMap[i.IP] = struct{}{}
+	}
+
+	schedulingInfo, err := topology.FetchSchedulingTopology(ctx, pdClient)
+	if err != nil {
+		if strings.Contains(err.Error(), "status code 404") {
+			schedulingInfo = []topology.SchedulingInfo{}
+		} else {
+			return nil, err
+		}
+	}
+	for _, i := range schedulingInfo {
+		allHostsMap[i.IP] = struct{}{}
+	}
+
+	allHosts := lo.Keys(allHostsMap)
+	sort.Strings(allHosts)
+
+	return allHosts, nil
+}
+
+// FetchAllHostsInfo fetches all hosts of the cluster and their information.
+// Note: The returned data and error may both exist.
+func FetchAllHostsInfo(ctx context.Context, pdClient *pd.Client, etcdClient *clientv3.Client, db *gorm.DB) ([]*Info, error) {
+	allHosts, err := fetchAllInstanceHosts(ctx, pdClient, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+
+	allHostsInfoMap := make(map[string]*Info)
+	if e := FillFromClusterLoadTable(db, allHostsInfoMap); e != nil {
+		log.Warn("Failed to read cluster_load table", zap.Error(e))
+		err = e
+	}
+	if e := FillFromClusterHardwareTable(db, allHostsInfoMap); e != nil && err == nil {
+		log.Warn("Failed to read cluster_hardware table", zap.Error(e))
+		err = e
+	}
+	if e := FillInstances(db, allHostsInfoMap); e != nil && err == nil {
+		log.Warn("Failed to fill instances for hosts", zap.Error(e))
+		err = e
+	}
+
+	r := make([]*Info, 0, len(allHosts))
+	for _, host := range allHosts {
+		if im, ok := allHostsInfoMap[host]; ok {
+			r = append(r, im)
+		} else {
+			// Missing item
+			r = append(r, NewHostInfo(host))
+		}
+	}
+	return r, err
+}
@@ -0,0 +1,610 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/util/client/pdclient"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+)
+
+const (
+	placementScanPageSize      = 1024
+	defaultPlacementMaxRegions = 100000
+	maxPlacementViolations     = 100
+	// A zone is over- or under-loaded if its regions or leaders per store deviate more than this from the average.
+	zoneLoadTolerance = 0.2
+	defaultRuleGroup  = "pd"
+	// The implicit isolation level after all location labels, which isolates replicas by the IP of stores.
+	hostIsolationLevel = "ip"
+	noIsolationLevel   = "none"
+)
+
+type ZoneLoadStatus string
+
+const (
+	ZoneLoadStatusBalanced    ZoneLoadStatus = "balanced"
+	ZoneLoadStatusOverloaded  ZoneLoadStatus = "overloaded"
+	ZoneLoadStatusUnderloaded ZoneLoadStatus = "underloaded"
+)
+
+type ZoneLoad struct {
+	Zone            string         `json:"zone"`
+	StoreCount      int            `json:"store_count"`
+	RegionCount     int            `json:"region_count"` // Number of replicas in the zone
+	LeaderCount     int            `json:"leader_count"`
+	RegionsPerStore float64        `json:"regions_per_store"`
+	LeadersPerStore float64        `json:"leaders_per_store"`
+	RegionStatus    ZoneLoadStatus `json:"region_status"`
+	LeaderStatus    ZoneLoadStatus `json:"leader_status"`
+}
+
+type PlacementViolationKind string
+
+const (
+	// Replicas share the value of the first location label, usually the zone.
+	PlacementViolationSharedLocation PlacementViolationKind = "shared_location"
+	// Replicas are on stores of the same IP.
+	PlacementViolationSharedHost PlacementViolationKind = "shared_host"
+	// Replicas are not isolated at the isolation level of the placement rule.
+	PlacementViolationIsolationLevel PlacementViolationKind = "isolation_level"
+)
+
+type PlacementViolation struct {
+	RegionID uint64                 `json:"region_id"`
+	GroupID  string                 `json:"group_id"`
+	Kind     PlacementViolationKind `json:"kind"`
+	Label    string                 `json:"label,omitempty"`
+	Value    string                 `json:"value,omitempty"`
+	StoreIDs []uint64               `json:"store_ids"`
+	Message  string                 `json:"message"`
+}
+
+type RegionGroupIsolation struct {
+	GroupID string `json:"group_id"`
+	// Isolation level required by placement rules of the group
+	IsolationLevel string `json:"isolation_level,omitempty"`
+	RegionCount    int    `json:"region_count"`
+	// Average of region scores. A region scores 1 if its replicas are isolated at the first location label,
+	// and 0 if its replicas share a host.
+	Score float64 `json:"score"`
+	// Number of regions by the highest level their replicas are isolated at
+	LevelCounts map[string]int `json:"level_counts"`
+}
+
+type PlacementAnalysis struct {
+	LocationLabels []string               `json:"location_labels"`
+	ZoneLabel      string                 `json:"zone_label,omitempty"`
+	StoreCount     int                    `json:"store_count"`
+	RegionCount    int                    `json:"region_count"`
+	Truncated      bool                   `json:"truncated"`
+	Zones          []ZoneLoad             `json:"zones"`
+	Groups         []RegionGroupIsolation `json:"groups"`
+	ViolationCount int                    `json:"violation_count"`
+	Violations     []PlacementViolation   `json:"violations"` // At most maxPlacementViolations
+}
+
+type placementStore struct {
+	id     uint64
+	ip     string
+	labels map[string]string
+	// Values of location labels, followed by the IP
+	location []string
+}
+
+type placementRule struct {
+	groupID        string
+	id             string
+	index          int
+	startKey       []byte
+	endKey         []byte
+	role           string
+	count          int
+	constraints    []pdclient.GetConfigRulesResponseLabelConstraint
+	isolationLevel string
+	// Number of distinct values at each location level among stores matching the label constraints
+	distinctLocations []int
+}
+
+func (r placementRule) covers(key []byte) bool {
+	return bytes.Compare(r.startKey, key) <= 0 && (len(r.endKey) == 0 || bytes.Compare(key, r.endKey) < 0)
+}
+
+// matchStore checks the store against label constraints of the rule, the same way as PD.
+func (r placementRule) matchStore(s *placementStore) bool {
+	for _, c := range r.constraints {
+		value, ok := s.labels[c.Key]
+		in := false
+		for _, v := range c.Values {
+			if v == value {
+				in = true
+				break
+			}
+		}
+		switch c.Op {
+		case "in":
+			if !ok || !in {
+				return false
+			}
+		case "notIn":
+			if ok && in {
+				return false
+			}
+		case "exists":
+			if !ok {
+				return false
+			}
+		case "notExists":
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r placementRule) matchRole(s *placementStore, leaderStoreID uint64) bool {
+	switch strings.ToLower(r.role) {
+	case "leader":
+		return s.id == leaderStoreID
+	case "follower":
+		return s.id != leaderStoreID
+	default:
+		return true
+	}
+}
+
+// isolatable returns whether the replicas of the rule can be placed at distinct locations of the level, which is
+// not the case when the rule requires more replicas than the locations its label constraints allow,
+// e.g. the rule keeping all replicas in the primary region.
+func (r placementRule) isolatable(level int, replicas int) bool {
+	count := r.count
+	if count < replicas {
+		count = replicas
+	}
+	return count <= r.distinctLocations[level]
+}
+
+func decodeKey(key string) ([]byte, error) {
+	k, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, ErrInvalidData.Wrap(err, "%s key %s is not hex encoded", distro.R().PD, key)
+	}
+	return k, nil
+}
+
+func newPlacementRules(rules pdclient.GetConfigRulesResponse) ([]placementRule, error) {
+	result := make([]placementRule, 0, len(rules))
+	for _, rule := range rules {
+		if strings.EqualFold(rule.Role, "learner") {
+			continue
+		}
+		startKey, err := decodeKey(rule.StartKey)
+		if err != nil {
+			return nil, err
+		}
+		endKey, err := decodeKey(rule.EndKey)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, placementRule{
+			groupID:        rule.GroupID,
+			id:             rule.ID,
+			index:          rule.Index,
+			startKey:       startKey,
+			endKey:         endKey,
+			role:           rule.Role,
+			count:          rule.Count,
+			constraints:    rule.LabelConstraints,
+			isolationLevel: rule.IsolationLevel,
+		})
+	}
+	// Rules with larger index take precedence.
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].index != result[j].index {
+			return result[i].index > result[j].index
+		}
+		return result[i].groupID < result[j].groupID
+	})
+	return result, nil
+}
+
+// fitOrder sorts rules applying to a region in the order they pick replicas, which approximates the rule fit of
+// PD: leader rules first, then rules with more label constraints.
+func fitOrder(rules []placementRule) []placementRule {
+	sorted := append([]placementRule{}, rules...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		li, lj := strings.EqualFold(sorted[i].role, "leader"), strings.EqualFold(sorted[j].role, "leader")
+		if li != lj {
+			return li
+		}
+		return len(sorted[i].constraints) > len(sorted[j].constraints)
+	})
+	return sorted
+}
+
+// distinctLocations returns the number of distinct non-empty values at each location level among the stores.
+func distinctLocations(stores []*placementStore, levels int) []int {
+	result := make([]int, levels)
+	for level := 0; level < levels; level++ {
+		seen := make(map[string]struct{})
+		for _, s := range stores {
+			if v := s.location[level]; v != "" {
+				seen[v] = struct{}{}
+			}
+		}
+		result[level] = len(seen)
+	}
+	return result
+}
+
+// isolatedDepth returns the index of the first level at which all stores have distinct locations, or -1 if they
+// are not isolated at any level.
+func isolatedDepth(stores []*placementStore, levels int) int {
+	for depth := 0; depth < levels; depth++ {
+		seen := make(map[string]struct{}, len(stores))
+		distinct := true
+		for _, s := range stores {
+			key := strings.Join(s.location[:depth+1], "\x00")
+			if _, ok := seen[key]; ok {
+				distinct = false
+				break
+			}
+			seen[key] = struct{}{}
+		}
+		if distinct {
+			return depth
+		}
+	}
+	return -1
+}
+
+// sharedStores returns groups of stores sharing the same value returned by key, ignoring empty values.
+func sharedStores(stores []*placementStore, key func(s *placementStore) string) map[string][]uint64 {
+	groups := make(map[string][]uint64)
+	for _, s := range stores {
+		if v := key(s); v != "" {
+			groups[v] = append(groups[v], s.id)
+		}
+	}
+	for v, ids := range groups {
+		if len(ids) < 2 {
+			delete(groups, v)
+		}
+	}
+	return groups
+}
+
+func zoneLoadStatus(value, average float64) ZoneLoadStatus {
+	switch {
+	case average <= 0:
+		return ZoneLoadStatusBalanced
+	case value > average*(1+zoneLoadTolerance):
+		return ZoneLoadStatusOverloaded
+	case value < average*(1-zoneLoadTolerance):
+		return ZoneLoadStatusUnderloaded
+	default:
+		return ZoneLoadStatusBalanced
+	}
+}
+
+// analyzePlacement checks voter replicas of regions on TiKV stores against location labels and placement rules.
+// Replicas are only reported as not isolated when the rule placing them allows them to be, according to its label
+// constraints and count. TiFlash stores and learners are not considered.
+func analyzePlacement(
+	locationLabels []string,
+	stores []pdclient.GetStoresResponseStore,
+	rules pdclient.GetConfigRulesResponse,
+	regions []pdclient.GetRegionsResponseRegion,
+) (*PlacementAnalysis, error) {
+	labels := make([]string, 0, len(locationLabels))
+	for _, l := range locationLabels {
+		if l = strings.TrimSpace(l); l != "" {
+			labels = append(labels, l)
+		}
+	}
+	levels := append(append([]string{}, labels...), hostIsolationLevel)
+
+	placementRules, err := newPlacementRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	tikvStores := make(map[uint64]*placementStore)
+	for _, s := range stores {
+		if strings.EqualFold(s.StateName, "tombstone") {
+			continue
+		}
+		storeLabels := make(map[string]string, len(s.Labels))
+		for _, l := range s.Labels {
+			storeLabels[l.Key] = l.Value
+		}
+		if engine := storeLabels["engine"]; engine == "tiflash" || engine == "tiflash_compute" {
+			continue
+		}
+		ip, _, err := net.SplitHostPort(s.Address)
+		if err != nil {
+			ip = s.Address
+		}
+		ps := &placementStore{id: uint64(s.ID), ip: ip, labels: storeLabels, location: make([]string, 0, len(levels))}
+		for _, l := range labels {
+			ps.location = append(ps.location, storeLabels[l])
+		}
+		ps.location = append(ps.location, ip)
+		tikvStores[ps.id] = ps
+	}
+	storeList := make([]*placementStore, 0, len(tikvStores))
+	for _, s := range tikvStores {
+		storeList = append(storeList, s)
+	}
+	eligibleLocations := func(rule *placementRule) {
+		eligible := make([]*placementStore, 0, len(storeList))
+		for _, s := range storeList {
+			if rule.matchStore(s) {
+				eligible = append(eligible, s)
+			}
+		}
+		rule.distinctLocations = distinctLocations(eligible, len(levels))
+	}
+	for i := range placementRules {
+		eligibleLocations(&placementRules[i])
+	}
+	// Without placement rules, all voters are placed by the default rule.
+	defaultRule := placementRule{groupID: defaultRuleGroup}
+	eligibleLocations(&defaultRule)
+
+	result := &PlacementAnalysis{
+		LocationLabels: labels,
+		StoreCount:     len(tikvStores),
+		RegionCount:    len(regions),
+		Zones:          make([]ZoneLoad, 0),
+		Groups:         make([]RegionGroupIsolation, 0),
+		Violations:     make([]PlacementViolation, 0),
+	}
+	addViolation := func(v PlacementViolation) {
+		result.ViolationCount++
+		if len(result.Violations) < maxPlacementViolations {
+			result.Violations = append(result.Violations, v)
+		}
+	}
+
+	groups := make(map[string]*RegionGroupIsolation)
+	regionCounts := make(map[uint64]int)
+	leaderCounts := make(map[uint64]int)
+	for _, region := range regions {
+		replicas := make([]*placementStore, 0, len(region.Peers))
+		for _, peer := range region.Peers {
+			if strings.EqualFold(peer.RoleName, "learner") {
+				continue
+			}
+			if s, ok := tikvStores[peer.StoreID]; ok {
+				replicas = append(replicas, s)
+				regionCounts[s.id]++
+			}
+		}
+		var leaderStoreID uint64
+		if region.Leader != nil {
+			if _, ok := tikvStores[region.Leader.StoreID]; ok {
+				leaderStoreID = region.Leader.StoreID
+				leaderCounts[leaderStoreID]++
+			}
+		}
+
+		startKey, err := decodeKey(region.StartKey)
+		if err != nil {
+			return nil, err
+		}
+		// The region belongs to the group of the rule with the largest index, and all rules of the group
+		// covering the region apply.
+		var applied []placementRule
+		for _, rule := range placementRules {
+			if rule.covers(startKey) && (len(applied) == 0 || rule.groupID == applied[0].groupID) {
+				applied = append(applied, rule)
+			}
+		}
+		if len(applied) == 0 {
+			applied = []placementRule{defaultRule}
+		}
+		groupID, requiredLevel := applied[0].groupID, ""
+		for _, rule := range applied {
+			if rule.isolationLevel != "" {
+				requiredLevel = rule.isolationLevel
+				break
+			}
+		}
+		group, ok := groups[groupID]
+		if !ok {
+			group = &RegionGroupIsolation{GroupID: groupID, IsolationLevel: requiredLevel, LevelCounts: make(map[string]int)}
+			groups[groupID] = group
+		}
+		if len(replicas) < 2 {
+			// Isolation is meaningless for a single replica.
+			continue
+		}
+
+		depth := isolatedDepth(replicas, len(levels))
+		group.RegionCount++
+		if depth < 0 {
+			group.LevelCounts[noIsolationLevel]++
+		} else {
+			group.LevelCounts[levels[depth]]++
+			group.Score += float64(len(levels)-depth) / float64(len(levels))
+		}
+
+		// Each rule is evaluated against the replicas it places.
+		assigned := make(map[uint64]struct{}, len(replicas))
+		for _, rule := range fitOrder(applied) {
+			ruleReplicas := make([]*placementStore, 0, len(replicas))
+			for _, s := range replicas {
+				if _, ok := assigned[s.id]; ok || !rule.matchStore(s) || !rule.matchRole(s, leaderStoreID) {
+					continue
+				}
+				if rule.count > 0 && len(ruleReplicas) >= rule.count {
+					break
+				}
+				ruleReplicas = append(ruleReplicas, s)
+				assigned[s.id] = struct{}{}
+			}
+			if len(ruleReplicas) < 2 {
+				continue
+			}
+			checkRulePlacement(region.ID, rule, ruleReplicas, labels, addViolation)
+		}
+	}
+
+	for _, group := range groups {
+		if group.RegionCount > 0 {
+			group.Score /= float64(group.RegionCount)
+		}
+		result.Groups = append(result.Groups, *group)
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		return result.Groups[i].GroupID < result.Groups[j].GroupID
+	})
+
+	if len(labels) > 0 && len(tikvStores) > 0 {
+		result.ZoneLabel = labels[0]
+		zones := make(map[string]*ZoneLoad)
+		totalRegions, totalLeaders := 0, 0
+		for _, s := range tikvStores {
+			zone, ok := zones[s.location[0]]
+			if !ok {
+				zone = &ZoneLoad{Zone: s.location[0]}
+				zones[s.location[0]] = zone
+			}
+			zone.StoreCount++
+			zone.RegionCount += regionCounts[s.id]
+			zone.LeaderCount += leaderCounts[s.id]
+			totalRegions += regionCounts[s.id]
+			totalLeaders += leaderCounts[s.id]
+		}
+		avgRegions := float64(totalRegions) / float64(len(tikvStores))
+		avgLeaders := float64(totalLeaders) / float64(len(tikvStores))
+		for _, zone := range zones {
+			zone.RegionsPerStore = float64(zone.RegionCount) / float64(zone.StoreCount)
+			zone.LeadersPerStore = float64(zone.LeaderCount) / float64(zone.StoreCount)
+			zone.RegionStatus = zoneLoadStatus(zone.RegionsPerStore, avgRegions)
+			zone.LeaderStatus = zoneLoadStatus(zone.LeadersPerStore, avgLeaders)
+			result.Zones = append(result.Zones, *zone)
+		}
+		sort.Slice(result.Zones, func(i, j int) bool {
+			return result.Zones[i].Zone < result.Zones[j].Zone
+		})
+	}
+	return result, nil
+}
+
+// checkRulePlacement reports replicas of a rule which are not isolated though they can be.
+func checkRulePlacement(
+	regionID uint64,
+	rule placementRule,
+	replicas []*placementStore,
+	labels []string,
+	addViolation func(v PlacementViolation),
+) {
+	if len(labels) > 0 && rule.isolatable(0, len(replicas)) {
+		shared := sharedStores(replicas, func(s *placementStore) string { return s.location[0] })
+		for _, value := range sortedKeys(shared) {
+			addViolation(PlacementViolation{
+				RegionID: regionID,
+				GroupID:  rule.groupID,
+				Kind:     PlacementViolationSharedLocation,
+				Label:    labels[0],
+				Value:    value,
+				StoreIDs: shared[value],
+				Message:  fmt.Sprintf("%d replicas are in %s %s", len(shared[value]), labels[0], value),
+			})
+		}
+	}
+	if rule.isolatable(len(labels), len(replicas)) {
+		shared := sharedStores(replicas, func(s *placementStore) string { return s.ip })
+		for _, ip := range sortedKeys(shared) {
+			addViolation(PlacementViolation{
+				RegionID: regionID,
+				GroupID:  rule.groupID,
+				Kind:     PlacementViolationSharedHost,
+				Value:    ip,
+				StoreIDs: shared[ip],
+				Message:  fmt.Sprintf("%d replicas are on host %s", len(shared[ip]), ip),
+			})
+		}
+	}
+	if rule.isolationLevel == "" {
+		return
+	}
+	requiredDepth := -1
+	for i, l := range labels {
+		if l == rule.isolationLevel {
+			requiredDepth = i
+		}
+	}
+	if requiredDepth < 0 || !rule.isolatable(requiredDepth, len(replicas)) {
+		return
+	}
+	if depth := isolatedDepth(replicas, len(labels)+1); depth < 0 || depth > requiredDepth {
+		ids := make([]uint64, 0, len(replicas))
+		for _, s := range replicas {
+			ids = append(ids, s.id)
+		}
+		addViolation(PlacementViolation{
+			RegionID: regionID,
+			GroupID:  rule.groupID,
+			Kind:     PlacementViolationIsolationLevel,
+			Label:    rule.isolationLevel,
+			StoreIDs: ids,
+			Message:  fmt.Sprintf("Replicas are not isolated by %s", rule.isolationLevel),
+		})
+	}
+}
+
+func sortedKeys(m map[string][]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Service) fetchPlacementAnalysis(ctx context.Context, maxRegions int) (*PlacementAnalysis, error) {
+	pdAPI := s.params.PDAPIClient.Clone()
+	pdAPI.SetDefaultBaseURL(s.params.Config.PDEndPoint)
+
+	locationLabels, err := pdAPI.HLGetLocationLabels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stores, err := pdAPI.HLGetStores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rules pdclient.GetConfigRulesResponse
+	resp, err := pdAPI.GetConfigRules(ctx)
+	if err != nil {
+		// Placement rules may be disabled, in which case all regions belong to the default group.
+		log.Warn("Failed to fetch placement rules", zap.Error(err))
+	} else if resp != nil {
+		rules = *resp
+	}
+	regions, truncated, err := pdAPI.HLScanRegions(ctx, placementScanPageSize, maxRegions)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := analyzePlacement(locationLabels, stores, rules, regions)
+	if err != nil {
+		return nil, err
+	}
+	result.Truncated = truncated
+	return result, nil
+}
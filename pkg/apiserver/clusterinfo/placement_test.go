@@ -0,0 +1,183 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/client/pdclient"
+)
+
+func testStore(id int, address string, labels ...string) pdclient.GetStoresResponseStore {
+	s := pdclient.GetStoresResponseStore{ID: id, Address: address, StateName: "Up"}
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.Labels = append(s.Labels, pdclient.GetStoresResponseStoreLabel{Key: labels[i], Value: labels[i+1]})
+	}
+	return s
+}
+
+func testRegion(id uint64, startKey string, leader uint64, stores ...uint64) pdclient.GetRegionsResponseRegion {
+	r := pdclient.GetRegionsResponseRegion{ID: id, StartKey: startKey}
+	for _, s := range stores {
+		peer := pdclient.GetRegionsResponsePeer{ID: id*100 + s, StoreID: s, RoleName: "Voter"}
+		r.Peers = append(r.Peers, peer)
+		if s == leader {
+			r.Leader = &peer
+		}
+	}
+	return r
+}
+
+func TestAnalyzePlacement(t *testing.T) {
+	stores := []pdclient.GetStoresResponseStore{
+		testStore(1, "10.0.1.1:20160", "zone", "z1", "host", "h1"),
+		testStore(2, "10.0.2.1:20160", "zone", "z2", "host", "h2"),
+		testStore(3, "10.0.3.1:20160", "zone", "z3", "host", "h3"),
+		testStore(4, "10.0.3.1:20161", "zone", "z3", "host", "h3"),
+		testStore(5, "10.0.4.1:3930", "zone", "z1", "engine", "tiflash"),
+	}
+	rules := pdclient.GetConfigRulesResponse{
+		{GroupID: "pd", ID: "default", Role: "voter", Count: 3, IsolationLevel: "zone"},
+		{GroupID: "tiflash", ID: "table-1", Index: 120, StartKey: "7431", EndKey: "7432", Role: "learner", Count: 1},
+		{GroupID: "hot", ID: "table-2", Index: 10, StartKey: "7432", EndKey: "7433", Role: "voter", Count: 3},
+	}
+	learner := testRegion(3, "7431", 1, 1, 2, 3)
+	learner.Peers = append(learner.Peers, pdclient.GetRegionsResponsePeer{ID: 305, StoreID: 5, RoleName: "Learner"})
+	regions := []pdclient.GetRegionsResponseRegion{
+		testRegion(1, "", 1, 1, 2, 3),
+		testRegion(2, "7430", 1, 1, 3, 4),
+		learner,
+		testRegion(4, "7432", 3, 1, 2, 3),
+		testRegion(5, "7432ff", 4, 3, 4),
+	}
+
+	result, err := analyzePlacement([]string{"zone", " host"}, stores, rules, regions)
+	require.NoError(t, err)
+	require.Equal(t, []string{"zone", "host"}, result.LocationLabels)
+	require.Equal(t, "zone", result.ZoneLabel)
+	require.Equal(t, 4, result.StoreCount)
+	require.Equal(t, 5, result.RegionCount)
+
+	require.Len(t, result.Groups, 2)
+	require.Equal(t, "hot", result.Groups[0].GroupID)
+	require.Equal(t, map[string]int{"zone": 1, "none": 1}, result.Groups[0].LevelCounts)
+	require.InDelta(t, 0.5, result.Groups[0].Score, 1e-6)
+	require.Equal(t, "pd", result.Groups[1].GroupID)
+	require.Equal(t, "zone", result.Groups[1].IsolationLevel)
+	require.Equal(t, 3, result.Groups[1].RegionCount)
+	require.Equal(t, map[string]int{"zone": 2, "none": 1}, result.Groups[1].LevelCounts)
+	require.InDelta(t, 2.0/3, result.Groups[1].Score, 1e-6)
+
+	require.Equal(t, 5, result.ViolationCount)
+	require.Equal(t, PlacementViolation{
+		RegionID: 2,
+		GroupID:  "pd",
+		Kind:     PlacementViolationSharedLocation,
+		Label:    "zone",
+		Value:    "z3",
+		StoreIDs: []uint64{3, 4},
+		Message:  "2 replicas are in zone z3",
+	}, result.Violations[0])
+	require.Equal(t, PlacementViolationSharedHost, result.Violations[1].Kind)
+	require.Equal(t, "10.0.3.1", result.Violations[1].Value)
+	require.Equal(t, PlacementViolationIsolationLevel, result.Violations[2].Kind)
+	require.Equal(t, uint64(2), result.Violations[2].RegionID)
+	require.Equal(t, uint64(5), result.Violations[3].RegionID)
+
+	require.Len(t, result.Zones, 3)
+	require.Equal(t, ZoneLoad{
+		Zone:            "z1",
+		StoreCount:      1,
+		RegionCount:     4,
+		LeaderCount:     3,
+		RegionsPerStore: 4,
+		LeadersPerStore: 3,
+		RegionStatus:    ZoneLoadStatusBalanced,
+		LeaderStatus:    ZoneLoadStatusOverloaded,
+	}, result.Zones[0])
+	require.Equal(t, ZoneLoadStatusUnderloaded, result.Zones[1].LeaderStatus)
+	require.Equal(t, 2, result.Zones[2].StoreCount)
+	require.Equal(t, 7, result.Zones[2].RegionCount)
+	require.Equal(t, ZoneLoadStatusBalanced, result.Zones[2].LeaderStatus)
+}
+
+func TestAnalyzePlacementWithConstrainedRules(t *testing.T) {
+	stores := []pdclient.GetStoresResponseStore{
+		testStore(1, "10.0.1.1:20160", "region", "east", "zone", "e1"),
+		testStore(2, "10.0.1.2:20160", "region", "east", "zone", "e2"),
+		testStore(3, "10.0.1.3:20160", "region", "east", "zone", "e2"),
+		testStore(4, "10.0.2.1:20160", "region", "west", "zone", "w1"),
+		testStore(5, "10.0.2.2:20160", "region", "west", "zone", "w1"),
+	}
+	eastOnly := []pdclient.GetConfigRulesResponseLabelConstraint{{Key: "region", Op: "in", Values: []string{"east"}}}
+	rules := pdclient.GetConfigRulesResponse{
+		{GroupID: "pd", ID: "default", Role: "voter", Count: 5},
+		// Like a placement policy with PRIMARY_REGION, which keeps the leader and a follower in the primary region.
+		{GroupID: "primary", ID: "leader", Index: 10, StartKey: "74", EndKey: "75", Role: "leader", Count: 1, LabelConstraints: eastOnly},
+		{GroupID: "primary", ID: "followers", Index: 10, StartKey: "74", EndKey: "75", Role: "follower", Count: 1, LabelConstraints: eastOnly},
+		{GroupID: "primary", ID: "others", Index: 10, StartKey: "74", EndKey: "75", Role: "follower", Count: 1, LabelConstraints: []pdclient.GetConfigRulesResponseLabelConstraint{
+			{Key: "region", Op: "notIn", Values: []string{"east"}},
+		}},
+	}
+	regions := []pdclient.GetRegionsResponseRegion{
+		// 5 replicas in 2 regions and 3 zones: regions and zones are unavoidably shared.
+		testRegion(1, "", 1, 1, 2, 3, 4, 5),
+		testRegion(2, "7401", 1, 1, 2, 4),
+		// Replicas in the primary region share a zone.
+		testRegion(3, "7402", 2, 2, 3, 4),
+	}
+
+	result, err := analyzePlacement([]string{"region", "zone"}, stores, rules, regions)
+	require.NoError(t, err)
+	require.Len(t, result.Groups, 2)
+	require.Equal(t, "pd", result.Groups[0].GroupID)
+	require.Equal(t, 1, result.Groups[0].RegionCount)
+	require.Equal(t, "primary", result.Groups[1].GroupID)
+	require.Equal(t, 2, result.Groups[1].RegionCount)
+	require.Equal(t, 0, result.ViolationCount)
+
+	// Replicas in the primary region can be isolated by zone.
+	rules = pdclient.GetConfigRulesResponse{
+		rules[0],
+		{GroupID: "primary", ID: "voters", Index: 10, StartKey: "74", EndKey: "75", Role: "voter", Count: 2, LabelConstraints: eastOnly, IsolationLevel: "zone"},
+		rules[3],
+	}
+	result, err = analyzePlacement([]string{"region", "zone"}, stores, rules, regions)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.ViolationCount)
+	require.Equal(t, PlacementViolation{
+		RegionID: 3,
+		GroupID:  "primary",
+		Kind:     PlacementViolationIsolationLevel,
+		Label:    "zone",
+		StoreIDs: []uint64{2, 3},
+		Message:  "Replicas are not isolated by zone",
+	}, result.Violations[0])
+}
+
+func TestAnalyzePlacementWithoutLabels(t *testing.T) {
+	stores := []pdclient.GetStoresResponseStore{
+		testStore(1, "10.0.0.1:20160"),
+		testStore(2, "10.0.0.1:20161"),
+		testStore(3, "10.0.0.2:20160"),
+	}
+	result, err := analyzePlacement([]string{}, stores, nil, []pdclient.GetRegionsResponseRegion{
+		testRegion(1, "", 1, 1, 2),
+		testRegion(2, "74", 1, 1, 3),
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.Zones)
+	require.Equal(t, []RegionGroupIsolation{{
+		GroupID:     "pd",
+		RegionCount: 2,
+		Score:       0.5,
+		LevelCounts: map[string]int{"ip": 1, "none": 1},
+	}}, result.Groups)
+	require.Equal(t, 1, result.ViolationCount)
+	require.Equal(t, PlacementViolationSharedHost, result.Violations[0].Kind)
+
+	_, err = analyzePlacement([]string{}, stores, nil, []pdclient.GetRegionsResponseRegion{testRegion(1, "xyz", 1, 1)})
+	require.Error(t, err)
+}
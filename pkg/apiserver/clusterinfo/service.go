@@ -9,42 +9,81 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/metrics"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
 	"github.com/pingcap/tidb-dashboard/pkg/httpc"
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/ticdc"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/pkg/tiproxy"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/client/pdclient"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
+var (
+	ErrNS                = errorx.NewNamespace("error.api.clusterinfo")
+	ErrInvalidData       = ErrNS.NewType("invalid_data")
+	ErrHealthCheckFailed = ErrNS.NewType("health_check_failed")
+)
+
 type ServiceParams struct {
 	fx.In
 	PDClient   *pd.Client
 	EtcdClient *clientv3.Client
 	HTTPClient *httpc.Client
 	TiDBClient *tidb.Client
+	LocalStore *dbstore.DB
+
+	TiCDCClient   *ticdc.Client
+	TiProxyClient *tiproxy.Client
+	PDAPIClient   *pdclient.APIClient
+	Config        *config.Config
+	Metrics       *metrics.Service
 }
 
 type Service struct {
 	params       ServiceParams
 	lifecycleCtx context.Context
+	wg           sync.WaitGroup
+
+	publicHealthMu sync.Mutex
+	publicHealth   *ClusterHealth
 }
 
 func NewService(lc fx.Lifecycle, p ServiceParams) *Service {
+	if err := autoMigrate(p.LocalStore); err != nil {
+		log.Fatal("Failed to initialize database", zap.Error(err))
+	}
+
 	s := &Service{params: p}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			s.lifecycleCtx = ctx
+			s.wg.Go(func() {
+				s.historyLoop(ctx)
+			})
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			s.wg.Wait()
 			return nil
 		},
 	})
@@ -68,11 +107,21 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 
 	endpoint.GET("/store_location", s.getStoreLocationTopology)
 
+	endpoint = r.Group("/clusterinfo")
+	// The health can be checked without authentication, e.g. by load balancers, with only the overall verdict.
+	endpoint.GET("/health", auth.MWAuthOptional(), s.getHealth)
+	endpoint.Use(auth.MWAuthRequired())
+	endpoint.GET("/topology/graph", s.getTopologyGraph)
+	endpoint.GET("/placement", s.getPlacementAnalysis)
+	endpoint.GET("/upgrade_readiness", s.getUpgradeReadiness)
+
 	endpoint = r.Group("/host")
 	endpoint.Use(auth.MWAuthRequired())
-	endpoint.Use(utils.MWConnectTiDB(s.params.TiDBClient))
-	endpoint.GET("/all", s.getHostsInfo)
-	endpoint.GET("/statistics", s.getStatistics)
+	// History is recorded in background, so that reading it does not need a SQL connection.
+	endpoint.GET("/history", s.getHostHistory)
+	endpoint.GET("/trend", s.getCapacityTrend)
+	endpoint.GET("/all", utils.MWConnectTiDB(s.params.TiDBClient), s.getHostsInfo)
+	endpoint.GET("/statistics", utils.MWConnectTiDB(s.params.TiDBClient), s.getStatistics)
 }
 
 // @Summary Hide a TiDB instance
@@ -362,3 +411,205 @@ func (s *Service) getStatistics(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, stats)
 }
+
+type HostHistoryResponse struct {
+	Hosts      []HostSampleModel      `json:"hosts"`
+	Partitions []PartitionSampleModel `json:"partitions"`
+}
+
+// @ID clusterInfoGetHostHistory
+// @Summary Get the recorded CPU, memory and disk usage of hosts
+// @Description Hosts are recorded every 10 minutes, and kept for 90 days
+// @Param host query string false "host, all hosts by default"
+// @Param begin query int false "begin time in unix seconds, 7 days ago by default"
+// @Param end query int false "end time in unix seconds, now by default"
+// @Router /host/history [get]
+// @Security JwtAuth
+// @Success 200 {object} HostHistoryResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getHostHistory(c *gin.Context) {
+	end := time.Now().Unix()
+	begin := end - 7*24*3600
+	var err error
+	if v := c.Query("begin"); v != "" {
+		if begin, err = strconv.ParseInt(v, 10, 64); err != nil {
+			rest.Error(c, rest.ErrBadRequest.New("Invalid begin %s", v))
+			return
+		}
+	}
+	if v := c.Query("end"); v != "" {
+		if end, err = strconv.ParseInt(v, 10, 64); err != nil {
+			rest.Error(c, rest.ErrBadRequest.New("Invalid end %s", v))
+			return
+		}
+	}
+
+	query := func() *gorm.DB {
+		db := s.params.LocalStore.Where("sampled_at >= ? AND sampled_at <= ?", begin, end)
+		if host := c.Query("host"); host != "" {
+			db = db.Where("host = ?", host)
+		}
+		return db.Order("sampled_at")
+	}
+	var resp HostHistoryResponse
+	if err := query().Find(&resp.Hosts).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if err := query().Find(&resp.Partitions).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @ID clusterInfoGetCapacityTrend
+// @Summary Get the capacity trend of disk partitions and stores
+// @Description Project the days until full by fitting the used space over the recent days
+// @Param days query int false "days of history to fit, 30 by default"
+// @Router /host/trend [get]
+// @Security JwtAuth
+// @Success 200 {object} CapacityTrendResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getCapacityTrend(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d <= 0 {
+			rest.Error(c, rest.ErrBadRequest.New("Invalid days %s", v))
+			return
+		}
+		days = d
+	}
+	resp, err := s.getCapacityTrends(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// openOptionalTiDBConnection opens a SQL connection if the session has SQL credentials. The connection is nil if
+// there are no credentials or the connection fails.
+func (s *Service) openOptionalTiDBConnection(c *gin.Context) (*gorm.DB, error) {
+	session := utils.GetSession(c)
+	if session == nil || !session.HasTiDBAuth {
+		return nil, nil
+	}
+	db, err := s.params.TiDBClient.OpenSQLConn(session.TiDBUsername, session.TiDBPassword)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// @ID clusterInfoGetHealth
+// @Summary Get the health of the cluster
+// @Description Check all components and return an overall verdict with per-component reasons. The status code is 503 if the cluster is unhealthy.
+// @Description Without authentication, only the overall verdict is returned, which may be cached for 10 seconds.
+// @Router /clusterinfo/health [get]
+// @Security JwtAuth
+// @Success 200 {object} ClusterHealth
+// @Failure 503 {object} ClusterHealth
+func (s *Service) getHealth(c *gin.Context) {
+	if utils.GetSession(c) == nil {
+		health := s.checkPublicHealth(c.Request.Context())
+		status := http.StatusOK
+		if health.Verdict == HealthVerdictUnhealthy {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, health)
+		return
+	}
+
+	// Unlike other host APIs, the SQL connection is optional, so that the health can be checked when TiDB is down.
+	db, dbErr := s.openOptionalTiDBConnection(c)
+	if db != nil {
+		defer func() {
+			_ = utils.CloseTiDBConnection(db)
+		}()
+	}
+
+	health := s.checkHealth(c.Request.Context(), db, dbErr)
+	status := http.StatusOK
+	if health.Verdict == HealthVerdictUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, health)
+}
+
+// @ID clusterInfoGetTopologyGraph
+// @Summary Get the topology of the cluster as a graph
+// @Description Hosts, instances, location labels and component relationships as a graph. Instances that failed to be fetched are reported in errors.
+// @Description Monitoring components are probed, and reported as unreachable if the probe fails.
+// @Param format query string false "Output format" Enums(json, dot, mermaid)
+// @Router /clusterinfo/topology/graph [get]
+// @Security JwtAuth
+// @Success 200 {object} TopologyGraph
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getTopologyGraph(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dot" && format != "mermaid" {
+		rest.Error(c, rest.ErrBadRequest.New("unsupported format %s", format))
+		return
+	}
+
+	graph := s.fetchTopologyGraph(c.Request.Context())
+	switch format {
+	case "dot":
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(graph.RenderDOT()))
+	case "mermaid":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(graph.RenderMermaid()))
+	default:
+		c.JSON(http.StatusOK, graph)
+	}
+}
+
+// @ID clusterInfoGetPlacementAnalysis
+// @Summary Analyze replica placement of regions
+// @Description Check replica placement against location labels and placement rules, and report zones with unbalanced regions or leaders.
+// @Param limit query int false "Maximum number of regions to scan"
+// @Router /clusterinfo/placement [get]
+// @Security JwtAuth
+// @Success 200 {object} PlacementAnalysis
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getPlacementAnalysis(c *gin.Context) {
+	limit := defaultPlacementMaxRegions
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			rest.Error(c, rest.ErrBadRequest.New("invalid limit %s", v))
+			return
+		}
+		limit = n
+	}
+
+	result, err := s.fetchPlacementAnalysis(c.Request.Context(), limit)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @ID clusterInfoGetUpgradeReadiness
+// @Summary Check whether the cluster is ready for a rolling upgrade
+// @Description Report versions of all instances, version skews and upgrade preconditions. Call it again after each upgrade step.
+// @Router /clusterinfo/upgrade_readiness [get]
+// @Security JwtAuth
+// @Success 200 {object} UpgradeReadiness
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) getUpgradeReadiness(c *gin.Context) {
+	db, dbErr := s.openOptionalTiDBConnection(c)
+	if db != nil {
+		defer func() {
+			_ = utils.CloseTiDBConnection(db)
+		}()
+	}
+
+	c.JSON(http.StatusOK, s.checkUpgradeReadiness(c.Request.Context(), db, dbErr))
+}
@@ -0,0 +1,442 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+)
+
+const (
+	// Instances are restarted one by one during rolling upgrades, so hosts need free resources to take over the load.
+	upgradeMemoryHeadroomRatio = 0.2
+	upgradeDiskHeadroomRatio   = 0.2
+)
+
+// Components that share the release version, in the order they should be upgraded. TiProxy is versioned
+// independently, so it is not included.
+var upgradeOrder = []string{"pd", "tso", "scheduling", "tikv", "tiflash", "tidb", "ticdc"}
+
+// Regions in these states are not healthy and may become unavailable when stores restart.
+var unhealthyRegionStates = []string{"miss-peer", "down-peer", "pending-peer", "offline-peer"}
+
+type UpgradeVerdict string
+
+const (
+	UpgradeVerdictReady   UpgradeVerdict = "ready"
+	UpgradeVerdictBlocked UpgradeVerdict = "blocked"
+)
+
+type UpgradeInstance struct {
+	Component string                   `json:"component"`
+	Address   string                   `json:"address"`
+	Version   string                   `json:"version"`
+	GitHash   string                   `json:"git_hash"`
+	Status    topology.ComponentStatus `json:"status"`
+}
+
+type VersionSkewKind string
+
+const (
+	// Instances of a component run different versions.
+	VersionSkewMixedInstances VersionSkewKind = "mixed_instances"
+	// Components run different versions.
+	VersionSkewMixedComponents VersionSkewKind = "mixed_components"
+	// A component runs a newer version than a component that should be upgraded before it.
+	VersionSkewUpgradeOrder VersionSkewKind = "upgrade_order"
+)
+
+type VersionSkew struct {
+	Kind       VersionSkewKind `json:"kind"`
+	Components []string        `json:"components"`
+	Versions   []string        `json:"versions"`
+	Blocking   bool            `json:"blocking"`
+	Message    string          `json:"message"`
+}
+
+type UpgradeCheckStatus string
+
+const (
+	UpgradeCheckPassed UpgradeCheckStatus = "passed"
+	UpgradeCheckFailed UpgradeCheckStatus = "failed"
+	// The check can not be done, which blocks the upgrade as well.
+	UpgradeCheckSkipped UpgradeCheckStatus = "skipped"
+)
+
+type UpgradeCheck struct {
+	Name    string             `json:"name"`
+	Status  UpgradeCheckStatus `json:"status"`
+	Message string             `json:"message"`
+	Details []string           `json:"details"`
+}
+
+type UpgradeReadiness struct {
+	Verdict   UpgradeVerdict    `json:"verdict"`
+	CheckedAt int64             `json:"checked_at"`
+	Instances []UpgradeInstance `json:"instances"`
+	// Distinct versions of each component
+	Versions map[string][]string `json:"versions"`
+	Skews    []VersionSkew       `json:"skews"`
+	Checks   []UpgradeCheck      `json:"checks"`
+}
+
+func newUpgradeCheck(name string, failures []string, passedMessage string, failedMessage string) UpgradeCheck {
+	if len(failures) == 0 {
+		return UpgradeCheck{Name: name, Status: UpgradeCheckPassed, Message: passedMessage, Details: []string{}}
+	}
+	return UpgradeCheck{Name: name, Status: UpgradeCheckFailed, Message: failedMessage, Details: failures}
+}
+
+func skippedUpgradeCheck(name string, err error) UpgradeCheck {
+	return UpgradeCheck{Name: name, Status: UpgradeCheckSkipped, Message: err.Error(), Details: []string{}}
+}
+
+// parseReleaseVersion parses versions like `v8.5.0-alpha-xxx`, ignoring the suffix.
+func parseReleaseVersion(version string) *semver.Version {
+	v, err := semver.NewVersion(strings.Split(version, "-")[0])
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		vi, vj := parseReleaseVersion(versions[i]), parseReleaseVersion(versions[j])
+		if vi != nil && vj != nil && !vi.Equal(vj) {
+			return vi.LessThan(vj)
+		}
+		return versions[i] < versions[j]
+	})
+}
+
+// detectVersionSkews returns distinct versions of each component and skews between them.
+func detectVersionSkews(instances []UpgradeInstance) (map[string][]string, []VersionSkew) {
+	versionSets := make(map[string]map[string]struct{})
+	for _, i := range instances {
+		if i.Status == topology.ComponentStatusTombstone || i.Version == "" {
+			continue
+		}
+		if versionSets[i.Component] == nil {
+			versionSets[i.Component] = make(map[string]struct{})
+		}
+		versionSets[i.Component][i.Version] = struct{}{}
+	}
+	versions := make(map[string][]string, len(versionSets))
+	for component, set := range versionSets {
+		list := make([]string, 0, len(set))
+		for v := range set {
+			list = append(list, v)
+		}
+		sortVersions(list)
+		versions[component] = list
+	}
+
+	skews := make([]VersionSkew, 0)
+	components := make([]string, 0, len(versions))
+	for component := range versions {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		if len(versions[component]) > 1 {
+			skews = append(skews, VersionSkew{
+				Kind:       VersionSkewMixedInstances,
+				Components: []string{component},
+				Versions:   versions[component],
+				Message:    fmt.Sprintf("Instances of %s run %d different versions", componentDisplayName(component), len(versions[component])),
+			})
+		}
+	}
+
+	// Release versions of ordered components, ignoring versions that can not be parsed
+	type versionRange struct {
+		component string
+		min, max  *semver.Version
+	}
+	ranges := make([]versionRange, 0, len(upgradeOrder))
+	releaseVersions := make(map[string]struct{})
+	for _, component := range upgradeOrder {
+		r := versionRange{component: component}
+		for _, v := range versions[component] {
+			parsed := parseReleaseVersion(v)
+			if parsed == nil {
+				continue
+			}
+			releaseVersions[parsed.String()] = struct{}{}
+			if r.min == nil || parsed.LessThan(r.min) {
+				r.min = parsed
+			}
+			if r.max == nil || parsed.GreaterThan(r.max) {
+				r.max = parsed
+			}
+		}
+		if r.min != nil {
+			ranges = append(ranges, r)
+		}
+	}
+	if len(releaseVersions) > 1 {
+		mixed := make([]string, 0, len(ranges))
+		for _, r := range ranges {
+			mixed = append(mixed, r.component)
+		}
+		list := make([]string, 0, len(releaseVersions))
+		for v := range releaseVersions {
+			list = append(list, v)
+		}
+		sortVersions(list)
+		skews = append(skews, VersionSkew{
+			Kind:       VersionSkewMixedComponents,
+			Components: mixed,
+			Versions:   list,
+			Message:    fmt.Sprintf("Components run %d different release versions", len(list)),
+		})
+	}
+	for i, earlier := range ranges {
+		for _, later := range ranges[i+1:] {
+			if !later.max.GreaterThan(earlier.min) {
+				continue
+			}
+			skews = append(skews, VersionSkew{
+				Kind:       VersionSkewUpgradeOrder,
+				Components: []string{earlier.component, later.component},
+				Versions:   []string{earlier.min.String(), later.max.String()},
+				Blocking:   true,
+				Message: fmt.Sprintf("%s %s is newer than %s %s, which should be upgraded first",
+					componentDisplayName(later.component), later.max, componentDisplayName(earlier.component), earlier.min),
+			})
+		}
+	}
+	return versions, skews
+}
+
+func checkUpgradeStores(instances []UpgradeInstance) UpgradeCheck {
+	failures := make([]string, 0)
+	for _, i := range instances {
+		if i.Component != "tikv" && i.Component != "tiflash" {
+			continue
+		}
+		switch i.Status {
+		case topology.ComponentStatusDown:
+			failures = append(failures, fmt.Sprintf("%s %s is down", componentDisplayName(i.Component), i.Address))
+		case topology.ComponentStatusOffline:
+			failures = append(failures, fmt.Sprintf("%s %s is offline", componentDisplayName(i.Component), i.Address))
+		case topology.ComponentStatusUnreachable:
+			failures = append(failures, fmt.Sprintf("%s %s is disconnected", componentDisplayName(i.Component), i.Address))
+		}
+	}
+	return newUpgradeCheck("stores", failures, "All stores are up", "Some stores are not up")
+}
+
+// States of DDL jobs which are still in the DDL job queue. Finished jobs are moved to the history, which can be
+// large and should not be scanned.
+var pendingDDLJobStates = []string{"queueing", "running", "rollingback", "cancelling", "pausing", "paused"}
+
+type pendingDDLJob struct {
+	JobID     int64  `gorm:"column:JOB_ID"`
+	DBName    string `gorm:"column:DB_NAME"`
+	TableName string `gorm:"column:TABLE_NAME"`
+	JobType   string `gorm:"column:JOB_TYPE"`
+	State     string `gorm:"column:STATE"`
+}
+
+func checkUpgradeDDLJobs(db *gorm.DB) UpgradeCheck {
+	var jobs []pendingDDLJob
+	err := db.
+		Raw("SELECT JOB_ID, DB_NAME, TABLE_NAME, JOB_TYPE, STATE FROM INFORMATION_SCHEMA.DDL_JOBS WHERE STATE IN ?", pendingDDLJobStates).
+		Scan(&jobs).Error
+	if err != nil {
+		return skippedUpgradeCheck("ddl_jobs", err)
+	}
+	failures := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		failures = append(failures, fmt.Sprintf("Job %d (%s on %s.%s) is %s", job.JobID, job.JobType, job.DBName, job.TableName, job.State))
+	}
+	return newUpgradeCheck("ddl_jobs", failures, "No DDL job is pending", "Some DDL jobs are pending")
+}
+
+func (s *Service) checkUpgradeRegions() UpgradeCheck {
+	failures := make([]string, 0)
+	for _, state := range unhealthyRegionStates {
+		data, err := s.params.PDClient.SendGetRequest("/regions/check/" + state)
+		if err != nil {
+			return skippedUpgradeCheck("regions", err)
+		}
+		resp := struct {
+			Count int `json:"count"`
+		}{}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return skippedUpgradeCheck("regions", ErrInvalidData.Wrap(err, "%s regions check API unmarshal failed", distro.R().PD))
+		}
+		if resp.Count > 0 {
+			failures = append(failures, fmt.Sprintf("%d regions are %s", resp.Count, state))
+		}
+	}
+	return newUpgradeCheck("regions", failures, "All regions are healthy", "Some regions are not healthy")
+}
+
+func checkUpgradeResources(hosts []*hostinfo.Info) UpgradeCheck {
+	failures := make([]string, 0)
+	for _, host := range hosts {
+		if host.MemoryUsage != nil && host.MemoryUsage.Total > 0 {
+			free := 1 - float64(host.MemoryUsage.Used)/float64(host.MemoryUsage.Total)
+			if free < upgradeMemoryHeadroomRatio {
+				failures = append(failures, fmt.Sprintf("Only %.0f%% of memory is free on %s", free*100, host.Host))
+			}
+		}
+		paths := make([]string, 0, len(host.Partitions))
+		for path := range host.Partitions {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			partition := host.Partitions[path]
+			if partition.Total <= 0 {
+				continue
+			}
+			free := float64(partition.Free) / float64(partition.Total)
+			if free < upgradeDiskHeadroomRatio {
+				failures = append(failures, fmt.Sprintf("Only %.0f%% of %s is free on %s", free*100, partition.Path, host.Host))
+			}
+		}
+	}
+	return newUpgradeCheck("resources", failures, "All hosts have enough free resources", "Some hosts are short of resources")
+}
+
+func (s *Service) fetchUpgradeInstances(ctx context.Context) ([]UpgradeInstance, UpgradeCheck) {
+	instances := make([]UpgradeInstance, 0)
+	failures := make([]string, 0)
+	add := func(component, ip string, port uint, version, gitHash string, status topology.ComponentStatus) {
+		instances = append(instances, UpgradeInstance{
+			Component: component,
+			Address:   net.JoinHostPort(ip, strconv.Itoa(int(port))),
+			Version:   version,
+			GitHash:   gitHash,
+			Status:    status,
+		})
+	}
+	fail := func(component string, err error) {
+		failures = append(failures, fmt.Sprintf("Failed to fetch %s instances: %s", componentDisplayName(component), err))
+	}
+
+	if pdInfo, err := topology.FetchPDTopology(s.params.PDClient); err != nil {
+		fail("pd", err)
+	} else {
+		for _, i := range pdInfo {
+			add("pd", i.IP, i.Port, i.Version, i.GitHash, i.Status)
+		}
+	}
+	// TSO and scheduling services are only available in the microservice mode, so errors are ignored.
+	if tsoInfo, err := topology.FetchTSOTopology(ctx, s.params.PDClient); err == nil {
+		for _, i := range tsoInfo {
+			add("tso", i.IP, i.Port, i.Version, i.GitHash, i.Status)
+		}
+	}
+	if schedulingInfo, err := topology.FetchSchedulingTopology(ctx, s.params.PDClient); err == nil {
+		for _, i := range schedulingInfo {
+			add("scheduling", i.IP, i.Port, i.Version, i.GitHash, i.Status)
+		}
+	}
+	if tikvInfo, tiflashInfo, err := topology.FetchStoreTopology(s.params.PDClient); err != nil {
+		fail("tikv", err)
+	} else {
+		for _, i := range tikvInfo {
+			add("tikv", i.IP, i.Port, i.Version, i.GitHash, i.Status)
+		}
+		for _, i := range tiflashInfo {
+			add("tiflash", i.IP, i.Port, i.Version, i.GitHash, i.Status)
+		}
+	}
+	if tidbInfo, err := topology.FetchTiDBTopology(ctx, s.params.EtcdClient); err != nil {
+		fail("tidb", err)
+	} else {
+		for _, i := range tidbInfo {
+			add("tidb", i.IP, i.Port, i.Version, i.GitHash, i.Status)
+		}
+	}
+	if ticdcInfo, err := topology.FetchTiCDCTopology(ctx, s.params.EtcdClient); err != nil {
+		fail("ticdc", err)
+	} else {
+		for _, i := range ticdcInfo {
+			add("ticdc", i.IP, i.Port, i.Version, i.GitHash, i.Status)
+		}
+	}
+	if tiproxyInfo, err := topology.FetchTiProxyTopology(ctx, s.params.EtcdClient); err != nil {
+		fail("tiproxy", err)
+	} else {
+		for _, i := range tiproxyInfo {
+			add("tiproxy", i.IP, i.Port, i.Version, i.GitHash, i.Status)
+		}
+	}
+
+	order := make(map[string]int, len(upgradeOrder)+1)
+	for i, c := range append(append([]string{}, upgradeOrder...), "tiproxy") {
+		order[c] = i
+	}
+	sort.SliceStable(instances, func(i, j int) bool {
+		if instances[i].Component != instances[j].Component {
+			return order[instances[i].Component] < order[instances[j].Component]
+		}
+		return instances[i].Address < instances[j].Address
+	})
+	return instances, newUpgradeCheck("topology", failures, "All instances are fetched", "Some instances can not be fetched")
+}
+
+// checkUpgradeReadiness checks whether the cluster is ready for the next step of a rolling upgrade. Checks that read
+// from SQL are skipped if the database connection is not provided.
+func (s *Service) checkUpgradeReadiness(ctx context.Context, db *gorm.DB, dbErr error) *UpgradeReadiness {
+	instances, topologyCheck := s.fetchUpgradeInstances(ctx)
+	versions, skews := detectVersionSkews(instances)
+	checks := []UpgradeCheck{topologyCheck, checkUpgradeStores(instances), s.checkUpgradeRegions()}
+
+	if db == nil {
+		if dbErr == nil {
+			dbErr = fmt.Errorf("%s SQL credentials are required", distro.R().TiDB)
+		}
+		checks = append(checks, skippedUpgradeCheck("ddl_jobs", dbErr), skippedUpgradeCheck("resources", dbErr))
+	} else {
+		checks = append(checks, checkUpgradeDDLJobs(db))
+		hosts, err := s.fetchAllHostsInfo(db)
+		if err != nil && hosts == nil {
+			checks = append(checks, skippedUpgradeCheck("resources", err))
+		} else {
+			checks = append(checks, checkUpgradeResources(hosts))
+		}
+	}
+
+	return &UpgradeReadiness{
+		Verdict:   upgradeVerdict(skews, checks),
+		CheckedAt: time.Now().Unix(),
+		Instances: instances,
+		Versions:  versions,
+		Skews:     skews,
+		Checks:    checks,
+	}
+}
+
+func upgradeVerdict(skews []VersionSkew, checks []UpgradeCheck) UpgradeVerdict {
+	for _, skew := range skews {
+		if skew.Blocking {
+			return UpgradeVerdictBlocked
+		}
+	}
+	for _, check := range checks {
+		if check.Status != UpgradeCheckPassed {
+			return UpgradeVerdictBlocked
+		}
+	}
+	return UpgradeVerdictReady
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package clusterinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+func TestDetectVersionSkews(t *testing.T) {
+	versions, skews := detectVersionSkews([]UpgradeInstance{
+		{Component: "pd", Address: "pd-1", Version: "v8.5.0", Status: topology.ComponentStatusUp},
+		{Component: "pd", Address: "pd-2", Version: "v8.5.0", Status: topology.ComponentStatusUp},
+		{Component: "tikv", Address: "tikv-1", Version: "8.5.0", Status: topology.ComponentStatusUp},
+		{Component: "tikv", Address: "tikv-2", Version: "7.5.1", Status: topology.ComponentStatusUp},
+		{Component: "tikv", Address: "tikv-3", Version: "6.5.0", Status: topology.ComponentStatusTombstone},
+		{Component: "tidb", Address: "tidb-1", Version: "7.5.1", Status: topology.ComponentStatusUp},
+		{Component: "tiproxy", Address: "tiproxy-1", Version: "v1.3.0", Status: topology.ComponentStatusUp},
+	})
+	require.Equal(t, map[string][]string{
+		"pd":      {"v8.5.0"},
+		"tikv":    {"7.5.1", "8.5.0"},
+		"tidb":    {"7.5.1"},
+		"tiproxy": {"v1.3.0"},
+	}, versions)
+	require.Len(t, skews, 2)
+	require.Equal(t, VersionSkewMixedInstances, skews[0].Kind)
+	require.Equal(t, []string{"tikv"}, skews[0].Components)
+	require.Equal(t, VersionSkew{
+		Kind:       VersionSkewMixedComponents,
+		Components: []string{"pd", "tikv", "tidb"},
+		Versions:   []string{"7.5.1", "8.5.0"},
+		Message:    "Components run 2 different release versions",
+	}, skews[1])
+	require.Equal(t, UpgradeVerdictReady, upgradeVerdict(skews, nil))
+
+	_, skews = detectVersionSkews([]UpgradeInstance{
+		{Component: "pd", Address: "pd-1", Version: "v7.5.1"},
+		{Component: "tidb", Address: "tidb-1", Version: "v8.5.0-alpha"},
+	})
+	require.Len(t, skews, 2)
+	require.Equal(t, VersionSkewUpgradeOrder, skews[1].Kind)
+	require.True(t, skews[1].Blocking)
+	require.Equal(t, []string{"7.5.1", "8.5.0"}, skews[1].Versions)
+	require.Equal(t, "TiDB 8.5.0 is newer than PD 7.5.1, which should be upgraded first", skews[1].Message)
+	require.Equal(t, UpgradeVerdictBlocked, upgradeVerdict(skews, nil))
+}
+
+func TestUpgradeChecks(t *testing.T) {
+	check := checkUpgradeStores([]UpgradeInstance{
+		{Component: "pd", Address: "pd-1", Status: topology.ComponentStatusDown},
+		{Component: "tikv", Address: "tikv-1", Status: topology.ComponentStatusUp},
+		{Component: "tikv", Address: "tikv-2", Status: topology.ComponentStatusOffline},
+		{Component: "tiflash", Address: "tiflash-1", Status: topology.ComponentStatusDown},
+		{Component: "tikv", Address: "tikv-3", Status: topology.ComponentStatusTombstone},
+	})
+	require.Equal(t, UpgradeCheckFailed, check.Status)
+	require.Equal(t, []string{"TiKV tikv-2 is offline", "TiFlash tiflash-1 is down"}, check.Details)
+
+	host := hostinfo.NewHostInfo("10.0.0.1")
+	host.MemoryUsage = &hostinfo.MemoryUsageInfo{Total: 100, Used: 85}
+	host.Partitions["/data"] = &hostinfo.PartitionInfo{Path: "/data", Total: 100, Free: 50}
+	check = checkUpgradeResources([]*hostinfo.Info{host})
+	require.Equal(t, UpgradeCheckFailed, check.Status)
+	require.Equal(t, []string{"Only 15% of memory is free on 10.0.0.1"}, check.Details)
+
+	host.MemoryUsage.Used = 50
+	check = checkUpgradeResources([]*hostinfo.Info{host})
+	require.Equal(t, UpgradeCheckPassed, check.Status)
+	require.Equal(t, UpgradeVerdictReady, upgradeVerdict(nil, []UpgradeCheck{check}))
+	require.Equal(t, UpgradeVerdictBlocked, upgradeVerdict(nil, []UpgradeCheck{check, {Name: "ddl_jobs", Status: UpgradeCheckSkipped}}))
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func (s *Service) getConfigItemsFromTiFlashProxyToChannel(tiflash *topology.StoreInfo, ch chan<- channelItem) {
+	displayAddress := net.JoinHostPort(tiflash.IP, strconv.Itoa(int(tiflash.Port)))
+
+	// The status port of TiFlash stores is served by the TiFlash proxy, which reports its own config like TiKV.
+	data, err := s.params.TiFlashClient.SendGetRequest(tiflash.IP, int(tiflash.StatusPort), "/config")
+	var r map[string]interface{}
+	if err == nil {
+		r, err = processNestedConfigAPIResponse(data)
+	}
+	if err != nil {
+		ch <- channelItem{Err: ErrListConfigItemsFailed.Wrap(err, "Failed to list %s proxy config items of %s", distro.R().TiFlash, displayAddress)}
+		return
+	}
+	ch <- channelItem{
+		Err:                  nil,
+		SourceDisplayAddress: displayAddress,
+		SourceKind:           ItemKindTiFlashProxyConfig,
+		Values:               r,
+	}
+}
+
+func (s *Service) getConfigItemsFromTiCDCToChannel(ticdc *topology.TiCDCInfo, ch chan<- channelItem) {
+	displayAddress := net.JoinHostPort(ticdc.IP, strconv.Itoa(int(ticdc.Port)))
+
+	data, err := s.params.TiCDCClient.SendGetRequest(ticdc.IP, int(ticdc.StatusPort), "/config")
+	var r map[string]interface{}
+	if err == nil {
+		r, err = processNestedConfigAPIResponse(data)
+	}
+	if err != nil {
+		ch <- channelItem{Err: ErrListConfigItemsFailed.Wrap(err, "Failed to list %s config items of %s", distro.R().TiCDC, displayAddress)}
+		return
+	}
+	ch <- channelItem{
+		Err:                  nil,
+		SourceDisplayAddress: displayAddress,
+		SourceKind:           ItemKindTiCDCConfig,
+		Values:               r,
+	}
+}
+
+func (s *Service) getConfigItemsFromTiProxyToChannel(tiproxy *topology.TiProxyInfo, ch chan<- channelItem) {
+	displayAddress := net.JoinHostPort(tiproxy.IP, strconv.Itoa(int(tiproxy.Port)))
+
+	data, err := s.params.TiProxyClient.SendGetRequest(tiproxy.IP, int(tiproxy.StatusPort), "/api/admin/config/?format=json")
+	var r map[string]interface{}
+	if err == nil {
+		r, err = processNestedConfigAPIResponse(data)
+	}
+	if err != nil {
+		ch <- channelItem{Err: ErrListConfigItemsFailed.Wrap(err, "Failed to list %s config items of %s", distro.R().TiProxy, displayAddress)}
+		return
+	}
+	ch <- channelItem{
+		Err:                  nil,
+		SourceDisplayAddress: displayAddress,
+		SourceKind:           ItemKindTiProxyConfig,
+		Values:               r,
+	}
+}
+
+func (s *Service) editTiCDCConfig(id string, newValue interface{}) ([]rest.ErrorResponse, []InstanceEditResult, error) {
+	level, ok := newValue.(string)
+	if id != "log-level" || !ok {
+		return nil, nil, ErrEditFailed.New("Unsupported value `%v` for configuration `%s`", newValue, id)
+	}
+	bodyJSON, err := json.Marshal(map[string]string{"log_level": level})
+	if err != nil {
+		return nil, nil, ErrEditFailed.WrapWithNoMessage(err)
+	}
+	ticdcInfo, err := topology.FetchTiCDCTopology(s.lifecycleCtx, s.params.EtcdClient)
+	if err != nil {
+		return nil, nil, ErrEditFailed.WrapWithNoMessage(ErrListTopologyFailed.WrapWithNoMessage(err))
+	}
+	instances := make([]editInstance, 0, len(ticdcInfo))
+	for _, info := range ticdcInfo {
+		instances = append(instances, editInstance{
+			Address:    net.JoinHostPort(info.IP, strconv.Itoa(int(info.Port))),
+			IP:         info.IP,
+			StatusPort: int(info.StatusPort),
+		})
+	}
+	return editInstances(instances, func(instance editInstance) error {
+		_, err := s.params.TiCDCClient.SendPostRequest(instance.IP, instance.StatusPort, "/api/v1/log", bytes.NewBuffer(bodyJSON))
+		if err != nil {
+			return ErrEditFailed.Wrap(err, "Failed to edit config for %s instance `%s`", distro.R().TiCDC, instance.Address)
+		}
+		return nil
+	})
+}
+
+func (s *Service) editTiProxyConfig(id string, newValue interface{}) ([]rest.ErrorResponse, []InstanceEditResult, error) {
+	patch, err := tomlConfigPatch(id, newValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	tiproxyInfo, err := topology.FetchTiProxyTopology(s.lifecycleCtx, s.params.EtcdClient)
+	if err != nil {
+		return nil, nil, ErrEditFailed.WrapWithNoMessage(ErrListTopologyFailed.WrapWithNoMessage(err))
+	}
+	instances := make([]editInstance, 0, len(tiproxyInfo))
+	for _, info := range tiproxyInfo {
+		instances = append(instances, editInstance{
+			Address:    net.JoinHostPort(info.IP, strconv.Itoa(int(info.Port))),
+			IP:         info.IP,
+			StatusPort: int(info.StatusPort),
+		})
+	}
+	return editInstances(instances, func(instance editInstance) error {
+		// TiProxy merges the TOML in the request body into its current config.
+		_, err := s.params.TiProxyClient.SendPutRequest(instance.IP, instance.StatusPort, "/api/admin/config/", strings.NewReader(patch))
+		if err != nil {
+			return ErrEditFailed.Wrap(err, "Failed to edit config for %s instance `%s`", distro.R().TiProxy, instance.Address)
+		}
+		return nil
+	})
+}
+
+// tomlConfigPatch encodes a single flattened config item as TOML, e.g. `proxy.max-connections` to
+// `[proxy]\nmax-connections = 100`.
+func tomlConfigPatch(id string, newValue interface{}) (string, error) {
+	var value string
+	switch v := newValue.(type) {
+	case bool:
+		value = strconv.FormatBool(v)
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			value = strconv.FormatInt(int64(v), 10)
+		} else {
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case string:
+		value = strconv.Quote(v)
+	default:
+		return "", ErrEditFailed.New("Unsupported value `%v` for configuration `%s`", newValue, id)
+	}
+	idx := strings.LastIndex(id, ".")
+	if idx < 0 {
+		return fmt.Sprintf("%s = %s\n", id, value), nil
+	}
+	return fmt.Sprintf("[%s]\n%s = %s\n", id[:idx], id[idx+1:], value), nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOMLConfigPatch(t *testing.T) {
+	patch, err := tomlConfigPatch("proxy.max-connections", 100.0)
+	require.NoError(t, err)
+	require.Equal(t, "[proxy]\nmax-connections = 100\n", patch)
+
+	patch, err = tomlConfigPatch("log.level", "warn")
+	require.NoError(t, err)
+	require.Equal(t, "[log]\nlevel = \"warn\"\n", patch)
+
+	patch, err = tomlConfigPatch("balance.label.enable", true)
+	require.NoError(t, err)
+	require.Equal(t, "[balance.label]\nenable = true\n", patch)
+
+	patch, err = tomlConfigPatch("workdir", "/tmp")
+	require.NoError(t, err)
+	require.Equal(t, "workdir = \"/tmp\"\n", patch)
+
+	_, err = tomlConfigPatch("proxy.max-connections", nil)
+	require.Error(t, err)
+}
+
+func TestComponentEditable(t *testing.T) {
+	require.True(t, isConfigItemEditable(ItemKindTiCDCConfig, "log-level"))
+	require.True(t, isConfigItemEditable(ItemKindTiProxyConfig, "proxy.max-connections"))
+	require.False(t, isConfigItemEditable(ItemKindTiProxyConfig, "proxy.addr"))
+	require.False(t, isConfigItemEditable(ItemKindTiFlashProxyConfig, "logger.level"))
+}
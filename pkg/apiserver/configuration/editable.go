@@ -160,6 +160,20 @@ pd-server.key-type
 pd-server.metric-storage
 pd-server.dashboard-address
 replication-mode.replication-mode
+`,
+
+	// TiCDC only supports changing the log level online.
+	ItemKindTiCDCConfig: `
+log-level
+`,
+	// Items that TiProxy applies online through its config API.
+	ItemKindTiProxyConfig: `
+log.level
+proxy.max-connections
+proxy.conn-buffer-size
+proxy.graceful-wait-before-shutdown
+proxy.graceful-close-conn-timeout
+balance.policy
 `,
 
 	// Mark all global variables in TiDB being editable.
@@ -584,6 +598,15 @@ tidb_enable_telemetry
 `,
 }
 
+// TiDB config items that can be changed online through the `/settings` API of each instance,
+// mapped to the name of the API parameter.
+var tidbSettingsParams = map[string]string{
+	"log.level":                   "log_level",
+	"instance.tidb_general_log":   "tidb_general_log",
+	"instance.ddl_slow_threshold": "ddl_slow_threshold",
+	"check-mb4-value-in-utf8":     "check_mb4_value_in_utf8",
+}
+
 var editableConfigItems = map[ItemKind]map[string]struct{}{}
 
 func init() {
@@ -594,6 +617,10 @@ func init() {
 			editableConfigItems[kind][key] = struct{}{}
 		}
 	}
+	editableConfigItems[ItemKindTiDBConfig] = make(map[string]struct{})
+	for key := range tidbSettingsParams {
+		editableConfigItems[ItemKindTiDBConfig][key] = struct{}{}
+	}
 }
 
 func isConfigItemEditable(kind ItemKind, key string) bool {
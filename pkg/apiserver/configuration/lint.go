@@ -0,0 +1,420 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+	"github.com/pingcap/tidb-dashboard/util/netutil"
+)
+
+type LintSeverity string
+
+const (
+	LintSeverityInfo     LintSeverity = "info"
+	LintSeverityWarning  LintSeverity = "warning"
+	LintSeverityCritical LintSeverity = "critical"
+)
+
+type LintFinding struct {
+	Rule           string       `json:"rule"`
+	Severity       LintSeverity `json:"severity"`
+	Kind           ItemKind     `json:"kind"`
+	ConfigID       string       `json:"config_id"`
+	Message        string       `json:"message"`
+	Instances      []string     `json:"instances"`
+	CurrentValue   interface{}  `json:"current_value"`
+	SuggestedValue interface{}  `json:"suggested_value,omitempty"`
+}
+
+type lintRule struct {
+	Name  string
+	Check func(ctx *lintContext) []LintFinding
+}
+
+// lintContext provides the config of each instance and the host it runs on.
+type lintContext struct {
+	items []channelItem
+	hosts map[string]*hostinfo.Info
+}
+
+func newLintContext(items []channelItem, hosts []*hostinfo.Info) *lintContext {
+	ctx := &lintContext{items: items, hosts: make(map[string]*hostinfo.Info)}
+	for _, host := range hosts {
+		ctx.hosts[host.Host] = host
+	}
+	sort.Slice(ctx.items, func(i, j int) bool {
+		if ctx.items[i].SourceKind != ctx.items[j].SourceKind {
+			return ctx.items[i].SourceKind < ctx.items[j].SourceKind
+		}
+		return ctx.items[i].SourceDisplayAddress < ctx.items[j].SourceDisplayAddress
+	})
+	return ctx
+}
+
+func (ctx *lintContext) instancesOf(kind ItemKind) []channelItem {
+	r := make([]channelItem, 0)
+	for _, item := range ctx.items {
+		if item.SourceKind == kind {
+			r = append(r, item)
+		}
+	}
+	return r
+}
+
+func (ctx *lintContext) hostOf(address string) *hostinfo.Info {
+	hostname, _, err := netutil.ParseHostAndPortFromAddress(address)
+	if err != nil {
+		return nil
+	}
+	return ctx.hosts[hostname]
+}
+
+func (ctx *lintContext) hostMemory(address string) uint64 {
+	host := ctx.hostOf(address)
+	if host == nil || host.MemoryUsage == nil || host.MemoryUsage.Total <= 0 {
+		return 0
+	}
+	return uint64(host.MemoryUsage.Total)
+}
+
+// dataPartition returns the partition where the data directory of the instance is located.
+func (ctx *lintContext) dataPartition(address string) *hostinfo.PartitionInfo {
+	host := ctx.hostOf(address)
+	if host == nil {
+		return nil
+	}
+	instance, ok := host.Instances[address]
+	if !ok || instance.PartitionPathL == "" {
+		return nil
+	}
+	return host.Partitions[instance.PartitionPathL]
+}
+
+var byteSizeRE = regexp.MustCompile(`^(?i)\s*([0-9.]+)\s*([KMGTP]?I?B?)\s*$`)
+
+// parseByteSize parses sizes in bytes, or readable sizes like `512MiB`. Like TiKV, `KB` and `KiB` are both 1024 bytes.
+func parseByteSize(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case float64:
+		if v < 0 {
+			return 0, false
+		}
+		return uint64(v), true
+	case string:
+		m := byteSizeRE.FindStringSubmatch(v)
+		if m == nil {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		unit := strings.ToUpper(m[2])
+		exp := 0
+		if unit != "" {
+			exp = strings.IndexByte("BKMGTP", unit[0])
+		}
+		return uint64(n * math.Pow(1024, float64(exp))), true
+	default:
+		return 0, false
+	}
+}
+
+func formatByteSize(size uint64) string {
+	const units = "KMGTP"
+	unit := -1
+	for size >= 1024 && size%1024 == 0 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit < 0 {
+		return fmt.Sprintf("%dB", size)
+	}
+	return fmt.Sprintf("%d%ciB", size, units[unit])
+}
+
+// roundDownByteSize rounds the size down to MiB, so that the suggested value is readable.
+func roundDownByteSize(size uint64) uint64 {
+	return size / (1 << 20) * (1 << 20)
+}
+
+// TiKV uses 45% of the host memory for the block cache by default.
+const tikvBlockCacheMemoryRatio = 0.45
+
+func lintTiKVBlockCache(ctx *lintContext) []LintFinding {
+	// Group TiKV instances by host, since instances on the same host share the memory.
+	byHost := make(map[string][]channelItem)
+	hostOrder := make([]string, 0)
+	for _, item := range ctx.instancesOf(ItemKindTiKVConfig) {
+		host := ctx.hostOf(item.SourceDisplayAddress)
+		if host == nil || ctx.hostMemory(item.SourceDisplayAddress) == 0 {
+			continue
+		}
+		if _, ok := byHost[host.Host]; !ok {
+			hostOrder = append(hostOrder, host.Host)
+		}
+		byHost[host.Host] = append(byHost[host.Host], item)
+	}
+
+	findings := make([]LintFinding, 0)
+	for _, hostname := range hostOrder {
+		items := byHost[hostname]
+		memory := ctx.hostMemory(items[0].SourceDisplayAddress)
+		suggested := formatByteSize(roundDownByteSize(uint64(float64(memory) * tikvBlockCacheMemoryRatio / float64(len(items)))))
+
+		total := uint64(0)
+		auto := make([]string, 0)
+		instances := make([]string, 0, len(items))
+		for _, item := range items {
+			instances = append(instances, item.SourceDisplayAddress)
+			size, _ := parseByteSize(item.Values["storage.block-cache.capacity"])
+			if size == 0 {
+				auto = append(auto, item.SourceDisplayAddress)
+				size = uint64(float64(memory) * tikvBlockCacheMemoryRatio)
+			}
+			total += size
+		}
+		if len(items) > 1 && len(auto) > 0 {
+			findings = append(findings, LintFinding{
+				Severity:       LintSeverityCritical,
+				Kind:           ItemKindTiKVConfig,
+				ConfigID:       "storage.block-cache.capacity",
+				Message:        fmt.Sprintf("%d TiKV instances share host %s, but the block cache size is not set, so that each instance uses %.0f%% of the host memory", len(items), hostname, tikvBlockCacheMemoryRatio*100),
+				Instances:      auto,
+				CurrentValue:   items[0].Values["storage.block-cache.capacity"],
+				SuggestedValue: suggested,
+			})
+			continue
+		}
+		if float64(total) > float64(memory)*0.6 {
+			findings = append(findings, LintFinding{
+				Severity:       LintSeverityWarning,
+				Kind:           ItemKindTiKVConfig,
+				ConfigID:       "storage.block-cache.capacity",
+				Message:        fmt.Sprintf("Block caches of TiKV instances on host %s take %s, more than 60%% of the host memory %s", hostname, formatByteSize(total), formatByteSize(memory)),
+				Instances:      instances,
+				CurrentValue:   items[0].Values["storage.block-cache.capacity"],
+				SuggestedValue: suggested,
+			})
+		}
+	}
+	return findings
+}
+
+func lintTiKVCapacity(ctx *lintContext) []LintFinding {
+	// Group TiKV instances by the partition of the data directory.
+	type partitionKey struct {
+		host string
+		path string
+	}
+	byPartition := make(map[partitionKey][]channelItem)
+	partitionOrder := make([]partitionKey, 0)
+	findings := make([]LintFinding, 0)
+	for _, item := range ctx.instancesOf(ItemKindTiKVConfig) {
+		address := item.SourceDisplayAddress
+		partition := ctx.dataPartition(address)
+		if partition == nil || partition.Total <= 0 {
+			continue
+		}
+		capacity, _ := parseByteSize(item.Values["raftstore.capacity"])
+		if capacity > uint64(partition.Total) {
+			findings = append(findings, LintFinding{
+				Severity:       LintSeverityCritical,
+				Kind:           ItemKindTiKVConfig,
+				ConfigID:       "raftstore.capacity",
+				Message:        fmt.Sprintf("The capacity is larger than the size %s of the data disk %s", formatByteSize(uint64(partition.Total)), partition.Path),
+				Instances:      []string{address},
+				CurrentValue:   item.Values["raftstore.capacity"],
+				SuggestedValue: formatByteSize(roundDownByteSize(uint64(partition.Total))),
+			})
+			continue
+		}
+		if capacity == 0 {
+			key := partitionKey{host: ctx.hostOf(address).Host, path: strings.ToLower(partition.Path)}
+			if _, ok := byPartition[key]; !ok {
+				partitionOrder = append(partitionOrder, key)
+			}
+			byPartition[key] = append(byPartition[key], item)
+		}
+	}
+	for _, key := range partitionOrder {
+		items := byPartition[key]
+		if len(items) < 2 {
+			continue
+		}
+		partition := ctx.dataPartition(items[0].SourceDisplayAddress)
+		instances := make([]string, 0, len(items))
+		for _, item := range items {
+			instances = append(instances, item.SourceDisplayAddress)
+		}
+		findings = append(findings, LintFinding{
+			Severity:       LintSeverityWarning,
+			Kind:           ItemKindTiKVConfig,
+			ConfigID:       "raftstore.capacity",
+			Message:        fmt.Sprintf("%d TiKV instances share the data disk %s on host %s without a capacity limit", len(items), partition.Path, key.host),
+			Instances:      instances,
+			CurrentValue:   items[0].Values["raftstore.capacity"],
+			SuggestedValue: formatByteSize(roundDownByteSize(uint64(partition.Total) / uint64(len(items)))),
+		})
+	}
+	return findings
+}
+
+func lintTiKVReserveSpace(ctx *lintContext) []LintFinding {
+	const id = "storage.reserve-space"
+	values := make(map[string][]string)
+	raw := make(map[string]interface{})
+	for _, item := range ctx.instancesOf(ItemKindTiKVConfig) {
+		value, ok := item.Values[id]
+		if !ok {
+			continue
+		}
+		key := fmt.Sprint(value)
+		values[key] = append(values[key], item.SourceDisplayAddress)
+		raw[key] = value
+	}
+	if len(values) < 2 {
+		return nil
+	}
+	majority := ""
+	for key, instances := range values {
+		if majority == "" || len(instances) > len(values[majority]) || (len(instances) == len(values[majority]) && key < majority) {
+			majority = key
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != majority {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	findings := make([]LintFinding, 0, len(keys))
+	for _, key := range keys {
+		findings = append(findings, LintFinding{
+			Severity:       LintSeverityWarning,
+			Kind:           ItemKindTiKVConfig,
+			ConfigID:       id,
+			Message:        "The reserved space differs from other TiKV instances",
+			Instances:      values[key],
+			CurrentValue:   raw[key],
+			SuggestedValue: raw[majority],
+		})
+	}
+	return findings
+}
+
+// A single query should not use more than 80% of the host memory, otherwise one query may cause OOM.
+const tidbMemQuotaQueryMemoryRatio = 0.8
+
+func lintTiDBMemQuotaQuery(ctx *lintContext) []LintFinding {
+	var quotaVariable interface{}
+	for _, item := range ctx.instancesOf(ItemKindTiDBVariable) {
+		quotaVariable = item.Values["tidb_mem_quota_query"]
+	}
+
+	findings := make([]LintFinding, 0)
+	for _, item := range ctx.instancesOf(ItemKindTiDBConfig) {
+		address := item.SourceDisplayAddress
+		memory := ctx.hostMemory(address)
+		if memory == 0 {
+			continue
+		}
+		kind, id, value := ItemKindTiDBConfig, "mem-quota-query", item.Values["mem-quota-query"]
+		if quotaVariable != nil {
+			// The system variable takes precedence since TiDB v6.1.
+			kind, id, value = ItemKindTiDBVariable, "tidb_mem_quota_query", quotaVariable
+		}
+		quota, ok := parseByteSize(value)
+		if !ok || float64(quota) <= float64(memory)*tidbMemQuotaQueryMemoryRatio {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			Severity:       LintSeverityWarning,
+			Kind:           kind,
+			ConfigID:       id,
+			Message:        fmt.Sprintf("The memory quota of a query is more than %.0f%% of the host memory %s", tidbMemQuotaQueryMemoryRatio*100, formatByteSize(memory)),
+			Instances:      []string{address},
+			CurrentValue:   value,
+			SuggestedValue: strconv.FormatUint(roundDownByteSize(memory/2), 10), // Unlike TiKV, TiDB only accepts bytes
+		})
+	}
+	return findings
+}
+
+type deprecatedItem struct {
+	Kind        ItemKind
+	ID          string
+	Default     interface{} // Deprecated items are still reported with the default value, which is not a finding
+	Replacement string
+}
+
+var deprecatedItems = []deprecatedItem{
+	{ItemKindTiDBConfig, "mem-quota-query", 1073741824.0, "system variable tidb_mem_quota_query"},
+	{ItemKindTiDBConfig, "oom-action", "cancel", "system variable tidb_mem_oom_action"},
+	{ItemKindTiDBConfig, "log.enable-slow-log", true, "instance.tidb_enable_slow_log"},
+	{ItemKindTiDBConfig, "log.slow-threshold", 300.0, "instance.tidb_slow_log_threshold"},
+	{ItemKindTiDBConfig, "prepared-plan-cache.enabled", true, "system variable tidb_enable_prepared_plan_cache"},
+	{ItemKindTiKVConfig, "raftstore.sync-log", true, ""},
+	{ItemKindTiKVConfig, "storage.block-cache.shared", true, ""},
+}
+
+func lintDeprecatedItems(ctx *lintContext) []LintFinding {
+	findings := make([]LintFinding, 0)
+	for _, deprecated := range deprecatedItems {
+		instances := make([]string, 0)
+		var current interface{}
+		for _, item := range ctx.instancesOf(deprecated.Kind) {
+			value, ok := item.Values[deprecated.ID]
+			if !ok || valueEquals(value, deprecated.Default) {
+				continue
+			}
+			instances = append(instances, item.SourceDisplayAddress)
+			current = value
+		}
+		if len(instances) == 0 {
+			continue
+		}
+		message := fmt.Sprintf("`%s` is deprecated and no longer takes effect", deprecated.ID)
+		if deprecated.Replacement != "" {
+			message = fmt.Sprintf("`%s` is deprecated, use %s instead", deprecated.ID, deprecated.Replacement)
+		}
+		findings = append(findings, LintFinding{
+			Severity:       LintSeverityInfo,
+			Kind:           deprecated.Kind,
+			ConfigID:       deprecated.ID,
+			Message:        message,
+			Instances:      instances,
+			CurrentValue:   current,
+			SuggestedValue: deprecated.Default,
+		})
+	}
+	return findings
+}
+
+var lintRules = []lintRule{
+	{Name: "tikv-block-cache-size", Check: lintTiKVBlockCache},
+	{Name: "tikv-capacity", Check: lintTiKVCapacity},
+	{Name: "tikv-reserve-space", Check: lintTiKVReserveSpace},
+	{Name: "tidb-mem-quota-query", Check: lintTiDBMemQuotaQuery},
+	{Name: "deprecated-items", Check: lintDeprecatedItems},
+}
+
+func lintConfig(items []channelItem, hosts []*hostinfo.Info) []LintFinding {
+	ctx := newLintContext(items, hosts)
+	findings := make([]LintFinding, 0)
+	for _, rule := range lintRules {
+		for _, finding := range rule.Check(ctx) {
+			finding.Rule = rule.Name
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
+)
+
+const gib = 1 << 30
+
+func testHost(name string, memory int, disk int, instances ...string) *hostinfo.Info {
+	host := hostinfo.NewHostInfo(name)
+	host.MemoryUsage = &hostinfo.MemoryUsageInfo{Total: memory}
+	host.Partitions["/data"] = &hostinfo.PartitionInfo{Path: "/data", Total: disk}
+	for _, instance := range instances {
+		host.Instances[instance] = &hostinfo.InstanceInfo{Type: "tikv", PartitionPathL: "/data"}
+	}
+	return host
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[interface{}]uint64{
+		"512MiB":      512 << 20,
+		"512MB":       512 << 20,
+		"7.5GiB":      7.5 * gib,
+		"0KiB":        0,
+		"100":         100,
+		"1 TB":        1 << 40,
+		float64(4096): 4096,
+	}
+	for input, expected := range cases {
+		size, ok := parseByteSize(input)
+		require.True(t, ok, "%v", input)
+		require.Equal(t, expected, size, "%v", input)
+	}
+	_, ok := parseByteSize("abc")
+	require.False(t, ok)
+	_, ok = parseByteSize(true)
+	require.False(t, ok)
+
+	require.Equal(t, "7GiB", formatByteSize(7*gib))
+	require.Equal(t, "1536MiB", formatByteSize(1536<<20))
+	require.Equal(t, "100B", formatByteSize(100))
+}
+
+func TestLintConfig(t *testing.T) {
+	items := []channelItem{
+		tikvItem("10.0.0.1:20160", map[string]interface{}{
+			"storage.block-cache.capacity": "0KiB",
+			"raftstore.capacity":           "0KiB",
+			"storage.reserve-space":        "5GiB",
+			"raftstore.sync-log":           true,
+		}),
+		tikvItem("10.0.0.1:20161", map[string]interface{}{
+			"storage.block-cache.capacity": "0KiB",
+			"raftstore.capacity":           "0KiB",
+			"storage.reserve-space":        "5GiB",
+			"raftstore.sync-log":           false,
+		}),
+		tikvItem("10.0.0.2:20160", map[string]interface{}{
+			"storage.block-cache.capacity": "50GiB",
+			"raftstore.capacity":           "2TiB",
+			"storage.reserve-space":        "0KiB",
+		}),
+		{SourceKind: ItemKindTiDBConfig, SourceDisplayAddress: "10.0.0.3:4000", Values: map[string]interface{}{
+			"mem-quota-query": 1073741824.0,
+			"oom-action":      "log",
+		}},
+		{SourceKind: ItemKindTiDBVariable, Values: map[string]interface{}{"tidb_mem_quota_query": "34359738368"}},
+	}
+	hosts := []*hostinfo.Info{
+		testHost("10.0.0.1", 64*gib, 1000*gib, "10.0.0.1:20160", "10.0.0.1:20161"),
+		testHost("10.0.0.2", 64*gib, 1000*gib, "10.0.0.2:20160"),
+		testHost("10.0.0.3", 32*gib, 100*gib),
+	}
+
+	findings := lintConfig(items, hosts)
+	byRule := make(map[string][]LintFinding)
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+
+	blockCache := byRule["tikv-block-cache-size"]
+	require.Len(t, blockCache, 2)
+	require.Equal(t, LintSeverityCritical, blockCache[0].Severity)
+	require.Equal(t, []string{"10.0.0.1:20160", "10.0.0.1:20161"}, blockCache[0].Instances)
+	require.Equal(t, "14745MiB", blockCache[0].SuggestedValue)
+	require.Equal(t, LintSeverityWarning, blockCache[1].Severity)
+	require.Equal(t, []string{"10.0.0.2:20160"}, blockCache[1].Instances)
+
+	capacity := byRule["tikv-capacity"]
+	require.Len(t, capacity, 2)
+	require.Equal(t, LintSeverityCritical, capacity[0].Severity)
+	require.Equal(t, []string{"10.0.0.2:20160"}, capacity[0].Instances)
+	require.Equal(t, "1000GiB", capacity[0].SuggestedValue)
+	require.Equal(t, []string{"10.0.0.1:20160", "10.0.0.1:20161"}, capacity[1].Instances)
+	require.Equal(t, "500GiB", capacity[1].SuggestedValue)
+
+	reserve := byRule["tikv-reserve-space"]
+	require.Len(t, reserve, 1)
+	require.Equal(t, []string{"10.0.0.2:20160"}, reserve[0].Instances)
+	require.Equal(t, "5GiB", reserve[0].SuggestedValue)
+
+	quota := byRule["tidb-mem-quota-query"]
+	require.Len(t, quota, 1)
+	require.Equal(t, ItemKindTiDBVariable, quota[0].Kind)
+	require.Equal(t, []string{"10.0.0.3:4000"}, quota[0].Instances)
+	require.Equal(t, "17179869184", quota[0].SuggestedValue)
+
+	deprecated := byRule["deprecated-items"]
+	require.Len(t, deprecated, 2)
+	require.Equal(t, "oom-action", deprecated[0].ConfigID)
+	require.Equal(t, "raftstore.sync-log", deprecated[1].ConfigID)
+	require.Equal(t, []string{"10.0.0.1:20161"}, deprecated[1].Instances)
+}
@@ -0,0 +1,406 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+type PlanStatus string
+
+const (
+	PlanStatusDraft      PlanStatus = "draft"
+	PlanStatusApplied    PlanStatus = "applied"
+	PlanStatusFailed     PlanStatus = "failed"
+	PlanStatusRolledBack PlanStatus = "rolled_back"
+)
+
+// PlanChange is a staged edit of a configuration item.
+type PlanChange struct {
+	Kind     ItemKind    `json:"kind"`
+	ID       string      `json:"id"`
+	NewValue interface{} `json:"new_value"`
+	Target   EditTarget  `json:"target"`
+}
+
+type PlanChanges []PlanChange
+
+func (r *PlanChanges) Scan(src interface{}) error {
+	return json.Unmarshal([]byte(src.(string)), r)
+}
+
+func (r PlanChanges) Value() (driver.Value, error) {
+	val, err := json.Marshal(r)
+	return string(val), err
+}
+
+type InstanceVerification struct {
+	Instance string              `json:"instance"`
+	OldValue interface{}         `json:"old_value"`
+	Value    interface{}         `json:"value"`    // The value read back after applying the plan
+	Verified bool                `json:"verified"` // Whether the value read back is the new value
+	Error    *rest.ErrorResponse `json:"error,omitempty"`
+	Warning  string              `json:"warning,omitempty"` // See InstanceEditResult
+}
+
+type PlanChangeResult struct {
+	Kind      ItemKind               `json:"kind"`
+	ID        string                 `json:"id"`
+	Error     *rest.ErrorResponse    `json:"error,omitempty"`
+	Instances []InstanceVerification `json:"instances"`
+}
+
+type PlanResults []PlanChangeResult
+
+func (r *PlanResults) Scan(src interface{}) error {
+	return json.Unmarshal([]byte(src.(string)), r)
+}
+
+func (r PlanResults) Value() (driver.Value, error) {
+	val, err := json.Marshal(r)
+	return string(val), err
+}
+
+// PlanModel is a list of configuration changes to be applied in order.
+type PlanModel struct {
+	ID             uint        `json:"id" gorm:"primary_key"`
+	CreatedAt      int64       `json:"created_at"`
+	AppliedAt      int64       `json:"applied_at"`
+	Description    string      `json:"description"`
+	Status         PlanStatus  `json:"status" gorm:"size:16"`
+	Changes        PlanChanges `json:"changes" gorm:"type:text"`
+	Results        PlanResults `json:"results" gorm:"type:text"`
+	RollbackOf     uint        `json:"rollback_of"`      // The plan reverted by this plan
+	RollbackPlanID uint        `json:"rollback_plan_id"` // The plan reverting this plan, generated when this plan is applied
+}
+
+func (PlanModel) TableName() string {
+	return "configuration_plans"
+}
+
+func validatePlanChanges(changes []PlanChange) error {
+	if len(changes) == 0 {
+		return rest.ErrBadRequest.New("No change in the plan")
+	}
+	for _, change := range changes {
+		if !isConfigItemEditable(change.Kind, change.ID) {
+			return ErrNotEditable.New("Configuration `%s` is not editable", change.ID)
+		}
+		if !change.Target.IsEmpty() && change.Kind != ItemKindTiKVConfig && change.Kind != ItemKindTiDBConfig {
+			return rest.ErrBadRequest.New("Configuration of kind `%s` can only be edited cluster-wide", change.Kind)
+		}
+	}
+	return nil
+}
+
+func (s *Service) loadPlan(id uint) (*PlanModel, error) {
+	var plan PlanModel
+	if err := s.params.LocalStore.First(&plan, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrPlanNotFound.New("Plan %d is not found", id).WithProperty(rest.HTTPCodeProperty(http.StatusNotFound))
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// valueEquals compares config values, in which sizes and durations are compared by their amounts,
+// e.g. `1GiB` equals to `1024MiB`, and `1m` equals to `60s`.
+func valueEquals(a, b interface{}) bool {
+	if fmt.Sprint(a) == fmt.Sprint(b) {
+		return true
+	}
+	if x, ok := parseByteSize(a); ok {
+		if y, ok := parseByteSize(b); ok {
+			return x == y
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			dx, errX := time.ParseDuration(x)
+			dy, errY := time.ParseDuration(y)
+			return errX == nil && errY == nil && dx == dy
+		}
+	}
+	return false
+}
+
+// selectAddresses returns the addresses of instances selected by the change. Nil is returned for kinds
+// that can only be edited cluster-wide, which means all sources of the kind.
+func (s *Service) selectAddresses(change PlanChange) ([]string, error) {
+	var instances []editInstance
+	var err error
+	switch change.Kind {
+	case ItemKindTiKVConfig:
+		instances, err = s.selectTiKVInstances(change.Target)
+	case ItemKindTiDBConfig:
+		instances, err = s.selectTiDBInstances(change.Target)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		addresses = append(addresses, instance.Address)
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// valuesOf returns the value of a config item in the given instances, or in all sources of the kind if addresses is nil.
+// Instances failed to be fetched are reported as missing.
+func valuesOf(items []channelItem, kind ItemKind, id string, addresses []string) []InstanceValue {
+	sources := make(map[string]map[string]interface{})
+	for _, item := range items {
+		if item.SourceKind == kind {
+			sources[item.SourceDisplayAddress] = item.Values
+		}
+	}
+	if addresses == nil {
+		addresses = make([]string, 0, len(sources))
+		for address := range sources {
+			addresses = append(addresses, address)
+		}
+		sort.Strings(addresses)
+	}
+	values := make([]InstanceValue, 0, len(addresses))
+	for _, address := range addresses {
+		value, ok := sources[address][id]
+		values = append(values, InstanceValue{Instance: address, Value: value, Missing: !ok})
+	}
+	return values
+}
+
+type PreviewInstance struct {
+	Instance     string      `json:"instance"`
+	CurrentValue interface{} `json:"current_value"`
+	Missing      bool        `json:"missing"`
+	Changed      bool        `json:"changed"` // Whether the value will be changed by the plan
+}
+
+type PlanPreviewItem struct {
+	PlanChange
+	Instances []PreviewInstance `json:"instances"`
+}
+
+type PlanPreview struct {
+	Errors []rest.ErrorResponse `json:"errors"`
+	Items  []PlanPreviewItem    `json:"items"`
+}
+
+func (s *Service) previewPlan(db *gorm.DB, plan *PlanModel) (*PlanPreview, error) {
+	items, errs, err := s.collectConfigItems(db)
+	if err != nil {
+		return nil, err
+	}
+	preview := &PlanPreview{Errors: errs, Items: make([]PlanPreviewItem, 0, len(plan.Changes))}
+	for _, change := range plan.Changes {
+		addresses, err := s.selectAddresses(change)
+		if err != nil {
+			return nil, err
+		}
+		previewItem := PlanPreviewItem{PlanChange: change, Instances: make([]PreviewInstance, 0)}
+		for _, v := range valuesOf(items, change.Kind, change.ID, addresses) {
+			previewItem.Instances = append(previewItem.Instances, PreviewInstance{
+				Instance:     v.Instance,
+				CurrentValue: v.Value,
+				Missing:      v.Missing,
+				Changed:      v.Missing || !valueEquals(v.Value, change.NewValue),
+			})
+		}
+		preview.Items = append(preview.Items, previewItem)
+	}
+	return preview, nil
+}
+
+// applyChange edits a config item and records the old value of each selected instance.
+func (s *Service) applyChange(db *gorm.DB, items []channelItem, change PlanChange) PlanChangeResult {
+	result := PlanChangeResult{Kind: change.Kind, ID: change.ID, Instances: make([]InstanceVerification, 0)}
+	addresses, err := s.selectAddresses(change)
+	if err != nil {
+		resp := rest.NewErrorResponse(err)
+		result.Error = &resp
+		return result
+	}
+	_, editResults, err := s.editConfig(db, change.Kind, change.ID, change.NewValue, change.Target)
+	if err != nil {
+		resp := rest.NewErrorResponse(err)
+		result.Error = &resp
+		if editResults == nil {
+			return result
+		}
+	}
+	editResultsByInstance := make(map[string]InstanceEditResult)
+	for _, r := range editResults {
+		editResultsByInstance[r.Instance] = r
+	}
+	for _, v := range valuesOf(items, change.Kind, change.ID, addresses) {
+		editResult := editResultsByInstance[v.Instance]
+		result.Instances = append(result.Instances, InstanceVerification{
+			Instance: v.Instance,
+			OldValue: v.Value,
+			Error:    editResult.Error,
+			Warning:  editResult.Warning,
+		})
+	}
+	return result
+}
+
+// verifyResult reads back the value of each successfully edited instance.
+func verifyResult(items []channelItem, change PlanChange, r *PlanChangeResult) {
+	addresses := make([]string, 0, len(r.Instances))
+	for _, instance := range r.Instances {
+		addresses = append(addresses, instance.Instance)
+	}
+	for j, v := range valuesOf(items, r.Kind, r.ID, addresses) {
+		instance := &r.Instances[j]
+		if instance.Error != nil {
+			continue
+		}
+		instance.Value = v.Value
+		instance.Verified = !v.Missing && valueEquals(v.Value, change.NewValue)
+	}
+}
+
+// succeeded returns whether the change is applied and verified on all selected instances.
+func (r *PlanChangeResult) succeeded() bool {
+	if r.Error != nil {
+		return false
+	}
+	for _, instance := range r.Instances {
+		if !instance.Verified {
+			return false
+		}
+	}
+	return true
+}
+
+// buildRollbackChanges reverts the applied changes in the reverse order. Instances of a config item are
+// grouped by their old values, so that each group can be reverted in one edit. Instances failed to be
+// edited are skipped, while the edited ones are reverted even if the change is not verified.
+func buildRollbackChanges(changes []PlanChange, results []PlanChangeResult) []PlanChange {
+	rollback := make([]PlanChange, 0)
+	for i := len(results) - 1; i >= 0; i-- {
+		r := results[i]
+		groups := make(map[string]*PlanChange)
+		groupOrder := make([]string, 0)
+		for _, instance := range r.Instances {
+			if instance.Error != nil || instance.OldValue == nil || valueEquals(instance.OldValue, changes[i].NewValue) {
+				continue
+			}
+			key := fmt.Sprintf("%T:%v", instance.OldValue, instance.OldValue)
+			group, ok := groups[key]
+			if !ok {
+				group = &PlanChange{Kind: r.Kind, ID: r.ID, NewValue: instance.OldValue}
+				groups[key] = group
+				groupOrder = append(groupOrder, key)
+			}
+			if instance.Instance != "" {
+				group.Target.Instances = append(group.Target.Instances, instance.Instance)
+			}
+		}
+		for _, key := range groupOrder {
+			rollback = append(rollback, *groups[key])
+		}
+	}
+	return rollback
+}
+
+// applyPlan applies the changes of a draft plan in order. Each change is verified by reading back the config
+// right after it is applied, and the plan fails and stops at the first change failed to be applied or verified.
+// A rollback plan is saved for the applied changes. Plans are applied one at a time, and the plan is reloaded
+// before applying, so that a plan is never applied twice.
+func (s *Service) applyPlan(db *gorm.DB, plan *PlanModel) error {
+	s.planMu.Lock()
+	defer s.planMu.Unlock()
+
+	current, err := s.loadPlan(plan.ID)
+	if err != nil {
+		return err
+	}
+	*plan = *current
+	if plan.Status != PlanStatusDraft {
+		return ErrPlanStatus.New("Plan %d is %s, only draft plans can be applied", plan.ID, plan.Status)
+	}
+	items, _, err := s.collectConfigItems(db)
+	if err != nil {
+		return err
+	}
+
+	results := make(PlanResults, 0, len(plan.Changes))
+	status := PlanStatusApplied
+	for _, change := range plan.Changes {
+		result := s.applyChange(db, items, change)
+		if result.Error == nil {
+			// The values read back are the old values of the next change as well.
+			items, _, err = s.collectConfigItems(db)
+			if err != nil {
+				resp := rest.NewErrorResponse(err)
+				result.Error = &resp
+			} else {
+				verifyResult(items, change, &result)
+			}
+		}
+		results = append(results, result)
+		if !result.succeeded() {
+			status = PlanStatusFailed
+			break
+		}
+	}
+
+	rollback := &PlanModel{
+		CreatedAt:   time.Now().Unix(),
+		Description: fmt.Sprintf("Rollback of plan %d", plan.ID),
+		Status:      PlanStatusDraft,
+		Changes:     buildRollbackChanges(plan.Changes, results),
+		Results:     PlanResults{},
+		RollbackOf:  plan.ID,
+	}
+	return s.params.LocalStore.Transaction(func(tx *gorm.DB) error {
+		if len(rollback.Changes) > 0 {
+			if err := tx.Create(rollback).Error; err != nil {
+				return err
+			}
+			plan.RollbackPlanID = rollback.ID
+		}
+		plan.Status = status
+		plan.AppliedAt = time.Now().Unix()
+		plan.Results = results
+		return tx.Save(plan).Error
+	})
+}
+
+// rollbackPlan applies the rollback plan of an applied plan.
+func (s *Service) rollbackPlan(db *gorm.DB, plan *PlanModel) (*PlanModel, error) {
+	if plan.Status != PlanStatusApplied && plan.Status != PlanStatusFailed {
+		return nil, ErrPlanStatus.New("Plan %d is %s, only applied plans can be rolled back", plan.ID, plan.Status)
+	}
+	if plan.RollbackPlanID == 0 {
+		return nil, ErrPlanStatus.New("Plan %d has nothing to roll back", plan.ID)
+	}
+	rollback, err := s.loadPlan(plan.RollbackPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPlan(db, rollback); err != nil {
+		return nil, err
+	}
+	if rollback.Status == PlanStatusApplied {
+		plan.Status = PlanStatusRolledBack
+		if err := s.params.LocalStore.Save(plan).Error; err != nil {
+			return nil, err
+		}
+	}
+	return rollback, nil
+}
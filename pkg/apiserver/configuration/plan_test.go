@@ -0,0 +1,118 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func TestValidatePlanChanges(t *testing.T) {
+	require.Error(t, validatePlanChanges(nil))
+	require.Error(t, validatePlanChanges([]PlanChange{{Kind: ItemKindTiKVConfig, ID: "server.addr"}}))
+	require.Error(t, validatePlanChanges([]PlanChange{{
+		Kind:   ItemKindPDConfig,
+		ID:     "log.level",
+		Target: EditTarget{Instances: []string{"pd-0:2379"}},
+	}}))
+	require.NoError(t, validatePlanChanges([]PlanChange{
+		{Kind: ItemKindTiKVConfig, ID: "gc.batch-keys", NewValue: 1024.0, Target: EditTarget{Labels: map[string]string{"zone": "az1"}}},
+		{Kind: ItemKindPDConfig, ID: "log.level", NewValue: "warn"},
+		{Kind: ItemKindTiDBVariable, ID: "tidb_retry_limit", NewValue: "20"},
+	}))
+}
+
+func TestPlanVerifyAndRollback(t *testing.T) {
+	changes := []PlanChange{
+		{Kind: ItemKindPDConfig, ID: "log.level", NewValue: "warn"},
+		{Kind: ItemKindTiKVConfig, ID: "gc.batch-keys", NewValue: 1024.0},
+	}
+	before := []channelItem{
+		{SourceKind: ItemKindPDConfig, Values: map[string]interface{}{"log.level": "info"}},
+		tikvItem("tikv-0:20160", map[string]interface{}{"gc.batch-keys": 512.0}),
+		tikvItem("tikv-1:20160", map[string]interface{}{"gc.batch-keys": 256.0}),
+		tikvItem("tikv-2:20160", map[string]interface{}{"gc.batch-keys": 512.0}),
+		tikvItem("tikv-3:20160", map[string]interface{}{"gc.batch-keys": 1024.0}),
+	}
+	require.Equal(t, []InstanceValue{{Instance: "", Value: "info"}}, valuesOf(before, ItemKindPDConfig, "log.level", nil))
+
+	editErr := rest.NewErrorResponse(ErrEditFailed.New("failed"))
+	results := []PlanChangeResult{{Kind: ItemKindPDConfig, ID: "log.level"}, {Kind: ItemKindTiKVConfig, ID: "gc.batch-keys"}}
+	for i, change := range changes {
+		for _, v := range valuesOf(before, change.Kind, change.ID, nil) {
+			results[i].Instances = append(results[i].Instances, InstanceVerification{Instance: v.Instance, OldValue: v.Value})
+		}
+	}
+	results[1].Instances[1].Error = &editErr
+
+	after := []channelItem{
+		{SourceKind: ItemKindPDConfig, Values: map[string]interface{}{"log.level": "warn"}},
+		tikvItem("tikv-0:20160", map[string]interface{}{"gc.batch-keys": 1024.0}),
+		tikvItem("tikv-1:20160", map[string]interface{}{"gc.batch-keys": 256.0}),
+		tikvItem("tikv-2:20160", map[string]interface{}{"gc.batch-keys": 512.0}),
+		tikvItem("tikv-3:20160", map[string]interface{}{"gc.batch-keys": 1024.0}),
+	}
+	for i := range results {
+		verifyResult(after, changes[i], &results[i])
+	}
+	require.True(t, results[0].Instances[0].Verified)
+	require.True(t, results[0].succeeded())
+	require.False(t, results[1].succeeded())
+	require.True(t, results[1].Instances[0].Verified)
+	require.False(t, results[1].Instances[1].Verified)
+	require.Nil(t, results[1].Instances[1].Value)
+	require.False(t, results[1].Instances[2].Verified)
+	require.Equal(t, 512.0, results[1].Instances[2].Value)
+
+	// Changes are reverted in the reverse order, skipping failed and unchanged instances.
+	require.Equal(t, []PlanChange{
+		{Kind: ItemKindTiKVConfig, ID: "gc.batch-keys", NewValue: 512.0, Target: EditTarget{Instances: []string{"tikv-0:20160", "tikv-2:20160"}}},
+		{Kind: ItemKindPDConfig, ID: "log.level", NewValue: "info"},
+	}, buildRollbackChanges(changes, results))
+}
+
+func TestValueEquals(t *testing.T) {
+	require.True(t, valueEquals("info", "info"))
+	require.True(t, valueEquals(1024.0, "1KiB"))
+	require.True(t, valueEquals("1GiB", "1024MiB"))
+	require.True(t, valueEquals("1GB", "1024MB"))
+	require.True(t, valueEquals("60s", "1m"))
+	require.False(t, valueEquals("1GiB", "1000MiB"))
+	require.False(t, valueEquals("10s", "20s"))
+	require.False(t, valueEquals("info", "warn"))
+	require.False(t, valueEquals(true, "1"))
+}
+
+func TestPlanModel(t *testing.T) {
+	store := newTestStore(t)
+	plan := PlanModel{
+		Status:  PlanStatusDraft,
+		Changes: PlanChanges{{Kind: ItemKindPDConfig, ID: "log.level", NewValue: "warn"}},
+		Results: PlanResults{},
+	}
+	require.NoError(t, store.Create(&plan).Error)
+
+	s := &Service{params: ServiceParams{LocalStore: store}}
+	loaded, err := s.loadPlan(plan.ID)
+	require.NoError(t, err)
+	require.Equal(t, plan.Changes, loaded.Changes)
+
+	_, err = s.loadPlan(plan.ID + 1)
+	require.Error(t, err)
+
+	err = s.applyPlan(nil, &PlanModel{ID: 10, Status: PlanStatusApplied})
+	require.Error(t, err)
+
+	// The status is reloaded, so that a plan applied meanwhile is not applied again.
+	require.NoError(t, store.Model(&plan).Update("status", PlanStatusApplied).Error)
+	stale := plan
+	err = s.applyPlan(nil, &stale)
+	require.True(t, errorx.IsOfType(err, ErrPlanStatus))
+	require.Equal(t, PlanStatusApplied, stale.Status)
+	_, err = s.rollbackPlan(nil, &PlanModel{ID: 10, Status: PlanStatusApplied})
+	require.Error(t, err)
+}
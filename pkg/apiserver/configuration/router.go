@@ -4,6 +4,8 @@ package configuration
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -19,10 +21,23 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 	endpoint.Use(utils.MWForbidByExperimentalFlag(s.params.Config.EnableExperimental))
 	endpoint.GET("/all", s.getHandler)
 	endpoint.POST("/edit", auth.MWRequireWritePriv(), s.editHandler)
+	endpoint.GET("/snapshots", s.listSnapshotsHandler)
+	endpoint.POST("/snapshots", auth.MWRequireWritePriv(), s.takeSnapshotHandler)
+	endpoint.GET("/snapshots/diff", s.diffSnapshotsHandler)
+	endpoint.GET("/drift", s.driftHandler)
+	endpoint.GET("/lint", s.lintHandler)
+	endpoint.GET("/plans", s.listPlansHandler)
+	endpoint.POST("/plans", auth.MWRequireWritePriv(), s.createPlanHandler)
+	endpoint.GET("/plans/:id", s.getPlanHandler)
+	endpoint.DELETE("/plans/:id", auth.MWRequireWritePriv(), s.deletePlanHandler)
+	endpoint.GET("/plans/:id/preview", s.previewPlanHandler)
+	endpoint.POST("/plans/:id/apply", auth.MWRequireWritePriv(), s.applyPlanHandler)
+	endpoint.POST("/plans/:id/rollback", auth.MWRequireWritePriv(), s.rollbackPlanHandler)
 }
 
 // @ID configurationGetAll
 // @Summary Get all configurations
+// @Description For TiFlash, only the config of the TiFlash proxy is listed, since the config of the TiFlash engine is not served by any status API.
 // @Success 200 {object} AllConfigItems
 // @Router /configuration/all [get]
 // @Security JwtAuth
@@ -43,14 +58,20 @@ type EditRequest struct {
 	Kind     ItemKind    `json:"kind"`
 	ID       string      `json:"id"`
 	NewValue interface{} `json:"new_value"`
+	// Only TiKV and TiDB config can be edited for a subset of instances
+	Target EditTarget `json:"target"`
 }
 
 type EditResponse struct {
 	Warnings []rest.ErrorResponse `json:"warnings"`
+	Results  []InstanceEditResult `json:"results"`
 }
 
 // @ID configurationEdit
 // @Summary Edit a configuration
+// @Description Edit a configuration of all instances, or of the TiKV or TiDB instances selected by addresses or labels.
+// @Description If the edit fails on all instances, 500 is responded with an EditResponse containing the failure of each instance.
+// @Description Edits of TiDB config are applied to the running instances only, and are lost after the instances restart, which is reported as the warning of each instance.
 // @Param request body EditRequest true "Request body"
 // @Success 200 {object} EditResponse
 // @Router /configuration/edit [post]
@@ -67,14 +88,383 @@ func (s *Service) editHandler(c *gin.Context) {
 	}
 
 	db := utils.GetTiDBConnection(c)
-	warnings, err := s.editConfig(db, req.Kind, req.ID, req.NewValue)
-	if err != nil {
+	warnings, results, err := s.editConfig(db, req.Kind, req.ID, req.NewValue, req.Target)
+	if err != nil && results == nil {
 		rest.Error(c, err)
 		return
 	}
 
 	var resp EditResponse
 	resp.Warnings = warnings
+	resp.Results = results
+
+	status := http.StatusOK
+	if err != nil {
+		// All instances failed, the failure of each instance is in the results.
+		status = http.StatusInternalServerError
+	}
+	c.JSON(status, resp)
+}
+
+// @ID configurationListSnapshots
+// @Summary List configuration snapshots
+// @Description Snapshots are taken every hour, and kept for 30 days
+// @Success 200 {array} SnapshotModel
+// @Router /configuration/snapshots [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) listSnapshotsHandler(c *gin.Context) {
+	var snapshots []SnapshotModel
+	if err := s.params.LocalStore.Order("id DESC").Find(&snapshots).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, snapshots)
+}
+
+// @ID configurationTakeSnapshot
+// @Summary Take a configuration snapshot
+// @Description Take a snapshot of the configuration of all instances, including global variables. For TiFlash, only the config of the TiFlash proxy is captured.
+// @Success 200 {object} SnapshotModel
+// @Router /configuration/snapshots [post]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) takeSnapshotHandler(c *gin.Context) {
+	db := utils.GetTiDBConnection(c)
+	snapshot, err := s.takeSnapshot(db, true)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+func parseSnapshotID(c *gin.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Query(name), 10, 64)
+	if err != nil {
+		return 0, rest.ErrBadRequest.New("Invalid %s %s", name, c.Query(name))
+	}
+	return uint(id), nil
+}
+
+// latestSnapshotID returns the snapshot ID in the query, or the latest one if it is not specified.
+func (s *Service) latestSnapshotID(c *gin.Context, name string) (uint, error) {
+	if c.Query(name) != "" {
+		return parseSnapshotID(c, name)
+	}
+	var snapshot SnapshotModel
+	if err := s.params.LocalStore.Order("id DESC").First(&snapshot).Error; err != nil {
+		return 0, ErrSnapshotNotFound.New("No snapshot has been taken").WithProperty(rest.HTTPCodeProperty(http.StatusNotFound))
+	}
+	return snapshot.ID, nil
+}
+
+type SnapshotDiffResponse struct {
+	From    SnapshotModel  `json:"from"`
+	To      SnapshotModel  `json:"to"`
+	Changes []ConfigChange `json:"changes"`
+	// Kinds not compared since they are not captured in either snapshot, e.g. global variables in periodic snapshots
+	NotCaptured []ItemKind `json:"not_captured"`
+}
+
+// @ID configurationDiffSnapshots
+// @Summary Compare two configuration snapshots
+// @Description Global variables are only captured in manual snapshots. They are listed in not_captured instead of compared if either snapshot is periodic.
+// @Param from query int true "snapshot ID"
+// @Param to query int false "snapshot ID, the latest one by default"
+// @Param kind query string false "item kind" Enums(tikv_config, pd_config, tidb_config, tidb_variable, tiflash_proxy_config, ticdc_config, tiproxy_config)
+// @Success 200 {object} SnapshotDiffResponse
+// @Router /configuration/snapshots/diff [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) diffSnapshotsHandler(c *gin.Context) {
+	fromID, err := parseSnapshotID(c, "from")
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	toID, err := s.latestSnapshotID(c, "to")
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, resp)
+	cache := newBlobCache(s.params.LocalStore)
+	from, fromConfigs, err := cache.loadSnapshot(fromID)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	to, toConfigs, err := cache.loadSnapshot(toID)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	changes, notCaptured := diffSnapshots(fromConfigs, toConfigs, ItemKind(c.Query("kind")))
+	c.JSON(http.StatusOK, SnapshotDiffResponse{
+		From:        *from,
+		To:          *to,
+		Changes:     changes,
+		NotCaptured: notCaptured,
+	})
+}
+
+type DriftResponse struct {
+	Snapshot SnapshotModel `json:"snapshot"`
+	Items    []DriftItem   `json:"items"`
+}
+
+// @ID configurationGetDrift
+// @Summary Get configuration drift
+// @Description Find instances whose configuration deviates from the majority of instances of the same kind, and since when
+// @Param snapshot_id query int false "snapshot ID, the latest one by default"
+// @Param kind query string false "item kind" Enums(tikv_config, tidb_config, tiflash_proxy_config, ticdc_config, tiproxy_config)
+// @Success 200 {object} DriftResponse
+// @Router /configuration/drift [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) driftHandler(c *gin.Context) {
+	id, err := s.latestSnapshotID(c, "snapshot_id")
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	cache := newBlobCache(s.params.LocalStore)
+	snapshot, configs, err := cache.loadSnapshot(id)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	items := computeDrift(configs, ItemKind(c.Query("kind")))
+	if err := cache.fillDriftSince(snapshot, items); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, DriftResponse{Snapshot: *snapshot, Items: items})
+}
+
+// @ID configurationLint
+// @Summary Check configurations against best practices
+// @Description Run the lint rules over the configuration of all instances and the hardware of hosts
+// @Success 200 {object} LintResult
+// @Router /configuration/lint [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) lintHandler(c *gin.Context) {
+	db := utils.GetTiDBConnection(c)
+	r, err := s.lint(db)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// @ID configurationListPlans
+// @Summary List configuration change plans
+// @Success 200 {array} PlanModel
+// @Router /configuration/plans [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) listPlansHandler(c *gin.Context) {
+	var plans []PlanModel
+	if err := s.params.LocalStore.Order("id DESC").Find(&plans).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+type CreatePlanRequest struct {
+	Description string       `json:"description"`
+	Changes     []PlanChange `json:"changes"`
+}
+
+// @ID configurationCreatePlan
+// @Summary Create a configuration change plan
+// @Description Stage several configuration edits, which can be previewed and then applied in order
+// @Param request body CreatePlanRequest true "Request body"
+// @Success 200 {object} PlanModel
+// @Router /configuration/plans [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) createPlanHandler(c *gin.Context) {
+	var req CreatePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if err := validatePlanChanges(req.Changes); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	plan := PlanModel{
+		CreatedAt:   time.Now().Unix(),
+		Description: req.Description,
+		Status:      PlanStatusDraft,
+		Changes:     req.Changes,
+		Results:     PlanResults{},
+	}
+	if err := s.params.LocalStore.Create(&plan).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+func (s *Service) loadPlanByParam(c *gin.Context) (*PlanModel, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, rest.ErrBadRequest.New("Invalid plan id %s", c.Param("id"))
+	}
+	return s.loadPlan(uint(id))
+}
+
+// @ID configurationGetPlan
+// @Summary Get a configuration change plan
+// @Param id path int true "plan ID"
+// @Success 200 {object} PlanModel
+// @Router /configuration/plans/{id} [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) getPlanHandler(c *gin.Context) {
+	plan, err := s.loadPlanByParam(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// @ID configurationDeletePlan
+// @Summary Delete a draft configuration change plan
+// @Description Rollback plans generated by applying plans cannot be deleted
+// @Param id path int true "plan ID"
+// @Success 200 {object} rest.EmptyResponse
+// @Router /configuration/plans/{id} [delete]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) deletePlanHandler(c *gin.Context) {
+	plan, err := s.loadPlanByParam(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if plan.Status != PlanStatusDraft {
+		rest.Error(c, ErrPlanStatus.New("Plan %d is %s, only draft plans can be deleted", plan.ID, plan.Status))
+		return
+	}
+	if plan.RollbackOf != 0 {
+		rest.Error(c, ErrPlanStatus.New("Plan %d is the rollback plan of plan %d, which cannot be deleted", plan.ID, plan.RollbackOf))
+		return
+	}
+	if err := s.params.LocalStore.Delete(plan).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rest.EmptyResponse{})
+}
+
+// @ID configurationPreviewPlan
+// @Summary Preview a configuration change plan
+// @Description Compare the changes of the plan with the current value of each selected instance
+// @Param id path int true "plan ID"
+// @Success 200 {object} PlanPreview
+// @Router /configuration/plans/{id}/preview [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) previewPlanHandler(c *gin.Context) {
+	plan, err := s.loadPlanByParam(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	preview, err := s.previewPlan(utils.GetTiDBConnection(c), plan)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// @ID configurationApplyPlan
+// @Summary Apply a configuration change plan
+// @Description Apply the changes in order and verify them on each instance. A rollback plan is generated for the applied changes.
+// @Description Like the edit API, changes of TiDB config are not persisted, which is reported as the warning of each instance.
+// @Param id path int true "plan ID"
+// @Success 200 {object} PlanModel
+// @Router /configuration/plans/{id}/apply [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) applyPlanHandler(c *gin.Context) {
+	plan, err := s.loadPlanByParam(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if err := s.applyPlan(utils.GetTiDBConnection(c), plan); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// @ID configurationRollbackPlan
+// @Summary Roll back an applied configuration change plan
+// @Param id path int true "plan ID"
+// @Success 200 {object} PlanModel "the applied rollback plan"
+// @Router /configuration/plans/{id}/rollback [post]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 403 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) rollbackPlanHandler(c *gin.Context) {
+	plan, err := s.loadPlanByParam(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	rollback, err := s.rollbackPlan(utils.GetTiDBConnection(c), plan)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rollback)
 }
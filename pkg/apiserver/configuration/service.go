@@ -10,16 +10,24 @@ import (
 	"net"
 	"sort"
 	"strconv"
+	"sync"
 
 	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/clusterinfo/hostinfo"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/ticdc"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
+	"github.com/pingcap/tidb-dashboard/pkg/tiflash"
 	"github.com/pingcap/tidb-dashboard/pkg/tikv"
+	"github.com/pingcap/tidb-dashboard/pkg/tiproxy"
 	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 	"github.com/pingcap/tidb-dashboard/util/distro"
 	"github.com/pingcap/tidb-dashboard/util/rest"
@@ -31,6 +39,9 @@ var (
 	ErrListConfigItemsFailed = ErrNS.NewType("list_config_items_failed")
 	ErrNotEditable           = ErrNS.NewType("not_editable")
 	ErrEditFailed            = ErrNS.NewType("edit_failed")
+	ErrSnapshotNotFound      = ErrNS.NewType("snapshot_not_found")
+	ErrPlanNotFound          = ErrNS.NewType("plan_not_found")
+	ErrPlanStatus            = ErrNS.NewType("invalid_plan_status")
 )
 
 type ServiceParams struct {
@@ -40,18 +51,39 @@ type ServiceParams struct {
 	EtcdClient *clientv3.Client
 	TiDBClient *tidb.Client
 	TiKVClient *tikv.Client
+	LocalStore *dbstore.DB
+
+	TiFlashClient *tiflash.Client
+	TiCDCClient   *ticdc.Client
+	TiProxyClient *tiproxy.Client
 }
 
 type Service struct {
 	params       ServiceParams
 	lifecycleCtx context.Context
+	wg           sync.WaitGroup
+	planMu       sync.Mutex // Serializes applying plans
 }
 
 func NewService(lc fx.Lifecycle, p ServiceParams) *Service {
+	if err := autoMigrate(p.LocalStore); err != nil {
+		log.Fatal("Failed to initialize database", zap.Error(err))
+	}
+
 	service := &Service{params: p}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			service.lifecycleCtx = ctx
+			// Configurations are only available in the experimental mode.
+			if p.Config.EnableExperimental {
+				service.wg.Go(func() {
+					service.snapshotLoop(ctx)
+				})
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			service.wg.Wait()
 			return nil
 		},
 	})
@@ -66,6 +98,12 @@ const (
 	ItemKindPDConfig     ItemKind = "pd_config"
 	ItemKindTiDBConfig   ItemKind = "tidb_config"
 	ItemKindTiDBVariable ItemKind = "tidb_variable"
+
+	// TiFlash stores only serve the config of the TiFlash proxy, the TiKV-based raft layer of TiFlash,
+	// while the config of the TiFlash engine itself is not exposed via any status port.
+	ItemKindTiFlashProxyConfig ItemKind = "tiflash_proxy_config"
+	ItemKindTiCDCConfig        ItemKind = "ticdc_config"
+	ItemKindTiProxyConfig      ItemKind = "tiproxy_config"
 )
 
 type channelItem struct {
@@ -185,10 +223,17 @@ func (s *Service) getGlobalVariablesFromTiDB(db *gorm.DB) (map[string]interface{
 }
 
 type Item struct {
-	ID           string      `json:"id"`
-	IsEditable   bool        `json:"is_editable"`
-	IsMultiValue bool        `json:"is_multi_value"` // TODO: Support per-instance config
-	Value        interface{} `json:"value"`          // When multi value present, this contains one of the value
+	ID           string          `json:"id"`
+	IsEditable   bool            `json:"is_editable"`
+	IsMultiValue bool            `json:"is_multi_value"`
+	Value        interface{}     `json:"value"`            // When multi value present, this contains one of the value
+	Values       []InstanceValue `json:"values,omitempty"` // Value of each instance, only present for multi value items
+}
+
+type InstanceValue struct {
+	Instance string      `json:"instance"`
+	Value    interface{} `json:"value"`
+	Missing  bool        `json:"missing"` // The config item is not present in this instance
 }
 
 type AllConfigItems struct {
@@ -196,15 +241,28 @@ type AllConfigItems struct {
 	Items  map[ItemKind][]Item  `json:"items"`
 }
 
-func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
-	tikvInfo, _, err := topology.FetchStoreTopology(s.params.PDClient)
+// collectConfigItems fetches the flattened config of all instances. Global variables are skipped if db is nil.
+func (s *Service) collectConfigItems(db *gorm.DB) ([]channelItem, []rest.ErrorResponse, error) {
+	tikvInfo, tiflashInfo, err := topology.FetchStoreTopology(s.params.PDClient)
 	if err != nil {
-		return nil, ErrListTopologyFailed.Wrap(err, "Failed to list TiKV stores")
+		return nil, nil, ErrListTopologyFailed.Wrap(err, "Failed to list TiKV stores")
 	}
 
 	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, s.params.EtcdClient)
 	if err != nil {
-		return nil, ErrListTopologyFailed.Wrap(err, "Failed to list %s instances", distro.R().TiDB)
+		return nil, nil, ErrListTopologyFailed.Wrap(err, "Failed to list %s instances", distro.R().TiDB)
+	}
+
+	errors := make([]rest.ErrorResponse, 0)
+
+	// TiCDC and TiProxy are optional components, so that failing to list them does not fail the whole request.
+	ticdcInfo, err := topology.FetchTiCDCTopology(s.lifecycleCtx, s.params.EtcdClient)
+	if err != nil {
+		errors = append(errors, rest.NewErrorResponse(ErrListTopologyFailed.Wrap(err, "Failed to list %s instances", distro.R().TiCDC)))
+	}
+	tiproxyInfo, err := topology.FetchTiProxyTopology(s.lifecycleCtx, s.params.EtcdClient)
+	if err != nil {
+		errors = append(errors, rest.NewErrorResponse(ErrListTopologyFailed.Wrap(err, "Failed to list %s instances", distro.R().TiProxy)))
 	}
 
 	ch := make(chan channelItem)
@@ -214,7 +272,7 @@ func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
 		waitItems++
 		go s.getConfigItemsFromPDToChannel(ch)
 	}
-	{
+	if db != nil {
 		waitItems++
 		go s.getGlobalVariablesFromTiDBToChannel(db, ch)
 	}
@@ -229,8 +287,25 @@ func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
 		item2 := item
 		go s.getConfigItemsFromTiDBToChannel(&item2, ch)
 	}
+	for _, item := range tiflashInfo {
+		if item.Status == topology.ComponentStatusTombstone {
+			continue
+		}
+		waitItems++
+		item2 := item
+		go s.getConfigItemsFromTiFlashProxyToChannel(&item2, ch)
+	}
+	for _, item := range ticdcInfo {
+		waitItems++
+		item2 := item
+		go s.getConfigItemsFromTiCDCToChannel(&item2, ch)
+	}
+	for _, item := range tiproxyInfo {
+		waitItems++
+		item2 := item
+		go s.getConfigItemsFromTiProxyToChannel(&item2, ch)
+	}
 
-	errors := make([]rest.ErrorResponse, 0)
 	successItems := make([]channelItem, 0)
 
 	for i := 0; i < waitItems; i++ {
@@ -242,22 +317,35 @@ func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
 		successItems = append(successItems, item)
 	}
 	close(ch)
+	return successItems, errors, nil
+}
+
+func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
+	successItems, errors, err := s.collectConfigItems(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AllConfigItems{
+		Errors: errors,
+		Items:  mergeConfigItems(successItems),
+	}, nil
+}
 
+// mergeConfigItems merges config items of the same kind from different instances. Items whose value differs
+// between instances, or is missing in some instances, are returned as multi value items with per-instance values.
+func mergeConfigItems(successItems []channelItem) map[ItemKind][]Item {
+	// Sources of each kind, sorted by the instance address
+	sourcesMap := make(map[ItemKind][]channelItem)
 	// The first occurred value of each config item
 	valuesMap := make(map[ItemKind]map[string]interface{})
 	// Number of config item key occurred to detect missing config items
 	occurTimesMap := make(map[ItemKind]map[string]int)
 	// Whether each config item has different values
 	identicalMap := make(map[ItemKind]map[string]bool)
-	// The expected number of occur times
-	expectedOccurTimes := make(map[ItemKind]int)
 
 	for _, item := range successItems {
-		if _, ok := expectedOccurTimes[item.SourceKind]; !ok {
-			expectedOccurTimes[item.SourceKind] = 1
-		} else {
-			expectedOccurTimes[item.SourceKind]++
-		}
+		sourcesMap[item.SourceKind] = append(sourcesMap[item.SourceKind], item)
 		if _, ok := valuesMap[item.SourceKind]; !ok {
 			valuesMap[item.SourceKind] = make(map[string]interface{})
 			occurTimesMap[item.SourceKind] = make(map[string]int)
@@ -279,23 +367,36 @@ func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
 
 	result := make(map[ItemKind][]Item)
 	for kind, v := range valuesMap {
+		sources := sourcesMap[kind]
+		sort.Slice(sources, func(i, j int) bool {
+			return sources[i].SourceDisplayAddress < sources[j].SourceDisplayAddress
+		})
+
 		result[kind] = make([]Item, 0)
 		for configKey, configValue := range v {
 			// There are two cases when a config item has multiple values:
 			// 1. Values are not equal
 			// 2. Value is missing
-			isMultiValue := !identicalMap[kind][configKey]
-			value := configValue
-			if !isMultiValue && occurTimesMap[kind][configKey] < expectedOccurTimes[kind] {
-				isMultiValue = false
-			}
+			isMultiValue := !identicalMap[kind][configKey] || occurTimesMap[kind][configKey] < len(sources)
 
-			result[kind] = append(result[kind], Item{
+			item := Item{
 				ID:           configKey,
 				IsEditable:   isConfigItemEditable(kind, configKey),
 				IsMultiValue: isMultiValue,
-				Value:        value,
-			})
+				Value:        configValue,
+			}
+			if isMultiValue {
+				item.Values = make([]InstanceValue, 0, len(sources))
+				for _, source := range sources {
+					value, ok := source.Values[configKey]
+					item.Values = append(item.Values, InstanceValue{
+						Instance: source.SourceDisplayAddress,
+						Value:    value,
+						Missing:  !ok,
+					})
+				}
+			}
+			result[kind] = append(result[kind], item)
 		}
 
 		s := result[kind]
@@ -303,62 +404,99 @@ func (s *Service) getAllConfigItems(db *gorm.DB) (*AllConfigItems, error) {
 			return s[i].ID < s[j].ID
 		})
 	}
-
-	return &AllConfigItems{
-		Errors: errors,
-		Items:  result,
-	}, nil
+	return result
 }
 
-func (s *Service) editConfig(db *gorm.DB, kind ItemKind, id string, newValue interface{}) ([]rest.ErrorResponse, error) {
+func (s *Service) editConfig(db *gorm.DB, kind ItemKind, id string, newValue interface{}, target EditTarget) ([]rest.ErrorResponse, []InstanceEditResult, error) {
 	if !isConfigItemEditable(kind, id) {
-		return nil, ErrNotEditable.New("Configuration `%s` is not editable", id)
+		return nil, nil, ErrNotEditable.New("Configuration `%s` is not editable", id)
+	}
+	if !target.IsEmpty() && kind != ItemKindTiKVConfig && kind != ItemKindTiDBConfig {
+		return nil, nil, ErrEditFailed.New("Configuration of kind `%s` can only be edited cluster-wide", kind)
 	}
 	body := make(map[string]interface{})
 	body[id] = newValue
 	bodyJSON, err := json.Marshal(&body)
 	if err != nil {
-		return nil, ErrEditFailed.WrapWithNoMessage(err)
+		return nil, nil, ErrEditFailed.WrapWithNoMessage(err)
 	}
 
 	switch kind {
 	case ItemKindPDConfig:
 		_, err := s.params.PDClient.SendPostRequest("/config", bytes.NewBuffer(bodyJSON))
 		if err != nil {
-			return nil, ErrEditFailed.WrapWithNoMessage(err)
+			return nil, nil, ErrEditFailed.WrapWithNoMessage(err)
 		}
 	case ItemKindTiKVConfig:
-		tikvInfo, _, err := topology.FetchStoreTopology(s.params.PDClient)
+		instances, err := s.selectTiKVInstances(target)
 		if err != nil {
-			return nil, ErrEditFailed.WrapWithNoMessage(ErrListTopologyFailed.WrapWithNoMessage(err))
+			return nil, nil, err
 		}
-		failures := make([]error, 0)
-		for _, kvStore := range tikvInfo {
+		return editInstances(instances, func(instance editInstance) error {
 			// TODO: What about tombstone stores?
-			_, err := s.params.TiKVClient.SendPostRequest(kvStore.IP, int(kvStore.StatusPort), "/config", bytes.NewBuffer(bodyJSON))
+			_, err := s.params.TiKVClient.SendPostRequest(instance.IP, instance.StatusPort, "/config", bytes.NewBuffer(bodyJSON))
 			if err != nil {
-				failures = append(failures, ErrEditFailed.Wrap(err, "Failed to edit config for TiKV instance `%s`", net.JoinHostPort(kvStore.IP, strconv.Itoa(int(kvStore.Port)))))
-			}
-		}
-		if len(failures) == len(tikvInfo) {
-			if len(failures) > 0 {
-				return nil, failures[0]
+				return ErrEditFailed.Wrap(err, "Failed to edit config for TiKV instance `%s`", instance.Address)
 			}
-			return nil, nil
+			return nil
+		})
+	case ItemKindTiDBConfig:
+		query, err := tidbSettingsQuery(id, newValue)
+		if err != nil {
+			return nil, nil, err
 		}
-		warnings := make([]rest.ErrorResponse, 0)
-		for _, err := range failures {
-			warnings = append(warnings, rest.NewErrorResponse(err))
+		instances, err := s.selectTiDBInstances(target)
+		if err != nil {
+			return nil, nil, err
 		}
-		return warnings, nil
+		warnings, results, err := editInstances(instances, func(instance editInstance) error {
+			_, err := s.params.TiDBClient.
+				WithStatusAPIAddress(instance.IP, instance.StatusPort).
+				SendPostRequest("/settings?"+query.Encode(), nil)
+			if err != nil {
+				return ErrEditFailed.Wrap(err, "Failed to edit config for %s instance `%s`", distro.R().TiDB, instance.Address)
+			}
+			return nil
+		})
+		warnNotPersisted(results, distro.R().TiDB)
+		return warnings, results, err
+	case ItemKindTiCDCConfig:
+		return s.editTiCDCConfig(id, newValue)
+	case ItemKindTiProxyConfig:
+		return s.editTiProxyConfig(id, newValue)
 	case ItemKindTiDBVariable:
 		// We have checked the correctness of id, so no need to worry about injections
 		if err := db.Exec(fmt.Sprintf("SET GLOBAL %s = ?", id), newValue).Error; err != nil {
-			return nil, ErrEditFailed.WrapWithNoMessage(err)
+			return nil, nil, ErrEditFailed.WrapWithNoMessage(err)
 		}
 	default:
-		return nil, ErrEditFailed.New("Edit failed, not implemented")
+		return nil, nil, ErrEditFailed.New("Edit failed, not implemented")
 	}
 
-	return nil, nil
+	return nil, nil, nil
+}
+
+type LintResult struct {
+	Errors   []rest.ErrorResponse `json:"errors"`
+	Findings []LintFinding        `json:"findings"`
+}
+
+// lint checks the config of all instances against the best practices, using the hardware info of hosts.
+func (s *Service) lint(db *gorm.DB) (*LintResult, error) {
+	items, errs, err := s.collectConfigItems(db)
+	if err != nil {
+		return nil, err
+	}
+	// Host info may be partially available, in which case rules depending on the missing info are skipped.
+	hosts, err := hostinfo.FetchAllHostsInfo(s.lifecycleCtx, s.params.PDClient, s.params.EtcdClient, db)
+	if err != nil {
+		if hosts == nil {
+			return nil, err
+		}
+		errs = append(errs, rest.NewErrorResponse(err))
+	}
+	return &LintResult{
+		Errors:   errs,
+		Findings: lintConfig(items, hosts),
+	}, nil
 }
@@ -0,0 +1,450 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	snapshotInitialDelay = time.Minute
+	snapshotInterval     = time.Hour
+	snapshotRetention    = 30 * 24 * time.Hour
+)
+
+type ErrorMessages []string
+
+func (r *ErrorMessages) Scan(src interface{}) error {
+	return json.Unmarshal([]byte(src.(string)), r)
+}
+
+func (r ErrorMessages) Value() (driver.Value, error) {
+	val, err := json.Marshal(r)
+	return string(val), err
+}
+
+// SnapshotModel is the configuration of all instances at a time.
+type SnapshotModel struct {
+	ID           uint          `json:"id" gorm:"primary_key"`
+	TakenAt      int64         `json:"taken_at" gorm:"index"`
+	Manual       bool          `json:"manual"`
+	NumInstances int           `json:"num_instances"`
+	Errors       ErrorMessages `json:"errors" gorm:"type:text"` // Instances failed to be fetched are missing in the snapshot
+	// Global variables need a SQL session, so that they are only captured in manual snapshots.
+	VariablesCaptured bool `json:"variables_captured"`
+}
+
+func (SnapshotModel) TableName() string {
+	return "configuration_snapshots"
+}
+
+// SnapshotInstanceModel refers to the flattened config of an instance in a snapshot.
+type SnapshotInstanceModel struct {
+	ID         uint     `gorm:"primary_key"`
+	SnapshotID uint     `gorm:"index"`
+	Kind       ItemKind `gorm:"size:16;index:idx_configuration_snapshot_instance"`
+	Instance   string   `gorm:"size:64;index:idx_configuration_snapshot_instance"` // Empty for PD config and global variables
+	Hash       string   `gorm:"size:64"`
+}
+
+func (SnapshotInstanceModel) TableName() string {
+	return "configuration_snapshot_instances"
+}
+
+// ConfigBlobModel is a flattened config in JSON. Configs rarely change, so that they are shared by snapshots.
+type ConfigBlobModel struct {
+	Hash string `gorm:"primary_key;size:64"`
+	Data []byte
+}
+
+func (ConfigBlobModel) TableName() string {
+	return "configuration_blobs"
+}
+
+func autoMigrate(db *dbstore.DB) error {
+	return db.AutoMigrate(&SnapshotModel{}, &SnapshotInstanceModel{}, &ConfigBlobModel{}, &PlanModel{})
+}
+
+// saveSnapshot saves the collected config items as a snapshot.
+func saveSnapshot(store *dbstore.DB, items []channelItem, errs []rest.ErrorResponse, manual bool, takenAt time.Time) (*SnapshotModel, error) {
+	snapshot := &SnapshotModel{
+		TakenAt:      takenAt.Unix(),
+		Manual:       manual,
+		NumInstances: len(items),
+		Errors:       ErrorMessages{},
+	}
+	for _, e := range errs {
+		snapshot.Errors = append(snapshot.Errors, e.Message)
+	}
+	for _, item := range items {
+		if item.SourceKind == ItemKindTiDBVariable {
+			snapshot.VariablesCaptured = true
+		}
+	}
+	err := store.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(snapshot).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			// Keys of maps are sorted when marshalling, so that identical configs have the same hash.
+			data, err := json.Marshal(item.Values)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(data)
+			hash := hex.EncodeToString(sum[:])
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ConfigBlobModel{Hash: hash, Data: data}).Error; err != nil {
+				return err
+			}
+			instance := SnapshotInstanceModel{
+				SnapshotID: snapshot.ID,
+				Kind:       item.SourceKind,
+				Instance:   item.SourceDisplayAddress,
+				Hash:       hash,
+			}
+			if err := tx.Create(&instance).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// takeSnapshot fetches and saves the configuration of all instances. Global variables are only included
+// when db is not nil, since the dashboard does not keep SQL credentials for periodic snapshots.
+func (s *Service) takeSnapshot(db *gorm.DB, manual bool) (*SnapshotModel, error) {
+	items, errs, err := s.collectConfigItems(db)
+	if err != nil {
+		return nil, err
+	}
+	return saveSnapshot(s.params.LocalStore, items, errs, manual, time.Now())
+}
+
+func (s *Service) snapshotLoop(ctx context.Context) {
+	timer := time.NewTimer(snapshotInitialDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		// The dashboard does not keep SQL credentials, so that periodic snapshots do not capture global variables,
+		// which is reported in diffs.
+		if _, err := s.takeSnapshot(nil, false); err != nil {
+			log.Warn("Failed to take configuration snapshot", zap.Error(err))
+		}
+		if err := removeExpiredSnapshots(s.params.LocalStore, time.Now().Add(-snapshotRetention)); err != nil {
+			log.Warn("Failed to remove expired configuration snapshots", zap.Error(err))
+		}
+		timer.Reset(snapshotInterval)
+	}
+}
+
+// removeExpiredSnapshots removes snapshots taken before the time, and configs no longer referred by any snapshot.
+func removeExpiredSnapshots(store *dbstore.DB, before time.Time) error {
+	return store.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&SnapshotModel{}).Select("id").Where("taken_at < ?", before.Unix())
+		if err := tx.Where("snapshot_id IN (?)", expired).Delete(&SnapshotInstanceModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("taken_at < ?", before.Unix()).Delete(&SnapshotModel{}).Error; err != nil {
+			return err
+		}
+		referred := tx.Model(&SnapshotInstanceModel{}).Distinct("hash")
+		return tx.Where("hash NOT IN (?)", referred).Delete(&ConfigBlobModel{}).Error
+	})
+}
+
+type instanceKey struct {
+	Kind     ItemKind
+	Instance string
+}
+
+type snapshotConfigs map[instanceKey]map[string]interface{}
+
+// blobCache decodes each config blob only once.
+type blobCache struct {
+	store *dbstore.DB
+	blobs map[string]map[string]interface{}
+}
+
+func newBlobCache(store *dbstore.DB) *blobCache {
+	return &blobCache{store: store, blobs: make(map[string]map[string]interface{})}
+}
+
+func (c *blobCache) get(hash string) (map[string]interface{}, error) {
+	if values, ok := c.blobs[hash]; ok {
+		return values, nil
+	}
+	var blob ConfigBlobModel
+	if err := c.store.Where("hash = ?", hash).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(blob.Data, &values); err != nil {
+		return nil, err
+	}
+	c.blobs[hash] = values
+	return values, nil
+}
+
+func (c *blobCache) loadSnapshot(id uint) (*SnapshotModel, snapshotConfigs, error) {
+	var snapshot SnapshotModel
+	if err := c.store.Where("id = ?", id).First(&snapshot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, ErrSnapshotNotFound.New("Snapshot %d does not exist", id).WithProperty(rest.HTTPCodeProperty(http.StatusNotFound))
+		}
+		return nil, nil, err
+	}
+	var instances []SnapshotInstanceModel
+	if err := c.store.Where("snapshot_id = ?", id).Find(&instances).Error; err != nil {
+		return nil, nil, err
+	}
+	configs := make(snapshotConfigs, len(instances))
+	for _, instance := range instances {
+		values, err := c.get(instance.Hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		configs[instanceKey{Kind: instance.Kind, Instance: instance.Instance}] = values
+	}
+	return &snapshot, configs, nil
+}
+
+type ChangeType string
+
+const (
+	ChangeTypeAdded           ChangeType = "added"
+	ChangeTypeRemoved         ChangeType = "removed"
+	ChangeTypeModified        ChangeType = "modified"
+	ChangeTypeInstanceAdded   ChangeType = "instance_added"
+	ChangeTypeInstanceRemoved ChangeType = "instance_removed"
+)
+
+type ConfigChange struct {
+	Kind     ItemKind    `json:"kind"`
+	Instance string      `json:"instance"` // Empty for PD config and global variables
+	ID       string      `json:"id"`       // Empty if the whole instance is added or removed
+	Type     ChangeType  `json:"type"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
+
+// diffSnapshots returns the changes from one snapshot to another, optionally filtered by the kind, and the kinds
+// which are not compared since they are not captured in either snapshot. Global variables are only captured in
+// manual snapshots, so that they are compared only when both snapshots have them.
+func diffSnapshots(from, to snapshotConfigs, kind ItemKind) ([]ConfigChange, []ItemKind) {
+	variablesKey := instanceKey{Kind: ItemKindTiDBVariable}
+	_, fromHasVariables := from[variablesKey]
+	_, toHasVariables := to[variablesKey]
+	skipVariables := !fromHasVariables || !toHasVariables
+	notCaptured := make([]ItemKind, 0)
+	if skipVariables && (kind == "" || kind == ItemKindTiDBVariable) {
+		notCaptured = append(notCaptured, ItemKindTiDBVariable)
+	}
+
+	changes := make([]ConfigChange, 0)
+	keys := make(map[instanceKey]struct{})
+	for key := range from {
+		keys[key] = struct{}{}
+	}
+	for key := range to {
+		keys[key] = struct{}{}
+	}
+	for key := range keys {
+		if kind != "" && key.Kind != kind {
+			continue
+		}
+		if skipVariables && key.Kind == ItemKindTiDBVariable {
+			continue
+		}
+		oldValues, oldOk := from[key]
+		newValues, newOk := to[key]
+		switch {
+		case !oldOk:
+			changes = append(changes, ConfigChange{Kind: key.Kind, Instance: key.Instance, Type: ChangeTypeInstanceAdded})
+			continue
+		case !newOk:
+			changes = append(changes, ConfigChange{Kind: key.Kind, Instance: key.Instance, Type: ChangeTypeInstanceRemoved})
+			continue
+		}
+		for id, oldValue := range oldValues {
+			newValue, ok := newValues[id]
+			switch {
+			case !ok:
+				changes = append(changes, ConfigChange{Kind: key.Kind, Instance: key.Instance, ID: id, Type: ChangeTypeRemoved, OldValue: oldValue})
+			case !reflect.DeepEqual(oldValue, newValue):
+				changes = append(changes, ConfigChange{Kind: key.Kind, Instance: key.Instance, ID: id, Type: ChangeTypeModified, OldValue: oldValue, NewValue: newValue})
+			}
+		}
+		for id, newValue := range newValues {
+			if _, ok := oldValues[id]; !ok {
+				changes = append(changes, ConfigChange{Kind: key.Kind, Instance: key.Instance, ID: id, Type: ChangeTypeAdded, NewValue: newValue})
+			}
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Instance != b.Instance {
+			return a.Instance < b.Instance
+		}
+		return a.ID < b.ID
+	})
+	return changes, notCaptured
+}
+
+type DriftDeviation struct {
+	Instance string      `json:"instance"`
+	Value    interface{} `json:"value"`
+	Since    int64       `json:"since"` // The earliest time of consecutive snapshots in which the instance has the value
+}
+
+type DriftItem struct {
+	Kind          ItemKind         `json:"kind"`
+	ID            string           `json:"id"`
+	MajorityValue interface{}      `json:"majority_value"`
+	NumInstances  int              `json:"num_instances"`
+	Deviations    []DriftDeviation `json:"deviations"`
+}
+
+// computeDrift finds instances whose config values deviate from the majority of instances of the same kind.
+// PD config and global variables are cluster-wide, so that they never drift.
+func computeDrift(configs snapshotConfigs, kind ItemKind) []DriftItem {
+	type valueCount struct {
+		encoded string
+		value   interface{}
+		count   int
+	}
+	instancesOfKind := make(map[ItemKind][]string)
+	for key := range configs {
+		if key.Instance == "" || (kind != "" && key.Kind != kind) {
+			continue
+		}
+		instancesOfKind[key.Kind] = append(instancesOfKind[key.Kind], key.Instance)
+	}
+
+	items := make([]DriftItem, 0)
+	for k, instances := range instancesOfKind {
+		if len(instances) < 2 {
+			continue
+		}
+		sort.Strings(instances)
+		ids := make(map[string]struct{})
+		for _, instance := range instances {
+			for id := range configs[instanceKey{Kind: k, Instance: instance}] {
+				ids[id] = struct{}{}
+			}
+		}
+		for id := range ids {
+			counts := make(map[string]*valueCount)
+			encodedValues := make(map[string]string)
+			for _, instance := range instances {
+				value, ok := configs[instanceKey{Kind: k, Instance: instance}][id]
+				if !ok {
+					continue
+				}
+				data, _ := json.Marshal(value)
+				encoded := string(data)
+				encodedValues[instance] = encoded
+				if c, ok := counts[encoded]; ok {
+					c.count++
+				} else {
+					counts[encoded] = &valueCount{encoded: encoded, value: value, count: 1}
+				}
+			}
+			if len(counts) < 2 {
+				continue
+			}
+			var majority *valueCount
+			for _, c := range counts {
+				if majority == nil || c.count > majority.count || (c.count == majority.count && c.encoded < majority.encoded) {
+					majority = c
+				}
+			}
+			item := DriftItem{Kind: k, ID: id, MajorityValue: majority.value, NumInstances: len(encodedValues)}
+			for _, instance := range instances {
+				encoded, ok := encodedValues[instance]
+				if !ok || encoded == majority.encoded {
+					continue
+				}
+				item.Deviations = append(item.Deviations, DriftDeviation{Instance: instance, Value: counts[encoded].value})
+			}
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+// fillDriftSince finds since when each deviation exists, by walking back the history of the instance.
+func (c *blobCache) fillDriftSince(snapshot *SnapshotModel, items []DriftItem) error {
+	var snapshots []SnapshotModel
+	if err := c.store.Select("id, taken_at").Where("id <= ?", snapshot.ID).Find(&snapshots).Error; err != nil {
+		return err
+	}
+	takenAt := make(map[uint]int64, len(snapshots))
+	for _, s := range snapshots {
+		takenAt[s.ID] = s.TakenAt
+	}
+
+	histories := make(map[instanceKey][]SnapshotInstanceModel)
+	for i := range items {
+		for j := range items[i].Deviations {
+			d := &items[i].Deviations[j]
+			key := instanceKey{Kind: items[i].Kind, Instance: d.Instance}
+			history, ok := histories[key]
+			if !ok {
+				err := c.store.
+					Where("kind = ? AND instance = ? AND snapshot_id <= ?", key.Kind, key.Instance, snapshot.ID).
+					Order("snapshot_id DESC").
+					Find(&history).Error
+				if err != nil {
+					return err
+				}
+				histories[key] = history
+			}
+			d.Since = snapshot.TakenAt
+			for _, h := range history {
+				values, err := c.get(h.Hash)
+				if err != nil {
+					return err
+				}
+				value, ok := values[items[i].ID]
+				if !ok || !reflect.DeepEqual(value, d.Value) {
+					break
+				}
+				d.Since = takenAt[h.SnapshotID]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func newTestStore(t *testing.T) *dbstore.DB {
+	gormDB, err := gorm.Open(sqlite.Open(path.Join(t.TempDir(), "test.sqlite.db")), &gorm.Config{})
+	require.NoError(t, err)
+	db := &dbstore.DB{DB: gormDB}
+	require.NoError(t, autoMigrate(db))
+	return db
+}
+
+func tikvItem(address string, values map[string]interface{}) channelItem {
+	return channelItem{SourceKind: ItemKindTiKVConfig, SourceDisplayAddress: address, Values: values}
+}
+
+func TestSnapshotDiffAndDrift(t *testing.T) {
+	store := newTestStore(t)
+	t0 := time.Unix(1000, 0)
+	pd := channelItem{SourceKind: ItemKindPDConfig, Values: map[string]interface{}{"schedule.leader-schedule-limit": 4.0}}
+
+	s1, err := saveSnapshot(store, []channelItem{
+		pd,
+		tikvItem("tikv-0:20160", map[string]interface{}{"gc.batch-keys": 512.0, "raftstore.sync-log": true}),
+		tikvItem("tikv-1:20160", map[string]interface{}{"gc.batch-keys": 512.0, "raftstore.sync-log": true}),
+		tikvItem("tikv-2:20160", map[string]interface{}{"gc.batch-keys": 1024.0, "raftstore.sync-log": true}),
+	}, nil, false, t0)
+	require.NoError(t, err)
+	s2, err := saveSnapshot(store, []channelItem{
+		pd,
+		tikvItem("tikv-0:20160", map[string]interface{}{"gc.batch-keys": 512.0, "raftstore.sync-log": false}),
+		tikvItem("tikv-1:20160", map[string]interface{}{"gc.batch-keys": 512.0, "raftstore.sync-log": true}),
+		tikvItem("tikv-2:20160", map[string]interface{}{"gc.batch-keys": 1024.0, "raftstore.sync-log": true}),
+	}, []rest.ErrorResponse{{Message: "tikv-3 is down"}}, true, t0.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, ErrorMessages{"tikv-3 is down"}, s2.Errors)
+	require.False(t, s2.VariablesCaptured)
+
+	var numBlobs int64
+	require.NoError(t, store.Model(&ConfigBlobModel{}).Count(&numBlobs).Error)
+	require.Equal(t, int64(4), numBlobs)
+
+	cache := newBlobCache(store)
+	_, from, err := cache.loadSnapshot(s1.ID)
+	require.NoError(t, err)
+	snapshot, to, err := cache.loadSnapshot(s2.ID)
+	require.NoError(t, err)
+	changes, notCaptured := diffSnapshots(from, to, "")
+	require.Equal(t, []ConfigChange{
+		{Kind: ItemKindTiKVConfig, Instance: "tikv-0:20160", ID: "raftstore.sync-log", Type: ChangeTypeModified, OldValue: true, NewValue: false},
+	}, changes)
+	require.Equal(t, []ItemKind{ItemKindTiDBVariable}, notCaptured)
+	changes, notCaptured = diffSnapshots(from, to, ItemKindPDConfig)
+	require.Empty(t, changes)
+	require.Empty(t, notCaptured)
+
+	// Global variables missing in periodic snapshots are reported as not captured instead of removed or added.
+	variables := instanceKey{Kind: ItemKindTiDBVariable}
+	to[variables] = map[string]interface{}{"tidb_mem_quota_query": 1024.0}
+	changes, notCaptured = diffSnapshots(from, to, ItemKindTiDBVariable)
+	require.Empty(t, changes)
+	require.Equal(t, []ItemKind{ItemKindTiDBVariable}, notCaptured)
+	from[variables] = map[string]interface{}{"tidb_mem_quota_query": 2048.0}
+	changes, notCaptured = diffSnapshots(from, to, ItemKindTiDBVariable)
+	require.Equal(t, []ConfigChange{
+		{Kind: ItemKindTiDBVariable, ID: "tidb_mem_quota_query", Type: ChangeTypeModified, OldValue: 2048.0, NewValue: 1024.0},
+	}, changes)
+	require.Empty(t, notCaptured)
+	delete(from, variables)
+	delete(to, variables)
+
+	items := computeDrift(to, "")
+	require.NoError(t, cache.fillDriftSince(snapshot, items))
+	require.Equal(t, []DriftItem{
+		{Kind: ItemKindTiKVConfig, ID: "gc.batch-keys", MajorityValue: 512.0, NumInstances: 3, Deviations: []DriftDeviation{
+			{Instance: "tikv-2:20160", Value: 1024.0, Since: t0.Unix()},
+		}},
+		{Kind: ItemKindTiKVConfig, ID: "raftstore.sync-log", MajorityValue: true, NumInstances: 3, Deviations: []DriftDeviation{
+			{Instance: "tikv-0:20160", Value: false, Since: t0.Add(time.Hour).Unix()},
+		}},
+	}, items)
+
+	require.NoError(t, removeExpiredSnapshots(store, t0.Add(time.Minute)))
+	_, _, err = cache.loadSnapshot(s1.ID)
+	require.Error(t, err)
+	require.NoError(t, store.Model(&ConfigBlobModel{}).Count(&numBlobs).Error)
+	require.Equal(t, int64(4), numBlobs)
+
+	require.NoError(t, removeExpiredSnapshots(store, t0.Add(2*time.Hour)))
+	require.NoError(t, store.Model(&ConfigBlobModel{}).Count(&numBlobs).Error)
+	require.Zero(t, numBlobs)
+}
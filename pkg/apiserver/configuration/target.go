@@ -0,0 +1,178 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+	"github.com/pingcap/tidb-dashboard/util/distro"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+// EditTarget selects the instances to edit. An empty target selects all instances.
+type EditTarget struct {
+	// Display addresses of the instances, e.g. 127.0.0.1:20160
+	Instances []string `json:"instances"`
+	// Labels the instances must have, e.g. {"zone": "az1"}
+	Labels map[string]string `json:"labels"`
+}
+
+func (t EditTarget) IsEmpty() bool {
+	return len(t.Instances) == 0 && len(t.Labels) == 0
+}
+
+func (t EditTarget) match(address string, labels map[string]string) bool {
+	if len(t.Instances) > 0 && !slices.Contains(t.Instances, address) {
+		return false
+	}
+	for k, v := range t.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+type InstanceEditResult struct {
+	Instance string              `json:"instance"`
+	Error    *rest.ErrorResponse `json:"error,omitempty"`
+	// Set if the edit succeeds with a caveat, e.g. the change is lost after the instance restarts
+	Warning string `json:"warning,omitempty"`
+}
+
+type editInstance struct {
+	Address    string
+	IP         string
+	StatusPort int
+	Labels     map[string]string
+}
+
+// editInstances applies the edit to each instance, and returns the result of each instance. Failures are returned
+// as warnings, and the first failure is returned as the error as well if all instances fail.
+func editInstances(instances []editInstance, edit func(instance editInstance) error) ([]rest.ErrorResponse, []InstanceEditResult, error) {
+	warnings := make([]rest.ErrorResponse, 0)
+	results := make([]InstanceEditResult, 0, len(instances))
+	var firstErr error
+	for _, instance := range instances {
+		result := InstanceEditResult{Instance: instance.Address}
+		if err := edit(instance); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			resp := rest.NewErrorResponse(err)
+			warnings = append(warnings, resp)
+			result.Error = &resp
+		}
+		results = append(results, result)
+	}
+	if len(warnings) > 0 && len(warnings) == len(instances) {
+		return warnings, results, firstErr
+	}
+	return warnings, results, nil
+}
+
+// warnNotPersisted sets the warning of the succeeded edits, which only change the config of the running instances.
+func warnNotPersisted(results []InstanceEditResult, component string) {
+	for i := range results {
+		if results[i].Error == nil {
+			results[i].Warning = fmt.Sprintf("The change is not persisted and will be lost after the %s instance restarts, update the config file to keep it", component)
+		}
+	}
+}
+
+func filterEditInstances(instances []editInstance, target EditTarget, component string) ([]editInstance, error) {
+	selected := make([]editInstance, 0, len(instances))
+	for _, instance := range instances {
+		if target.match(instance.Address, instance.Labels) {
+			selected = append(selected, instance)
+		}
+	}
+	if len(selected) == 0 && !target.IsEmpty() {
+		return nil, ErrEditFailed.New("No %s instance matches the edit target", component)
+	}
+	return selected, nil
+}
+
+func (s *Service) selectTiKVInstances(target EditTarget) ([]editInstance, error) {
+	tikvInfo, _, err := topology.FetchStoreTopology(s.params.PDClient)
+	if err != nil {
+		return nil, ErrEditFailed.WrapWithNoMessage(ErrListTopologyFailed.WrapWithNoMessage(err))
+	}
+	instances := make([]editInstance, 0, len(tikvInfo))
+	for _, store := range tikvInfo {
+		instances = append(instances, editInstance{
+			Address:    net.JoinHostPort(store.IP, strconv.Itoa(int(store.Port))),
+			IP:         store.IP,
+			StatusPort: int(store.StatusPort),
+			Labels:     store.Labels,
+		})
+	}
+	return filterEditInstances(instances, target, "TiKV")
+}
+
+func (s *Service) selectTiDBInstances(target EditTarget) ([]editInstance, error) {
+	tidbInfo, err := topology.FetchTiDBTopology(s.lifecycleCtx, s.params.EtcdClient)
+	if err != nil {
+		return nil, ErrEditFailed.WrapWithNoMessage(ErrListTopologyFailed.WrapWithNoMessage(err))
+	}
+	instances := make([]editInstance, 0, len(tidbInfo))
+	for _, info := range tidbInfo {
+		instance := editInstance{
+			Address:    net.JoinHostPort(info.IP, strconv.Itoa(int(info.Port))),
+			IP:         info.IP,
+			StatusPort: int(info.StatusPort),
+		}
+		if len(target.Labels) > 0 {
+			// TiDB labels are not reported in the topology, so read them from the config.
+			values, err := s.getConfigItemsFromTiDB(info.IP, int(info.StatusPort))
+			if err != nil {
+				return nil, ErrEditFailed.Wrap(err, "Failed to read labels of %s instance `%s`", distro.R().TiDB, instance.Address)
+			}
+			instance.Labels = tidbLabelsFromConfig(values)
+		}
+		instances = append(instances, instance)
+	}
+	return filterEditInstances(instances, target, distro.R().TiDB)
+}
+
+func tidbLabelsFromConfig(values map[string]interface{}) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range values {
+		if name, ok := strings.CutPrefix(key, "labels."); ok {
+			labels[name] = fmt.Sprint(value)
+		}
+	}
+	return labels
+}
+
+// tidbSettingsQuery builds the query of the TiDB `/settings` API, which changes the config of a single instance.
+// The change is not persisted to the config file of the instance.
+func tidbSettingsQuery(id string, newValue interface{}) (url.Values, error) {
+	param, ok := tidbSettingsParams[id]
+	if !ok {
+		return nil, ErrNotEditable.New("Configuration `%s` is not editable", id)
+	}
+	var value string
+	switch v := newValue.(type) {
+	case bool:
+		value = "0"
+		if v {
+			value = "1"
+		}
+	case float64:
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		value = v
+	default:
+		return nil, ErrEditFailed.New("Unsupported value `%v` for configuration `%s`", newValue, id)
+	}
+	query := url.Values{}
+	query.Set(param, value)
+	return query, nil
+}
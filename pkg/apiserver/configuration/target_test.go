@@ -0,0 +1,112 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+func TestMergeConfigItems(t *testing.T) {
+	items := mergeConfigItems([]channelItem{
+		tikvItem("tikv-1:20160", map[string]interface{}{"gc.batch-keys": 1024.0, "raftstore.sync-log": true}),
+		tikvItem("tikv-0:20160", map[string]interface{}{"gc.batch-keys": 512.0, "raftstore.sync-log": true, "split.qps-threshold": 3000.0}),
+		{SourceKind: ItemKindPDConfig, Values: map[string]interface{}{"log.level": "info"}},
+	})
+
+	require.Equal(t, []Item{{ID: "log.level", IsEditable: true, Value: "info"}}, items[ItemKindPDConfig])
+	tikv := items[ItemKindTiKVConfig]
+	require.Len(t, tikv, 3)
+	require.Equal(t, "gc.batch-keys", tikv[0].ID)
+	require.True(t, tikv[0].IsMultiValue)
+	require.Equal(t, []InstanceValue{
+		{Instance: "tikv-0:20160", Value: 512.0},
+		{Instance: "tikv-1:20160", Value: 1024.0},
+	}, tikv[0].Values)
+	require.Equal(t, Item{ID: "raftstore.sync-log", IsEditable: true, Value: true}, tikv[1])
+	require.True(t, tikv[2].IsMultiValue)
+	require.Equal(t, []InstanceValue{
+		{Instance: "tikv-0:20160", Value: 3000.0},
+		{Instance: "tikv-1:20160", Missing: true},
+	}, tikv[2].Values)
+}
+
+func TestEditTarget(t *testing.T) {
+	instances := []editInstance{
+		{Address: "tikv-0:20160", Labels: map[string]string{"zone": "az1", "host": "h0"}},
+		{Address: "tikv-1:20160", Labels: map[string]string{"zone": "az1", "host": "h1"}},
+		{Address: "tikv-2:20160", Labels: map[string]string{"zone": "az2", "host": "h2"}},
+	}
+	addresses := func(target EditTarget) []string {
+		selected, err := filterEditInstances(instances, target, "TiKV")
+		require.NoError(t, err)
+		r := make([]string, 0, len(selected))
+		for _, i := range selected {
+			r = append(r, i.Address)
+		}
+		return r
+	}
+
+	require.Len(t, addresses(EditTarget{}), 3)
+	require.Equal(t, []string{"tikv-2:20160"}, addresses(EditTarget{Instances: []string{"tikv-2:20160"}}))
+	require.Equal(t, []string{"tikv-0:20160", "tikv-1:20160"}, addresses(EditTarget{Labels: map[string]string{"zone": "az1"}}))
+	require.Equal(t, []string{"tikv-1:20160"}, addresses(EditTarget{
+		Instances: []string{"tikv-1:20160", "tikv-2:20160"},
+		Labels:    map[string]string{"zone": "az1"},
+	}))
+
+	_, err := filterEditInstances(instances, EditTarget{Labels: map[string]string{"zone": "az3"}}, "TiKV")
+	require.Error(t, err)
+}
+
+func TestEditInstances(t *testing.T) {
+	instances := []editInstance{{Address: "tikv-0:20160"}, {Address: "tikv-1:20160"}}
+
+	warnings, results, err := editInstances(instances, func(instance editInstance) error {
+		if instance.Address == "tikv-1:20160" {
+			return ErrEditFailed.New("failed")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	require.Len(t, results, 2)
+	require.Nil(t, results[0].Error)
+	require.NotNil(t, results[1].Error)
+
+	warnings, results, err = editInstances(instances, func(instance editInstance) error {
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+	require.Len(t, warnings, 2)
+	require.Len(t, results, 2)
+	require.NotNil(t, results[0].Error)
+	require.NotNil(t, results[1].Error)
+
+	results = []InstanceEditResult{{Instance: "tidb-0:4000"}, {Instance: "tidb-1:4000", Error: &rest.ErrorResponse{}}}
+	warnNotPersisted(results, "TiDB")
+	require.Contains(t, results[0].Warning, "not persisted")
+	require.Empty(t, results[1].Warning)
+}
+
+func TestTiDBSettingsQuery(t *testing.T) {
+	query, err := tidbSettingsQuery("instance.tidb_general_log", true)
+	require.NoError(t, err)
+	require.Equal(t, "tidb_general_log=1", query.Encode())
+
+	query, err = tidbSettingsQuery("instance.ddl_slow_threshold", 300.0)
+	require.NoError(t, err)
+	require.Equal(t, "ddl_slow_threshold=300", query.Encode())
+
+	_, err = tidbSettingsQuery("performance.max-procs", 4.0)
+	require.Error(t, err)
+
+	require.Equal(t, map[string]string{"zone": "az1"}, tidbLabelsFromConfig(map[string]interface{}{
+		"labels.zone": "az1",
+		"log.level":   "info",
+	}))
+}
@@ -0,0 +1,413 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package conprof
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/pprof/profile"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	maxAggregatedProfiles = 500
+
+	aggregateGroupByInstance = "instance"
+)
+
+type AggregateRequest struct {
+	BeginTime int64 `json:"begin_time" form:"begin_time"`
+	EndTime   int64 `json:"end_time" form:"end_time"`
+	// The profiles in the base range are subtracted if it is specified.
+	BaseBeginTime int64  `json:"base_begin_time" form:"base_begin_time"`
+	BaseEndTime   int64  `json:"base_end_time" form:"base_end_time"`
+	Component     string `json:"component" form:"component"`
+	Address       string `json:"address" form:"address"` // empty means all instances of the component
+	ProfileType   string `json:"profile_type" form:"profile_type"`
+	GroupBy       string `json:"group_by" form:"group_by"`       // empty or "instance"
+	OutputType    string `json:"output_type" form:"output_type"` // top, graph or protobuf
+}
+
+func (r *AggregateRequest) hasBase() bool {
+	return r.BaseEndTime > r.BaseBeginTime
+}
+
+type AggregateResult struct {
+	Address         string                          `json:"address"` // empty if not grouped by instance
+	NumProfiles     int                             `json:"num_profiles"`
+	BaseNumProfiles int                             `json:"base_num_profiles"`
+	Top             *profiling.TopFunctionsResponse `json:"top,omitempty"`
+	Diff            *profiling.DiffTopResponse      `json:"diff,omitempty"`
+}
+
+type AggregateResponse struct {
+	Results []AggregateResult `json:"results"`
+}
+
+// rangeProfiles are the parsed protobuf profiles in a time range, keyed by the instance address,
+// or by the empty string if not grouped by instance.
+type rangeProfiles map[string][]*profile.Profile
+
+func (r rangeProfiles) add(req *AggregateRequest, address string, data []byte) error {
+	p, err := profile.ParseData(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse profile of %s: %v", address, err)
+	}
+	key := ""
+	if req.GroupBy == aggregateGroupByInstance {
+		key = address
+	}
+	r[key] = append(r[key], p)
+	return nil
+}
+
+func (r rangeProfiles) count() int {
+	n := 0
+	for _, profiles := range r {
+		n += len(profiles)
+	}
+	return n
+}
+
+// loadLocalProfiles loads the profiles collected by the built-in continuous profiler.
+func (s *Service) loadLocalProfiles(req *AggregateRequest, beginTime, endTime int64) (rangeProfiles, error) {
+	db := s.params.LocalStore.
+		Where("ts >= ? AND ts <= ? AND state = ?", beginTime, endTime, profileStateSuccess).
+		Where("component = ? AND profile_type = ?", req.Component, req.ProfileType)
+	if req.Address != "" {
+		db = db.Where("address = ?", req.Address)
+	}
+	var models []ProfileModel
+	if err := db.Order("ts").Limit(maxAggregatedProfiles + 1).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	if len(models) > maxAggregatedProfiles {
+		return nil, rest.ErrBadRequest.New("Too many profiles in the time range, expect at most %d", maxAggregatedProfiles)
+	}
+
+	result := make(rangeProfiles)
+	for _, m := range models {
+		if m.RawDataType != profiling.RawDataTypeProtobuf {
+			return nil, rest.ErrBadRequest.New("Cannot aggregate %s profiles", m.RawDataType)
+		}
+		data, err := decompress(m.Data)
+		if err != nil {
+			return nil, err
+		}
+		if err := result.add(req, m.Address, data); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// loadNgmProfiles downloads the profiles through the NgMonitoring API. The profiles of a group are downloaded by a
+// single request, which is filtered by the component, the profile type and the address.
+func (s *Service) loadNgmProfiles(ctx context.Context, req *AggregateRequest, beginTime, endTime int64) (rangeProfiles, error) {
+	body, err := s.params.NgmProxy.SendGetRequest(ctx, "/continuous_profiling/group_profiles", url.Values{
+		"begin_time": []string{strconv.FormatInt(beginTime, 10)},
+		"end_time":   []string{strconv.FormatInt(endTime, 10)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups []GroupProfiles
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return nil, err
+	}
+
+	result := make(rangeProfiles)
+	for _, g := range groups {
+		// Running groups are not complete yet, and failed groups have no profiles.
+		if g.State != groupStateFinished && g.State != groupStatePartialFailed {
+			continue
+		}
+		ts := strconv.FormatInt(g.Ts, 10)
+		var addresses []string
+		if req.GroupBy == aggregateGroupByInstance && req.Address == "" {
+			// The addresses are only needed to tell which instance each downloaded profile belongs to.
+			if addresses, err = s.loadNgmProfileAddresses(ctx, req, ts); err != nil {
+				return nil, err
+			}
+			if len(addresses) == 0 {
+				continue
+			}
+		}
+		query := url.Values{
+			"ts":           []string{ts},
+			"profile_type": []string{req.ProfileType},
+			"component":    []string{req.Component},
+			"data_format":  []string{"protobuf"},
+		}
+		if req.Address != "" {
+			query.Set("address", req.Address)
+		}
+		body, err := s.params.NgmProxy.SendGetRequest(ctx, "/continuous_profiling/download", query)
+		if err != nil {
+			return nil, err
+		}
+		files, err := unzipProfiles(body)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			address := req.Address
+			if addresses != nil {
+				if address = matchProfileAddress(f.name, addresses); address == "" {
+					continue
+				}
+			}
+			if result.count() >= maxAggregatedProfiles {
+				return nil, rest.ErrBadRequest.New("Too many profiles in the time range, expect at most %d", maxAggregatedProfiles)
+			}
+			if err := result.add(req, address, f.data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// loadNgmProfileAddresses returns the addresses of the successful profiles in a group matching the request.
+func (s *Service) loadNgmProfileAddresses(ctx context.Context, req *AggregateRequest, ts string) ([]string, error) {
+	body, err := s.params.NgmProxy.SendGetRequest(ctx, "/continuous_profiling/group_profile/detail", url.Values{"ts": []string{ts}})
+	if err != nil {
+		return nil, err
+	}
+	var detail GroupProfileDetail
+	if err := json.Unmarshal(body, &detail); err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, p := range detail.TargetProfiles {
+		if p.State == profileStateSuccess && p.Type == req.ProfileType && p.Target.Component == req.Component {
+			addresses = append(addresses, p.Target.Address)
+		}
+	}
+	return addresses, nil
+}
+
+// matchProfileAddress returns the address whose profile is stored in the file, or empty if there is none. The file
+// names contain the addresses whose colons are replaced by underscores.
+func matchProfileAddress(fileName string, addresses []string) string {
+	for _, address := range addresses {
+		if strings.Contains(fileName, "_"+strings.NewReplacer(":", "_").Replace(address)+"_") {
+			return address
+		}
+	}
+	return ""
+}
+
+type zipFile struct {
+	name string
+	data []byte
+}
+
+// unzipProfiles returns the profiles in the downloaded file, skipping the readme.
+func unzipProfiles(data []byte) ([]zipFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]zipFile, 0, len(zr.File))
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, ".md") {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, zipFile{name: f.Name, data: content})
+	}
+	return files, nil
+}
+
+func (s *Service) loadRangeProfiles(ctx context.Context, req *AggregateRequest, beginTime, endTime int64) (rangeProfiles, error) {
+	deployed, err := s.params.NgmProxy.IsDeployed()
+	if err != nil {
+		return nil, err
+	}
+	if deployed {
+		return s.loadNgmProfiles(ctx, req, beginTime, endTime)
+	}
+	return s.loadLocalProfiles(req, beginTime, endTime)
+}
+
+// aggregatedProfile is the merged profile of an instance, or of all instances.
+type aggregatedProfile struct {
+	AggregateResult
+	profile *profile.Profile
+}
+
+// aggregate merges the profiles of each key, and subtracts the base profiles if there are.
+func aggregate(target, base rangeProfiles, hasBase bool) ([]*aggregatedProfile, error) {
+	keys := make([]string, 0, len(target))
+	for key := range target {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	results := make([]*aggregatedProfile, 0, len(keys))
+	for _, key := range keys {
+		merged, err := profile.Merge(target[key])
+		if err != nil {
+			return nil, err
+		}
+		result := &aggregatedProfile{
+			AggregateResult: AggregateResult{Address: key, NumProfiles: len(target[key]), BaseNumProfiles: len(base[key])},
+			profile:         merged,
+		}
+		if hasBase {
+			if len(base[key]) == 0 {
+				if key == "" {
+					return nil, rest.ErrBadRequest.New("No profiles in the base time range")
+				}
+				return nil, rest.ErrBadRequest.New("No profiles of %s in the base time range", key)
+			}
+			baseMerged, err := profile.Merge(base[key])
+			if err != nil {
+				return nil, err
+			}
+			if result.profile, err = profiling.DiffProfiles(baseMerged, merged); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// @Summary Aggregate continuous profiles in a time range
+// @Description Merge all profiles of a component and profile type in a time range, optionally grouped by instance or compared with a base time range
+// @Router /continuous_profiling/aggregate [get]
+// @Param q query AggregateRequest true "Query"
+// @Param limit query int false "number of functions returned in top output"
+// @Security JwtAuth
+// @Produce json
+// @Produce html
+// @Produce application/x-gzip
+// @Success 200 {object} AggregateResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) aggregateProfiles(c *gin.Context) {
+	var req AggregateRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	if req.EndTime <= req.BeginTime || req.Component == "" || req.ProfileType == "" {
+		rest.Error(c, rest.ErrBadRequest.New("begin_time, end_time, component and profile_type are required"))
+		return
+	}
+	if req.GroupBy != "" && req.GroupBy != aggregateGroupByInstance {
+		rest.Error(c, rest.ErrBadRequest.New("Unsupported group_by %s", req.GroupBy))
+		return
+	}
+	outputType := profiling.ViewOutputType(req.OutputType)
+	if outputType == "" {
+		outputType = profiling.ViewOutputTypeTop
+	}
+	limit, err := profiling.ParseTopLimit(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	target, err := s.loadRangeProfiles(ctx, &req, req.BeginTime, req.EndTime)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	var base rangeProfiles
+	if req.hasBase() {
+		if base, err = s.loadRangeProfiles(ctx, &req, req.BaseBeginTime, req.BaseEndTime); err != nil {
+			rest.Error(c, err)
+			return
+		}
+	}
+	results, err := aggregate(target, base, req.hasBase())
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	switch outputType {
+	case profiling.ViewOutputTypeTop:
+		resp := AggregateResponse{Results: make([]AggregateResult, 0, len(results))}
+		for _, r := range results {
+			if req.hasBase() {
+				diff := profiling.TopDiffFunctions(r.profile, limit)
+				r.Diff = &diff
+			} else {
+				top := profiling.TopFunctions(r.profile, limit)
+				r.Top = &top
+			}
+			resp.Results = append(resp.Results, r.AggregateResult)
+		}
+		c.JSON(http.StatusOK, resp)
+	case profiling.ViewOutputTypeGraph:
+		if len(results) != 1 {
+			rest.Error(c, rest.ErrBadRequest.New("Expect exactly 1 aggregated profile to output graph, got %d", len(results)))
+			return
+		}
+		var buf bytes.Buffer
+		if err := results[0].profile.Write(&buf); err != nil {
+			rest.Error(c, err)
+			return
+		}
+		task := profiling.TaskModel{RawDataType: profiling.RawDataTypeProtobuf}
+		content, contentType, err := profiling.ConvertProfile(task, buf.Bytes(), outputType)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		c.Data(http.StatusOK, contentType, content)
+	case profiling.ViewOutputTypeProtobuf:
+		fileName := fmt.Sprintf("%s_%s_aggregated_%s.zip", req.ProfileType, req.Component, time.Now().Format("2006-01-02_15-04-05"))
+		c.Writer.Header().Set("Content-type", "application/octet-stream")
+		c.Writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+		zw := zip.NewWriter(c.Writer)
+		defer func() {
+			_ = zw.Close()
+		}()
+		for _, r := range results {
+			name := "all"
+			if r.Address != "" {
+				name = strings.NewReplacer(":", "_").Replace(r.Address)
+			}
+			f, err := zw.Create(fmt.Sprintf("%s_%s_%s.pb.gz", req.ProfileType, req.Component, name))
+			if err != nil {
+				rest.Error(c, err)
+				return
+			}
+			if err := r.profile.Write(f); err != nil {
+				rest.Error(c, err)
+				return
+			}
+		}
+	default:
+		rest.Error(c, rest.ErrBadRequest.New("Cannot output aggregated profiles as %s", outputType))
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package conprof
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
+)
+
+// buildCPUProfile builds a profile with a single sample of the given function.
+func buildCPUProfile(t *testing.T, function string, value int64) []byte {
+	fn := &profile.Function{ID: 1, Name: function}
+	loc := &profile.Location{ID: 1, Line: []profile.Line{{Function: fn}}}
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "cpu", Unit: "nanoseconds"}},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     1,
+		Sample:     []*profile.Sample{{Location: []*profile.Location{loc}, Value: []int64{value}}},
+		Location:   []*profile.Location{loc},
+		Function:   []*profile.Function{fn},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, p.Write(&buf))
+	data, err := compress(buf.Bytes())
+	require.NoError(t, err)
+	return data
+}
+
+func TestAggregateLocalProfiles(t *testing.T) {
+	s := newTestService(t)
+	db := s.params.LocalStore
+
+	for _, p := range []ProfileModel{
+		{Ts: 100, Address: "127.0.0.1:10080", Data: buildCPUProfile(t, "foo", 10)},
+		{Ts: 100, Address: "127.0.0.2:10080", Data: buildCPUProfile(t, "foo", 20)},
+		{Ts: 200, Address: "127.0.0.1:10080", Data: buildCPUProfile(t, "foo", 30)},
+		{Ts: 200, Address: "127.0.0.2:10080", Data: buildCPUProfile(t, "bar", 40)},
+	} {
+		p.ProfileType = "profile"
+		p.Component = "tidb"
+		p.State = profileStateSuccess
+		p.RawDataType = profiling.RawDataTypeProtobuf
+		require.NoError(t, db.Create(&p).Error)
+	}
+
+	req := &AggregateRequest{Component: "tidb", ProfileType: "profile"}
+	target, err := s.loadLocalProfiles(req, 0, 300)
+	require.NoError(t, err)
+	results, err := aggregate(target, nil, false)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, 4, results[0].NumProfiles)
+	top := profiling.TopFunctions(results[0].profile, 10)
+	require.Equal(t, int64(100), top.Total)
+	require.Equal(t, []profiling.TopFunction{{Name: "foo", Flat: 60, Cum: 60}, {Name: "bar", Flat: 40, Cum: 40}}, top.ByFlat)
+
+	req.GroupBy = aggregateGroupByInstance
+	base, err := s.loadLocalProfiles(req, 0, 150)
+	require.NoError(t, err)
+	target, err = s.loadLocalProfiles(req, 150, 300)
+	require.NoError(t, err)
+	results, err = aggregate(target, base, true)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "127.0.0.1:10080", results[0].Address)
+	diff := profiling.TopDiffFunctions(results[0].profile, 10)
+	require.Equal(t, int64(10), diff.BaseTotal)
+	require.Equal(t, int64(30), diff.TargetTotal)
+	require.Equal(t, "127.0.0.2:10080", results[1].Address)
+	diff = profiling.TopDiffFunctions(results[1].profile, 10)
+	require.Equal(t, []profiling.DiffFunction{
+		{Name: "bar", TargetFlat: 40, DeltaFlat: 40, TargetCum: 40, DeltaCum: 40},
+		{Name: "foo", BaseFlat: 20, DeltaFlat: -20, BaseCum: 20, DeltaCum: -20},
+	}, diff.Functions)
+
+	req.Address = "127.0.0.1:10080"
+	target, err = s.loadLocalProfiles(req, 0, 300)
+	require.NoError(t, err)
+	require.Len(t, target, 1)
+	require.Len(t, target["127.0.0.1:10080"], 2)
+}
+
+func TestAggregateWithoutBase(t *testing.T) {
+	data, err := decompress(buildCPUProfile(t, "foo", 10))
+	require.NoError(t, err)
+	target := make(rangeProfiles)
+	req := &AggregateRequest{GroupBy: aggregateGroupByInstance}
+	require.NoError(t, target.add(req, "127.0.0.1:10080", data))
+
+	_, err = aggregate(target, rangeProfiles{}, true)
+	require.Error(t, err)
+}
+
+func TestMatchProfileAddress(t *testing.T) {
+	addresses := []string{"127.0.0.1:1008", "127.0.0.1:10080"}
+	require.Equal(t, "127.0.0.1:10080", matchProfileAddress("profile_tidb_127.0.0.1_10080_100.proto", addresses))
+	require.Equal(t, "127.0.0.1:1008", matchProfileAddress("profile_tidb_127.0.0.1_1008_100.proto", addresses))
+	require.Equal(t, "", matchProfileAddress("profile_tidb_127.0.0.2_10080_100.proto", addresses))
+}
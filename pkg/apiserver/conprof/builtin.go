@@ -0,0 +1,273 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package conprof
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
+)
+
+const (
+	maxConcurrentScrapes = 16
+	// Expired profiles are removed even if the built-in profiler is disabled or NgMonitoring is deployed.
+	builtinRetentionInterval = 10 * time.Minute
+)
+
+// profileTypes maps the profile types of NgMonitoring to the profiling types, in display order.
+var profileTypes = []struct {
+	name          string
+	profilingType profiling.TaskProfilingType
+}{
+	{"profile", profiling.ProfilingTypeCPU},
+	{"heap", profiling.ProfilingTypeHeap},
+	{"goroutine", profiling.ProfilingTypeGoroutine},
+	{"mutex", profiling.ProfilingTypeMutex},
+}
+
+// supportedProfileTypes returns the number of profile types scraped from the component.
+func supportedProfileTypes(kind model.NodeKind) int {
+	if kind == model.NodeKindTiKV || kind == model.NodeKindTiFlash {
+		return 2 // profile and heap
+	}
+	return len(profileTypes)
+}
+
+// fetchTargets returns all components which are up, with the ports used by profiling.
+func (s *Service) fetchTargets(ctx context.Context) []Component {
+	var components []Component
+	add := func(kind model.NodeKind, ip string, port, statusPort uint, status topology.ComponentStatus) {
+		if status != topology.ComponentStatusUp {
+			return
+		}
+		components = append(components, Component{Name: string(kind), IP: ip, Port: port, StatusPort: statusPort})
+	}
+
+	if tidbs, err := topology.FetchTiDBTopology(ctx, s.params.EtcdClient); err != nil {
+		log.Warn("failed to fetch tidb topology for continuous profiling", zap.Error(err))
+	} else {
+		for _, i := range tidbs {
+			add(model.NodeKindTiDB, i.IP, i.Port, i.StatusPort, i.Status)
+		}
+	}
+	if pds, err := topology.FetchPDTopology(s.params.PDClient); err != nil {
+		log.Warn("failed to fetch pd topology for continuous profiling", zap.Error(err))
+	} else {
+		for _, i := range pds {
+			add(model.NodeKindPD, i.IP, i.Port, i.Port, i.Status)
+		}
+	}
+	if tikvs, tiflashes, err := topology.FetchStoreTopology(s.params.PDClient); err != nil {
+		log.Warn("failed to fetch store topology for continuous profiling", zap.Error(err))
+	} else {
+		for _, i := range tikvs {
+			add(model.NodeKindTiKV, i.IP, i.Port, i.StatusPort, i.Status)
+		}
+		for _, i := range tiflashes {
+			add(model.NodeKindTiFlash, i.IP, i.Port, i.StatusPort, i.Status)
+		}
+	}
+	if ticdcs, err := topology.FetchTiCDCTopology(ctx, s.params.EtcdClient); err != nil {
+		log.Warn("failed to fetch ticdc topology for continuous profiling", zap.Error(err))
+	} else {
+		for _, i := range ticdcs {
+			add(model.NodeKindTiCDC, i.IP, i.Port, i.StatusPort, i.Status)
+		}
+	}
+	if tiproxies, err := topology.FetchTiProxyTopology(ctx, s.params.EtcdClient); err != nil {
+		log.Warn("failed to fetch tiproxy topology for continuous profiling", zap.Error(err))
+	} else {
+		for _, i := range tiproxies {
+			add(model.NodeKindTiProxy, i.IP, i.Port, i.StatusPort, i.Status)
+		}
+	}
+	if tsos, err := topology.FetchTSOTopology(ctx, s.params.PDClient); err != nil {
+		log.Warn("failed to fetch tso topology for continuous profiling", zap.Error(err))
+	} else {
+		for _, i := range tsos {
+			add(model.NodeKindTSO, i.IP, i.Port, i.Port, i.Status)
+		}
+	}
+	if schedulings, err := topology.FetchSchedulingTopology(ctx, s.params.PDClient); err != nil {
+		log.Warn("failed to fetch scheduling topology for continuous profiling", zap.Error(err))
+	} else {
+		for _, i := range schedulings {
+			add(model.NodeKindScheduling, i.IP, i.Port, i.Port, i.Status)
+		}
+	}
+	return components
+}
+
+// requestTarget returns the profiling target of the component, whose address is the status address.
+func (c *Component) requestTarget() model.RequestTargetNode {
+	address := net.JoinHostPort(c.IP, strconv.Itoa(int(c.StatusPort)))
+	return model.RequestTargetNode{
+		Kind:        model.NodeKind(c.Name),
+		DisplayName: address,
+		IP:          c.IP,
+		Port:        int(c.StatusPort),
+	}
+}
+
+func (s *Service) builtinLoop(ctx context.Context) {
+	cfgCh := s.params.ConfigManager.NewPushChannel()
+	var cfg config.ContinuousProfilingConfig
+	var timeCh <-chan time.Time
+	var scrapeWg sync.WaitGroup
+	defer scrapeWg.Wait()
+	retentionTicker := time.NewTicker(builtinRetentionInterval)
+	defer retentionTicker.Stop()
+
+	scraping := false
+	scrapeDone := make(chan struct{}, 1)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case dc, ok := <-cfgCh:
+			if !ok {
+				return
+			}
+			cfg = dc.ContinuousProfiling
+			timeCh = nil
+			if cfg.Enable {
+				timeCh = time.After(time.Duration(cfg.IntervalSeconds) * time.Second)
+			}
+		case <-scrapeDone:
+			scraping = false
+		case <-retentionTicker.C:
+			if cfg.DataRetentionSeconds > 0 {
+				s.removeExpiredProfiles(cfg.DataRetentionSeconds)
+			}
+		case <-timeCh:
+			timeCh = time.After(time.Duration(cfg.IntervalSeconds) * time.Second)
+			// The last round is not finished.
+			if scraping {
+				continue
+			}
+			deployed, err := s.params.NgmProxy.IsDeployed()
+			if err != nil {
+				// NgMonitoring may be scraping, skip the round to avoid scraping twice.
+				log.Warn("failed to detect NgMonitoring, skip the continuous profiling round", zap.Error(err))
+				continue
+			}
+			// Leave the work to NgMonitoring if it is deployed.
+			if deployed {
+				continue
+			}
+			scraping = true
+			roundCfg := cfg
+			scrapeWg.Go(func() {
+				s.scrape(ctx, roundCfg)
+				scrapeDone <- struct{}{}
+			})
+		}
+	}
+}
+
+// scrape collects profiles of all components as a group.
+func (s *Service) scrape(ctx context.Context, cfg config.ContinuousProfilingConfig) {
+	group := &GroupModel{Ts: time.Now().Unix(), ProfileSecs: cfg.ProfileSeconds, State: groupStateRunning}
+	if err := s.params.LocalStore.Create(group).Error; err != nil {
+		log.Warn("failed to create continuous profiling group", zap.Error(err))
+		return
+	}
+
+	var mu sync.Mutex
+	succeeded, failed := 0, 0
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentScrapes)
+	for _, component := range s.fetchTargets(ctx) {
+		target := component.requestTarget()
+		for _, typ := range profileTypes[:supportedProfileTypes(target.Kind)] {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				profile := s.scrapeProfile(ctx, cfg, group.Ts, target, typ.name, typ.profilingType)
+				if err := s.params.LocalStore.Create(profile).Error; err != nil {
+					log.Warn("failed to save continuous profile", zap.Error(err))
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if profile.State == profileStateSuccess {
+					succeeded++
+				} else {
+					failed++
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	switch {
+	case failed == 0:
+		group.State = groupStateFinished
+	case succeeded == 0:
+		group.State = groupStateFailed
+	default:
+		group.State = groupStatePartialFailed
+	}
+	if err := s.params.LocalStore.Save(group).Error; err != nil {
+		log.Warn("failed to save continuous profiling group", zap.Error(err))
+	}
+}
+
+func (s *Service) scrapeProfile(ctx context.Context, cfg config.ContinuousProfilingConfig, ts int64, target model.RequestTargetNode, profileType string, profilingType profiling.TaskProfilingType) *ProfileModel {
+	profile := &ProfileModel{
+		Ts:          ts,
+		ProfileType: profileType,
+		Component:   string(target.Kind),
+		Address:     target.DisplayName,
+		State:       profileStateFailed,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+	task, err := s.params.Profiling.FetchProfile(ctx, &target, profilingType, uint(cfg.ProfileSeconds))
+	if err != nil {
+		profile.Error = err.Error()
+		return profile
+	}
+	defer func() {
+		_ = os.Remove(task.FilePath)
+	}()
+
+	data, err := os.ReadFile(task.FilePath)
+	if err == nil {
+		data, err = compress(data)
+	}
+	if err != nil {
+		profile.Error = err.Error()
+		return profile
+	}
+	profile.State = profileStateSuccess
+	profile.RawDataType = task.RawDataType
+	profile.Data = data
+	return profile
+}
+
+func (s *Service) removeExpiredProfiles(retentionSecs int) {
+	before := time.Now().Unix() - int64(retentionSecs)
+	if err := s.params.LocalStore.Where("ts < ?", before).Delete(&ProfileModel{}).Error; err != nil {
+		log.Warn("failed to remove expired continuous profiles", zap.Error(err))
+		return
+	}
+	if err := s.params.LocalStore.Where("ts < ?", before).Delete(&GroupModel{}).Error; err != nil {
+		log.Warn("failed to remove expired continuous profiling groups", zap.Error(err))
+	}
+}
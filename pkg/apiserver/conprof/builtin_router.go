@@ -0,0 +1,335 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package conprof
+
+import (
+	"archive/zip"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const defaultEstimatedProfileBytes = 50 * 1024
+
+// The handlers below implement the NgMonitoring continuous profiling API by the built-in continuous profiler.
+
+func (s *Service) getBuiltinConfig(c *gin.Context) {
+	dc, err := s.params.ConfigManager.Get()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, NgMonitoringConfig{ContinuousProfiling: dc.ContinuousProfiling})
+}
+
+func (s *Service) updateBuiltinConfig(c *gin.Context) {
+	dc, err := s.params.ConfigManager.Get()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	// Fields absent from the request keep their current values.
+	req := NgMonitoringConfig{ContinuousProfiling: dc.ContinuousProfiling}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	var opt config.DynamicConfigOption = func(dc *config.DynamicConfig) {
+		dc.ContinuousProfiling = req.ContinuousProfiling
+	}
+	if err := s.params.ConfigManager.Modify(opt); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.String(http.StatusOK, "ok")
+}
+
+func (s *Service) getBuiltinComponents(c *gin.Context) {
+	components := s.fetchTargets(s.lifecycleCtx)
+	if components == nil {
+		components = []Component{}
+	}
+	c.JSON(http.StatusOK, components)
+}
+
+func (s *Service) estimateBuiltinSize(c *gin.Context) {
+	dc, err := s.params.ConfigManager.Get()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	var avgSize struct {
+		Size float64
+	}
+	err = s.params.LocalStore.Model(&ProfileModel{}).
+		Select("AVG(LENGTH(data)) AS size").
+		Where("state = ?", profileStateSuccess).
+		Scan(&avgSize).Error
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	profileBytes := int(avgSize.Size)
+	if profileBytes == 0 {
+		profileBytes = defaultEstimatedProfileBytes
+	}
+
+	components := s.fetchTargets(s.lifecycleCtx)
+	profilesPerRound := 0
+	for _, component := range components {
+		profilesPerRound += supportedProfileTypes(component.requestTarget().Kind)
+	}
+	intervalSecs := dc.ContinuousProfiling.IntervalSeconds
+	if intervalSecs <= 0 {
+		intervalSecs = config.DefaultContinuousProfilingIntervalSeconds
+	}
+	c.JSON(http.StatusOK, EstimateSizeRes{
+		InstanceCount: len(components),
+		ProfileSize:   profilesPerRound * profileBytes * (24 * 60 * 60 / intervalSecs),
+	})
+}
+
+func (s *Service) getBuiltinGroupProfiles(c *gin.Context) {
+	beginTime, err1 := strconv.ParseInt(c.Query("begin_time"), 10, 64)
+	endTime, err2 := strconv.ParseInt(c.Query("end_time"), 10, 64)
+	if err1 != nil || err2 != nil {
+		rest.Error(c, rest.ErrBadRequest.New("begin_time and end_time are required"))
+		return
+	}
+
+	var groups []GroupModel
+	err := s.params.LocalStore.Where("ts >= ? AND ts <= ?", beginTime, endTime).Order("ts DESC").Find(&groups).Error
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	var profiles []ProfileModel
+	err = s.params.LocalStore.Select("ts, component, address").
+		Where("ts >= ? AND ts <= ?", beginTime, endTime).
+		Find(&profiles).Error
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	instances := make(map[int64]map[string]string) // ts -> address -> component
+	for _, p := range profiles {
+		if instances[p.Ts] == nil {
+			instances[p.Ts] = make(map[string]string)
+		}
+		instances[p.Ts][p.Component+"/"+p.Address] = p.Component
+	}
+	resp := make([]GroupProfiles, 0, len(groups))
+	for _, g := range groups {
+		var num ComponentNum
+		for _, component := range instances[g.Ts] {
+			switch model.NodeKind(component) {
+			case model.NodeKindTiDB:
+				num.TiDB++
+			case model.NodeKindPD:
+				num.PD++
+			case model.NodeKindTiKV:
+				num.TiKV++
+			case model.NodeKindTiFlash:
+				num.TiFlash++
+			case model.NodeKindTiCDC:
+				num.TiCDC++
+			case model.NodeKindTiProxy:
+				num.TiProxy++
+			case model.NodeKindTSO:
+				num.TSO++
+			case model.NodeKindScheduling:
+				num.Scheduling++
+			}
+		}
+		resp = append(resp, GroupProfiles{
+			Ts:          g.Ts,
+			ProfileSecs: g.ProfileSecs,
+			State:       g.State,
+			CompNum:     num,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Service) getBuiltinGroupProfileDetail(c *gin.Context) {
+	ts, err := strconv.ParseInt(c.Query("ts"), 10, 64)
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("ts is required"))
+		return
+	}
+	var group GroupModel
+	if err := s.params.LocalStore.Where("ts = ?", ts).First(&group).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	var profiles []ProfileModel
+	err = s.params.LocalStore.Select("ts, profile_type, component, address, state, error").
+		Where("ts = ?", ts).
+		Find(&profiles).Error
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	resp := GroupProfileDetail{
+		Ts:             group.Ts,
+		ProfileSecs:    group.ProfileSecs,
+		State:          group.State,
+		TargetProfiles: make([]ProfileDetail, 0, len(profiles)),
+	}
+	for _, p := range profiles {
+		resp.TargetProfiles = append(resp.TargetProfiles, ProfileDetail{
+			State:  p.State,
+			Error:  p.Error,
+			Type:   p.ProfileType,
+			Target: Target{Component: p.Component, Address: p.Address},
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// findBuiltinProfiles finds the successful profiles matching the query, which is rewritten from the action token.
+func (s *Service) findBuiltinProfiles(c *gin.Context) ([]ProfileModel, error) {
+	// Use the rewritten query instead of the cached one in the gin context.
+	q := c.Request.URL.Query()
+	ts, err := strconv.ParseInt(q.Get("ts"), 10, 64)
+	if err != nil {
+		return nil, rest.ErrBadRequest.New("ts is required")
+	}
+	db := s.params.LocalStore.Where("ts = ? AND state = ?", ts, profileStateSuccess)
+	if v := q.Get("profile_type"); v != "" {
+		db = db.Where("profile_type = ?", v)
+	}
+	if v := q.Get("component"); v != "" {
+		db = db.Where("component = ?", v)
+	}
+	if v := q.Get("address"); v != "" {
+		db = db.Where("address = ?", v)
+	}
+	var profiles []ProfileModel
+	if err := db.Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func profileFileName(p *ProfileModel) string {
+	ext := "proto"
+	switch p.RawDataType {
+	case profiling.RawDataTypeJeprof:
+		ext = "prof"
+	case profiling.RawDataTypeText:
+		ext = "txt"
+	}
+	address := strings.NewReplacer(":", "_").Replace(p.Address)
+	return fmt.Sprintf("%s_%s_%s_%d.%s", p.ProfileType, p.Component, address, p.Ts, ext)
+}
+
+func (s *Service) downloadBuiltinProfiles(c *gin.Context) {
+	profiles, err := s.findBuiltinProfiles(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("profile_%s.zip", time.Now().Format("2006-01-02_15-04-05"))
+	c.Writer.Header().Set("Content-type", "application/octet-stream")
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	zw := zip.NewWriter(c.Writer)
+	defer func() {
+		_ = zw.Close()
+	}()
+	for i := range profiles {
+		data, err := decompress(profiles[i].Data)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     profileFileName(&profiles[i]),
+			Method:   zip.Deflate,
+			Modified: time.Unix(profiles[i].Ts, 0),
+		})
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		if _, err := f.Write(data); err != nil {
+			rest.Error(c, err)
+			return
+		}
+	}
+}
+
+func (s *Service) viewBuiltinProfile(c *gin.Context) {
+	profiles, err := s.findBuiltinProfiles(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if len(profiles) != 1 {
+		rest.Error(c, rest.ErrBadRequest.New("expect 1 profile, got %d", len(profiles)))
+		return
+	}
+	p := profiles[0]
+	data, err := decompress(p.Data)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	var outputType profiling.ViewOutputType
+	switch dataFormat := c.Request.URL.Query().Get("data_format"); dataFormat {
+	case "svg":
+		outputType = profiling.ViewOutputTypeGraph
+	case "text":
+		outputType = profiling.ViewOutputTypeText
+	case "protobuf", "jeprof":
+		outputType = profiling.ViewOutputTypeProtobuf
+		if p.RawDataType == profiling.RawDataTypeJeprof {
+			c.Data(http.StatusOK, "application/octet-stream", data)
+			return
+		}
+	default:
+		rest.Error(c, rest.ErrBadRequest.New("unsupported data_format %s", dataFormat))
+		return
+	}
+
+	task := profiling.TaskModel{RawDataType: p.RawDataType}
+	if p.RawDataType == profiling.RawDataTypeJeprof {
+		// jeprof reads the profile from a file
+		f, err := os.CreateTemp("", "conprof_*.prof")
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		defer func() {
+			_ = os.Remove(f.Name())
+		}()
+		_, err = f.Write(data)
+		_ = f.Close()
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		task.FilePath = f.Name()
+	}
+	content, contentType, err := profiling.ConvertProfile(task, data, outputType)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, content)
+}
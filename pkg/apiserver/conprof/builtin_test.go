@@ -0,0 +1,78 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package conprof
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+func newTestService(t *testing.T) *Service {
+	gormDB, err := gorm.Open(sqlite.Open(path.Join(t.TempDir(), "test.sqlite.db")), &gorm.Config{})
+	require.NoError(t, err)
+	db := &dbstore.DB{DB: gormDB}
+	require.NoError(t, autoMigrate(db))
+	return &Service{params: ServiceParams{LocalStore: db}}
+}
+
+func serve(handler gin.HandlerFunc, target string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	handler(c)
+	return w
+}
+
+func TestBuiltinGroupProfiles(t *testing.T) {
+	s := newTestService(t)
+	db := s.params.LocalStore
+
+	data, err := compress([]byte("goroutine 1 [running]:"))
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&GroupModel{Ts: 100, ProfileSecs: 10, State: groupStatePartialFailed}).Error)
+	require.NoError(t, db.Create(&GroupModel{Ts: 200, ProfileSecs: 10, State: groupStateRunning}).Error)
+	for _, p := range []ProfileModel{
+		{Ts: 100, ProfileType: "profile", Component: "tidb", Address: "127.0.0.1:10080", State: profileStateSuccess},
+		{Ts: 100, ProfileType: "goroutine", Component: "tidb", Address: "127.0.0.1:10080", State: profileStateSuccess, RawDataType: profiling.RawDataTypeText, Data: data},
+		{Ts: 100, ProfileType: "profile", Component: "tikv", Address: "127.0.0.1:20180", State: profileStateFailed, Error: "timeout"},
+		{Ts: 100, ProfileType: "profile", Component: "tso", Address: "127.0.0.1:3379", State: profileStateSuccess},
+	} {
+		require.NoError(t, db.Create(&p).Error)
+	}
+
+	w := serve(s.getBuiltinGroupProfiles, "/?begin_time=0&end_time=150")
+	require.Equal(t, http.StatusOK, w.Code)
+	var groups []GroupProfiles
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &groups))
+	require.Equal(t, []GroupProfiles{
+		{Ts: 100, ProfileSecs: 10, State: groupStatePartialFailed, CompNum: ComponentNum{TiDB: 1, TiKV: 1, TSO: 1}},
+	}, groups)
+
+	w = serve(s.getBuiltinGroupProfileDetail, "/?ts=100")
+	require.Equal(t, http.StatusOK, w.Code)
+	var detail GroupProfileDetail
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	require.Len(t, detail.TargetProfiles, 4)
+	require.Equal(t, "timeout", detail.TargetProfiles[2].Error)
+
+	w = serve(s.viewBuiltinProfile, "/?ts=100&profile_type=goroutine&component=tidb&address=127.0.0.1:10080&data_format=text")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "goroutine 1 [running]:", w.Body.String())
+
+	s.removeExpiredProfiles(0)
+	var count int64
+	require.NoError(t, db.Model(&ProfileModel{}).Count(&count).Error)
+	require.Zero(t, count)
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package conprof
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+// The states are the same as NgMonitoring.
+const (
+	groupStateRunning       = "running"
+	groupStateFinished      = "finished"
+	groupStatePartialFailed = "partial failed"
+	groupStateFailed        = "failed"
+
+	profileStateSuccess = "success"
+	profileStateFailed  = "failed"
+)
+
+// GroupModel is a round of profiling on all components collected by the built-in continuous profiler.
+type GroupModel struct {
+	Ts          int64 `gorm:"primary_key;autoIncrement:false"`
+	ProfileSecs int
+	State       string `gorm:"size:16"`
+}
+
+func (GroupModel) TableName() string {
+	return "conprof_groups"
+}
+
+// ProfileModel is a single profile collected by the built-in continuous profiler.
+type ProfileModel struct {
+	ID          uint                      `gorm:"primary_key"`
+	Ts          int64                     `gorm:"index"`
+	ProfileType string                    `gorm:"size:16"`
+	Component   string                    `gorm:"size:16"`
+	Address     string                    `gorm:"size:64"`
+	State       string                    `gorm:"size:16"`
+	Error       string                    `gorm:"type:text"`
+	RawDataType profiling.TaskRawDataType `gorm:"size:16"`
+	Data        []byte                    // gzip compressed raw data
+}
+
+func (ProfileModel) TableName() string {
+	return "conprof_profiles"
+}
+
+func autoMigrate(db *dbstore.DB) error {
+	return db.AutoMigrate(&GroupModel{}, &ProfileModel{})
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	return io.ReadAll(r)
+}
@@ -6,14 +6,18 @@ package conprof
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/fx"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/profiling"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/user"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/util/featureflag"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
@@ -21,10 +25,14 @@ import (
 type ServiceParams struct {
 	fx.In
 
-	EtcdClient   *clientv3.Client
-	Config       *config.Config
-	NgmProxy     *utils.NgmProxy
-	FeatureFlags *featureflag.Registry
+	EtcdClient    *clientv3.Client
+	PDClient      *pd.Client
+	Config        *config.Config
+	ConfigManager *config.DynamicConfigManager
+	LocalStore    *dbstore.DB
+	NgmProxy      *utils.NgmProxy
+	FeatureFlags  *featureflag.Registry
+	Profiling     *profiling.Service
 }
 
 type Service struct {
@@ -32,9 +40,13 @@ type Service struct {
 
 	params       ServiceParams
 	lifecycleCtx context.Context
+	wg           sync.WaitGroup
 }
 
-func newService(lc fx.Lifecycle, p ServiceParams) *Service {
+func newService(lc fx.Lifecycle, p ServiceParams) (*Service, error) {
+	if err := autoMigrate(p.LocalStore); err != nil {
+		return nil, err
+	}
 	s := &Service{
 		FeatureFlagConprof: p.FeatureFlags.Register("conprof", ">= 5.3.0"),
 		params:             p,
@@ -43,11 +55,37 @@ func newService(lc fx.Lifecycle, p ServiceParams) *Service {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			s.lifecycleCtx = ctx
+			// The built-in continuous profiler works when NgMonitoring is not deployed.
+			s.wg.Go(func() {
+				s.builtinLoop(ctx)
+			})
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			s.wg.Wait()
 			return nil
 		},
 	})
 
-	return s
+	return s, nil
+}
+
+// route proxies the request to NgMonitoring if it is deployed, otherwise handles it by the built-in continuous profiler.
+// The request fails if whether NgMonitoring is deployed can not be told, instead of falling back to the built-in one.
+func (s *Service) route(targetPath string, builtin gin.HandlerFunc) gin.HandlerFunc {
+	proxy := s.params.NgmProxy.Route(targetPath)
+	return func(c *gin.Context) {
+		deployed, err := s.params.NgmProxy.IsDeployed()
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		if deployed {
+			proxy(c)
+			return
+		}
+		builtin(c)
+	}
 }
 
 // Register register the handlers to the service.
@@ -56,26 +94,22 @@ func registerRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 
 	endpoint.Use(s.FeatureFlagConprof.VersionGuard())
 	{
-		endpoint.GET("/config", auth.MWAuthRequired(), s.params.NgmProxy.Route("/config"))
-		endpoint.POST("/config", auth.MWAuthRequired(), auth.MWRequireWritePriv(), s.params.NgmProxy.Route("/config"))
-		endpoint.GET("/components", auth.MWAuthRequired(), s.params.NgmProxy.Route("/continuous_profiling/components"))
-		endpoint.GET("/estimate_size", auth.MWAuthRequired(), s.params.NgmProxy.Route("/continuous_profiling/estimate_size"))
-		endpoint.GET("/group_profiles", auth.MWAuthRequired(), s.params.NgmProxy.Route("/continuous_profiling/group_profiles"))
-		endpoint.GET("/group_profile/detail", auth.MWAuthRequired(), s.params.NgmProxy.Route("/continuous_profiling/group_profile/detail"))
+		endpoint.GET("/config", auth.MWAuthRequired(), s.route("/config", s.getBuiltinConfig))
+		endpoint.POST("/config", auth.MWAuthRequired(), auth.MWRequireWritePriv(), s.route("/config", s.updateBuiltinConfig))
+		endpoint.GET("/components", auth.MWAuthRequired(), s.route("/continuous_profiling/components", s.getBuiltinComponents))
+		endpoint.GET("/estimate_size", auth.MWAuthRequired(), s.route("/continuous_profiling/estimate_size", s.estimateBuiltinSize))
+		endpoint.GET("/group_profiles", auth.MWAuthRequired(), s.route("/continuous_profiling/group_profiles", s.getBuiltinGroupProfiles))
+		endpoint.GET("/group_profile/detail", auth.MWAuthRequired(), s.route("/continuous_profiling/group_profile/detail", s.getBuiltinGroupProfileDetail))
+
+		endpoint.GET("/aggregate", auth.MWAuthRequired(), s.aggregateProfiles)
 
 		endpoint.GET("/action_token", auth.MWAuthRequired(), s.GenConprofActionToken)
-		endpoint.GET("/download", s.parseJWTToken, s.params.NgmProxy.Route("/continuous_profiling/download"))
-		endpoint.GET("/single_profile/view", s.parseJWTToken, s.params.NgmProxy.Route("/continuous_profiling/single_profile/view"))
+		endpoint.GET("/download", s.parseJWTToken, s.route("/continuous_profiling/download", s.downloadBuiltinProfiles))
+		endpoint.GET("/single_profile/view", s.parseJWTToken, s.route("/continuous_profiling/single_profile/view", s.viewBuiltinProfile))
 	}
 }
 
-type ContinuousProfilingConfig struct {
-	Enable               bool `json:"enable"`
-	ProfileSeconds       int  `json:"profile_seconds"`
-	IntervalSeconds      int  `json:"interval_seconds"`
-	TimeoutSeconds       int  `json:"timeout_seconds"`
-	DataRetentionSeconds int  `json:"data_retention_seconds"`
-}
+type ContinuousProfilingConfig = config.ContinuousProfilingConfig
 
 type NgMonitoringConfig struct {
 	ContinuousProfiling ContinuousProfilingConfig `json:"continuous_profiling"`
@@ -140,11 +174,14 @@ type GetGroupProfileReq struct {
 }
 
 type ComponentNum struct {
-	TiDB    int `json:"tidb"`
-	PD      int `json:"pd"`
-	TiKV    int `json:"tikv"`
-	TiFlash int `json:"tiflash"`
-	TiCDC   int `json:"ticdc"`
+	TiDB       int `json:"tidb"`
+	PD         int `json:"pd"`
+	TiKV       int `json:"tikv"`
+	TiFlash    int `json:"tiflash"`
+	TiCDC      int `json:"ticdc"`
+	TiProxy    int `json:"tiproxy"`
+	TSO        int `json:"tso"`
+	Scheduling int `json:"scheduling"`
 }
 
 type GroupProfiles struct {
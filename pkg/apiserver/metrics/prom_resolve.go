@@ -145,6 +145,12 @@ func (s *Service) getPromAddressFromCache() (string, error) {
 	return resolveResult.(string), nil
 }
 
+// ResolvePromAddress returns the Prometheus address with its scheme, like `https://host:port`. The customized
+// address is preferred over the deployed one. If Prometheus is not available, empty address will be returned.
+func (s *Service) ResolvePromAddress() (string, error) {
+	return s.getPromAddressFromCache()
+}
+
 // Set the customized Prometheus address. Address can be empty or a valid address like `http://host:port`.
 // If address is set to empty, address from deployment tools will be used later.
 func (s *Service) setCustomPromAddress(addr string) (string, error) {
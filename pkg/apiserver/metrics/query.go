@@ -0,0 +1,101 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// sendPromRequest sends a GET request to the Prometheus API and returns the response body and content type.
+func (s *Service) sendPromRequest(ctx context.Context, path string, params url.Values) ([]byte, string, error) {
+	addr, err := s.getPromAddressFromCache()
+	if err != nil {
+		return nil, "", ErrLoadPrometheusAddressFailed.Wrap(err, "Load prometheus address failed")
+	}
+	if addr == "" {
+		return nil, "", ErrPrometheusNotFound.New("Prometheus is not deployed in the cluster")
+	}
+
+	uri := fmt.Sprintf("%s%s?%s", addr, path, params.Encode())
+	promReq, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, "", ErrPrometheusQueryFailed.Wrap(err, "failed to build Prometheus request")
+	}
+
+	promResp, err := s.params.HTTPClient.WithTimeout(defaultPromQueryTimeout).Do(promReq)
+	if err != nil {
+		return nil, "", ErrPrometheusQueryFailed.Wrap(err, "failed to send requests to Prometheus")
+	}
+
+	defer promResp.Body.Close()
+	if promResp.StatusCode != http.StatusOK {
+		return nil, "", ErrPrometheusQueryFailed.New("failed to query Prometheus")
+	}
+
+	body, err := io.ReadAll(promResp.Body)
+	if err != nil {
+		return nil, "", ErrPrometheusQueryFailed.Wrap(err, "failed to read Prometheus query result")
+	}
+	return body, promResp.Header.Get("content-type"), nil
+}
+
+// VectorSample is a single sample of an instant vector.
+type VectorSample struct {
+	Metric map[string]string
+	Value  float64
+}
+
+type instantQueryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryInstant evaluates a PromQL expression at the current time. The expression must return an instant vector.
+func (s *Service) QueryInstant(ctx context.Context, query string) ([]VectorSample, error) {
+	params := url.Values{}
+	params.Add("query", query)
+	body, _, err := s.sendPromRequest(ctx, "/api/v1/query", params)
+	if err != nil {
+		return nil, err
+	}
+	return parseInstantQueryResponse(body)
+}
+
+func parseInstantQueryResponse(body []byte) ([]VectorSample, error) {
+	var resp instantQueryResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, ErrPrometheusQueryFailed.Wrap(err, "failed to parse Prometheus query result")
+	}
+	if resp.Status != "success" {
+		return nil, ErrPrometheusQueryFailed.New("Prometheus query status is %s", resp.Status)
+	}
+	if resp.Data.ResultType != "vector" {
+		return nil, ErrPrometheusQueryFailed.New("expect vector result, got %s", resp.Data.ResultType)
+	}
+
+	samples := make([]VectorSample, 0, len(resp.Data.Result))
+	for _, r := range resp.Data.Result {
+		str, ok := r.Value[1].(string)
+		if !ok {
+			return nil, ErrPrometheusQueryFailed.New("invalid sample value %v", r.Value[1])
+		}
+		v, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, ErrPrometheusQueryFailed.Wrap(err, "invalid sample value %s", str)
+		}
+		samples = append(samples, VectorSample{Metric: r.Metric, Value: v})
+	}
+	return samples, nil
+}
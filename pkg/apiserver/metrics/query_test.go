@@ -0,0 +1,26 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseInstantQueryResponse(t *testing.T) {
+	samples, err := parseInstantQueryResponse([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"instance":"127.0.0.1:10080"},"value":[1700000000.123,"0.85"]},
+		{"metric":{"instance":"127.0.0.2:10080"},"value":[1700000000.123,"12"]}]}}`))
+	require.NoError(t, err)
+	require.Equal(t, []VectorSample{
+		{Metric: map[string]string{"instance": "127.0.0.1:10080"}, Value: 0.85},
+		{Metric: map[string]string{"instance": "127.0.0.2:10080"}, Value: 12},
+	}, samples)
+
+	_, err = parseInstantQueryResponse([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	require.Error(t, err)
+
+	_, err = parseInstantQueryResponse([]byte(`{"status":"error"}`))
+	require.Error(t, err)
+}
@@ -3,8 +3,6 @@
 package metrics
 
 import (
-	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -49,48 +47,19 @@ func (s *Service) queryMetrics(c *gin.Context) {
 		return
 	}
 
-	addr, err := s.getPromAddressFromCache()
-	if err != nil {
-		rest.Error(c, ErrLoadPrometheusAddressFailed.Wrap(err, "Load prometheus address failed"))
-		return
-	}
-	if addr == "" {
-		rest.Error(c, ErrPrometheusNotFound.New("Prometheus is not deployed in the cluster"))
-		return
-	}
-
 	params := url.Values{}
 	params.Add("query", req.Query)
 	params.Add("start", strconv.Itoa(req.StartTimeSec))
 	params.Add("end", strconv.Itoa(req.EndTimeSec))
 	params.Add("step", strconv.Itoa(req.StepSec))
 
-	uri := fmt.Sprintf("%s/api/v1/query_range?%s", addr, params.Encode())
-	promReq, err := http.NewRequestWithContext(s.lifecycleCtx, http.MethodGet, uri, nil)
-	if err != nil {
-		rest.Error(c, ErrPrometheusQueryFailed.Wrap(err, "failed to build Prometheus request"))
-		return
-	}
-
-	promResp, err := s.params.HTTPClient.WithTimeout(defaultPromQueryTimeout).Do(promReq)
+	body, contentType, err := s.sendPromRequest(s.lifecycleCtx, "/api/v1/query_range", params)
 	if err != nil {
-		rest.Error(c, ErrPrometheusQueryFailed.Wrap(err, "failed to send requests to Prometheus"))
-		return
-	}
-
-	defer promResp.Body.Close()
-	if promResp.StatusCode != http.StatusOK {
-		rest.Error(c, ErrPrometheusQueryFailed.New("failed to query Prometheus"))
-		return
-	}
-
-	body, err := io.ReadAll(promResp.Body)
-	if err != nil {
-		rest.Error(c, ErrPrometheusQueryFailed.Wrap(err, "failed to read Prometheus query result"))
+		rest.Error(c, err)
 		return
 	}
 
-	c.Data(promResp.StatusCode, promResp.Header.Get("content-type"), body)
+	c.Data(http.StatusOK, contentType, body)
 }
 
 type GetPromAddressConfigResponse struct {
@@ -0,0 +1,251 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const binaryDirName = "profiling_binaries"
+
+// BinaryModel is an uploaded executable or debuginfo file, which is used to symbolize jemalloc heap profiles.
+type BinaryModel struct {
+	ID         uint           `json:"id" gorm:"primary_key"`
+	Name       string         `json:"name" gorm:"type:text"`
+	Kind       model.NodeKind `json:"kind" gorm:"size:8;index"` // The component which the binary belongs to
+	BuildID    string         `json:"build_id" gorm:"size:64"`
+	Size       int64          `json:"size"`
+	HasDebug   bool           `json:"has_debug"` // Whether source lines can be resolved with DWARF debug info
+	FilePath   string         `json:"-" gorm:"type:text"`
+	UploadedAt int64          `json:"uploaded_at"`
+}
+
+func (BinaryModel) TableName() string {
+	return "profiling_binaries"
+}
+
+// maxCachedSymbolizers and maxCachedSymbolizerBytes limit the memory used by the symbolizers, since the debug info of
+// a binary can be large. A binary larger than maxCachedSymbolizerBytes is loaded for each use without being cached.
+const (
+	maxCachedSymbolizers     = 2
+	maxCachedSymbolizerBytes = 1 << 30
+)
+
+type cachedSymbolizer struct {
+	symbolizer *elfSymbolizer
+	size       int64
+}
+
+// symbolizerCache keeps the symbolizers of the recently used binaries, so that a binary is not loaded again for
+// each view of a profile.
+type symbolizerCache struct {
+	mu          sync.Mutex
+	symbolizers map[uint]cachedSymbolizer
+	recent      []uint // from the least recently used
+	bytes       int64  // the total size of the cached binaries
+}
+
+func (c *symbolizerCache) get(binary *BinaryModel) (*elfSymbolizer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.symbolizers[binary.ID]; ok {
+		c.touch(binary.ID)
+		return cached.symbolizer, nil
+	}
+	symbolizer, err := newELFSymbolizer(binary.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if binary.Size > maxCachedSymbolizerBytes {
+		return symbolizer, nil
+	}
+	if c.symbolizers == nil {
+		c.symbolizers = make(map[uint]cachedSymbolizer)
+	}
+	for len(c.recent) >= maxCachedSymbolizers || c.bytes+binary.Size > maxCachedSymbolizerBytes {
+		c.evict(c.recent[0])
+	}
+	c.symbolizers[binary.ID] = cachedSymbolizer{symbolizer: symbolizer, size: binary.Size}
+	c.recent = append(c.recent, binary.ID)
+	c.bytes += binary.Size
+	return symbolizer, nil
+}
+
+func (c *symbolizerCache) touch(id uint) {
+	for i, v := range c.recent {
+		if v == id {
+			c.recent = append(append(c.recent[:i:i], c.recent[i+1:]...), id)
+			return
+		}
+	}
+}
+
+func (c *symbolizerCache) evict(id uint) {
+	cached, ok := c.symbolizers[id]
+	if !ok {
+		return
+	}
+	delete(c.symbolizers, id)
+	c.bytes -= cached.size
+	for i, v := range c.recent {
+		if v == id {
+			c.recent = append(c.recent[:i:i], c.recent[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *symbolizerCache) remove(id uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict(id)
+}
+
+// loadSymbolizer loads the binary with the given ID, or the latest uploaded binary of the component
+// of the task if the ID is empty. It returns nil if there is no binary to symbolize the task.
+func (s *Service) loadSymbolizer(task TaskModel, binaryID string) (*elfSymbolizer, error) {
+	if task.RawDataType != RawDataTypeJeprof {
+		return nil, nil
+	}
+	var binaries []BinaryModel
+	db := s.params.LocalStore.DB
+	if binaryID != "" {
+		id, err := strconv.Atoi(binaryID)
+		if err != nil {
+			return nil, rest.ErrBadRequest.New("Invalid binary ID %s", binaryID)
+		}
+		db = db.Where("id = ?", id)
+	} else {
+		db = db.Where("kind = ?", task.Target.Kind)
+	}
+	if err := db.Order("id DESC").Limit(1).Find(&binaries).Error; err != nil {
+		return nil, err
+	}
+	if len(binaries) == 0 {
+		if binaryID != "" {
+			return nil, rest.ErrBadRequest.New("Binary %s does not exist", binaryID)
+		}
+		return nil, nil
+	}
+	if binaries[0].Kind != task.Target.Kind {
+		return nil, rest.ErrBadRequest.New("Binary %s belongs to %s, but the profile is collected from %s", binaryID, binaries[0].Kind, task.Target.Kind)
+	}
+	return s.symbolizers.get(&binaries[0])
+}
+
+// @ID uploadProfilingBinary
+// @Summary Upload a binary to symbolize heap profiles
+// @Description Upload the executable or debuginfo file of TiKV or TiFlash, which is used to resolve function names and source lines of jemalloc heap profiles.
+// @Accept multipart/form-data
+// @Param file formData file true "ELF executable or debuginfo file"
+// @Param kind formData string true "component kind" Enums(tikv, tiflash)
+// @Security JwtAuth
+// @Success 200 {object} BinaryModel
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/binary/upload [post]
+func (s *Service) uploadBinary(c *gin.Context) {
+	kind := model.NodeKind(c.PostForm("kind"))
+	if kind != model.NodeKindTiKV && kind != model.NodeKindTiFlash {
+		rest.Error(c, rest.ErrBadRequest.New("Binaries are only used by tikv and tiflash"))
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("File is required"))
+		return
+	}
+
+	dir := path.Join(s.params.Config.DataDir, binaryDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	filePath := path.Join(dir, fmt.Sprintf("%s_%d", kind, time.Now().UnixNano()))
+	if err := c.SaveUploadedFile(fileHeader, filePath); err != nil {
+		rest.Error(c, err)
+		return
+	}
+	symbolizer, err := newELFSymbolizer(filePath)
+	if err != nil {
+		_ = os.Remove(filePath)
+		rest.Error(c, rest.ErrBadRequest.New("Invalid ELF file: %v", err))
+		return
+	}
+
+	binary := BinaryModel{
+		Name:       path.Base(fileHeader.Filename),
+		Kind:       kind,
+		BuildID:    symbolizer.buildID,
+		Size:       fileHeader.Size,
+		HasDebug:   symbolizer.dwarf != nil,
+		FilePath:   filePath,
+		UploadedAt: time.Now().Unix(),
+	}
+	if err := s.params.LocalStore.Create(&binary).Error; err != nil {
+		_ = os.Remove(filePath)
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, binary)
+}
+
+// @ID getProfilingBinaryList
+// @Summary List uploaded binaries
+// @Security JwtAuth
+// @Success 200 {array} BinaryModel
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/binary/list [get]
+func (s *Service) getBinaryList(c *gin.Context) {
+	var binaries []BinaryModel
+	if err := s.params.LocalStore.Order("id DESC").Find(&binaries).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, binaries)
+}
+
+// @ID deleteProfilingBinary
+// @Summary Delete an uploaded binary
+// @Param binaryId path string true "binary ID"
+// @Security JwtAuth
+// @Success 200 {object} rest.EmptyResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/binary/delete/{binaryId} [delete]
+func (s *Service) deleteBinary(c *gin.Context) {
+	binaryID, err := strconv.Atoi(c.Param("binaryId"))
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	var binary BinaryModel
+	if err := s.params.LocalStore.Where("id = ?", binaryID).First(&binary).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if err := os.Remove(binary.FilePath); err != nil && !os.IsNotExist(err) {
+		rest.Error(c, err)
+		return
+	}
+	if err := s.params.LocalStore.Delete(&binary).Error; err != nil {
+		rest.Error(c, err)
+		return
+	}
+	s.symbolizers.remove(binary.ID)
+	c.JSON(http.StatusOK, rest.EmptyResponse{})
+}
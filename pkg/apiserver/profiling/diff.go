@@ -0,0 +1,266 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/pprof/profile"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+type DiffFunction struct {
+	Name       string `json:"name"`
+	BaseFlat   int64  `json:"base_flat"`
+	TargetFlat int64  `json:"target_flat"`
+	DeltaFlat  int64  `json:"delta_flat"`
+	BaseCum    int64  `json:"base_cum"`
+	TargetCum  int64  `json:"target_cum"`
+	DeltaCum   int64  `json:"delta_cum"`
+}
+
+type DiffTopResponse struct {
+	SampleType  string         `json:"sample_type"`
+	Unit        string         `json:"unit"`
+	BaseTotal   int64          `json:"base_total"`
+	TargetTotal int64          `json:"target_total"`
+	Functions   []DiffFunction `json:"functions"`
+}
+
+// diffProfiles subtracts the base profile from the target profile, in the same way as `pprof -diff_base`.
+// Samples from the base profile are negated and labeled, so that pprof renders the result as a diff.
+func diffProfiles(baseContent, targetContent []byte) (*profile.Profile, error) {
+	base, err := profile.ParseData(baseContent)
+	if err != nil {
+		return nil, ErrInvalidProfile.Wrap(err, "failed to parse base profile")
+	}
+	target, err := profile.ParseData(targetContent)
+	if err != nil {
+		return nil, ErrInvalidProfile.Wrap(err, "failed to parse target profile")
+	}
+	return DiffProfiles(base, target)
+}
+
+// DiffProfiles subtracts the parsed base profile from the parsed target profile. The base profile is modified.
+func DiffProfiles(base, target *profile.Profile) (*profile.Profile, error) {
+	base.SetLabel("pprof::base", []string{"true"})
+	base.Scale(-1)
+	diff, err := profile.Merge([]*profile.Profile{target, base})
+	if err != nil {
+		return nil, ErrIncomparableProfiles.Wrap(err, "profiles are not comparable")
+	}
+	return diff, nil
+}
+
+// defaultSampleIndex returns the sample value used by pprof when no sample index is specified.
+func defaultSampleIndex(p *profile.Profile) int {
+	for i, st := range p.SampleType {
+		if st.Type == p.DefaultSampleType {
+			return i
+		}
+	}
+	return len(p.SampleType) - 1
+}
+
+// TopDiffFunctions returns the n functions whose flat values change the most in the diff profile.
+func TopDiffFunctions(diff *profile.Profile, n int) DiffTopResponse {
+	resp := DiffTopResponse{Functions: []DiffFunction{}}
+	if len(diff.SampleType) == 0 {
+		return resp
+	}
+	idx := defaultSampleIndex(diff)
+	resp.SampleType = diff.SampleType[idx].Type
+	resp.Unit = diff.SampleType[idx].Unit
+
+	functions := make(map[string]*DiffFunction)
+	getFunction := func(name string) *DiffFunction {
+		f, ok := functions[name]
+		if !ok {
+			f = &DiffFunction{Name: name}
+			functions[name] = f
+		}
+		return f
+	}
+
+	for _, s := range diff.Sample {
+		isBase := s.DiffBaseSample()
+		v := s.Value[idx]
+		if isBase {
+			v = -v
+			resp.BaseTotal += v
+		} else {
+			resp.TargetTotal += v
+		}
+
+		seen := make(map[string]struct{})
+		for i, loc := range s.Location {
+			// Lines are ordered from the innermost inlined function to the caller.
+			for j, line := range loc.Line {
+				name := "unknown"
+				if line.Function != nil {
+					name = line.Function.Name
+				}
+				f := getFunction(name)
+				if i == 0 && j == 0 {
+					if isBase {
+						f.BaseFlat += v
+					} else {
+						f.TargetFlat += v
+					}
+				}
+				if _, ok := seen[name]; ok {
+					continue
+				}
+				seen[name] = struct{}{}
+				if isBase {
+					f.BaseCum += v
+				} else {
+					f.TargetCum += v
+				}
+			}
+		}
+	}
+
+	for _, f := range functions {
+		f.DeltaFlat = f.TargetFlat - f.BaseFlat
+		f.DeltaCum = f.TargetCum - f.BaseCum
+		if f.DeltaFlat == 0 && f.DeltaCum == 0 {
+			continue
+		}
+		resp.Functions = append(resp.Functions, *f)
+	}
+	sort.Slice(resp.Functions, func(i, j int) bool {
+		a, b := resp.Functions[i], resp.Functions[j]
+		if abs64(a.DeltaFlat) != abs64(b.DeltaFlat) {
+			return abs64(a.DeltaFlat) > abs64(b.DeltaFlat)
+		}
+		if abs64(a.DeltaCum) != abs64(b.DeltaCum) {
+			return abs64(a.DeltaCum) > abs64(b.DeltaCum)
+		}
+		return a.Name < b.Name
+	})
+	if len(resp.Functions) > n {
+		resp.Functions = resp.Functions[:n]
+	}
+	return resp
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func (s *Service) loadDiffTask(id, binaryID string) (*TaskModel, []byte, error) {
+	taskID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, nil, rest.ErrBadRequest.New("Invalid task ID %s", id)
+	}
+	task := TaskModel{}
+	err = s.params.LocalStore.Where("id = ? AND state = ?", taskID, TaskStateFinish).First(&task).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, rest.ErrNotFound.New("Finished task %d is not found", taskID)
+		}
+		return nil, nil, err
+	}
+	if task.RawDataType != RawDataTypeProtobuf && task.RawDataType != RawDataTypeJeprof {
+		return nil, nil, ErrIncomparableProfiles.New("task %d is neither a protobuf nor a jemalloc heap profile", task.ID)
+	}
+	content, err := os.ReadFile(task.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if task.RawDataType == RawDataTypeJeprof {
+		symbolizer, err := s.loadSymbolizer(task, binaryID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if content, err = jemallocToProtobuf(task, content, symbolizer); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &task, content, nil
+}
+
+// @ID viewProfilingDiff
+// @Summary Compare the results of two tasks
+// @Description Subtract the base profile from the target profile. Both tasks must be finished protobuf or jemalloc heap profiles of the same component and profiling type.
+// @Produce json
+// @Produce html
+// @Produce application/x-gzip
+// @Param base query string true "base task ID"
+// @Param target query string true "target task ID"
+// @Param output_type query string false "graph, top or protobuf" Enums(graph, top, protobuf)
+// @Param limit query int false "number of functions returned in top output"
+// @Param binary_id query string false "uploaded binary to symbolize jemalloc heap profiles"
+// @Security JwtAuth
+// @Success 200 {object} DiffTopResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/diff [get]
+func (s *Service) viewDiff(c *gin.Context) {
+	baseTask, baseContent, err := s.loadDiffTask(c.Query("base"), c.Query("binary_id"))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	targetTask, targetContent, err := s.loadDiffTask(c.Query("target"), c.Query("binary_id"))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	if baseTask.ProfilingType != targetTask.ProfilingType || baseTask.Target.Kind != targetTask.Target.Kind {
+		rest.Error(c, ErrIncomparableProfiles.New("cannot compare %s profile of %s with %s profile of %s",
+			baseTask.ProfilingType, baseTask.Target.Kind, targetTask.ProfilingType, targetTask.Target.Kind))
+		return
+	}
+
+	diff, err := diffProfiles(baseContent, targetContent)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	outputType := ViewOutputType(c.DefaultQuery("output_type", string(ViewOutputTypeTop)))
+	switch outputType {
+	case ViewOutputTypeTop:
+		limit, err := ParseTopLimit(c)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, TopDiffFunctions(diff, limit))
+	case ViewOutputTypeGraph, ViewOutputTypeProtobuf:
+		var buf bytes.Buffer
+		if err := diff.Write(&buf); err != nil {
+			rest.Error(c, err)
+			return
+		}
+		if outputType == ViewOutputTypeProtobuf {
+			fileName := fmt.Sprintf("%s_diff_%d_%d.pb.gz", targetTask.ProfilingType, baseTask.ID, targetTask.ID)
+			c.Writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+			c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+			return
+		}
+		svgContent, err := convertProtobufToSVG(buf.Bytes(), *targetTask)
+		if err != nil {
+			rest.Error(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", svgContent)
+	default:
+		rest.Error(c, rest.ErrBadRequest.New("Cannot output diff as %s", outputType))
+	}
+}
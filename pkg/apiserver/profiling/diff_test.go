@@ -0,0 +1,94 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/pingcap/check"
+)
+
+func TestT(t *testing.T) {
+	check.CustomVerboseFlag = true
+	check.TestingT(t)
+}
+
+var _ = check.Suite(&testDiffSuite{})
+
+type testDiffSuite struct{}
+
+// buildProfile builds a CPU profile whose samples are stacks of function names, from the leaf to the root.
+func buildProfile(c *check.C, samples map[string]int64, stacks map[string][]string) []byte {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     1,
+	}
+	functions := make(map[string]*profile.Function)
+	locations := make(map[string]*profile.Location)
+	getLocation := func(name string) *profile.Location {
+		if loc, ok := locations[name]; ok {
+			return loc
+		}
+		fn := &profile.Function{ID: uint64(len(functions) + 1), Name: name}
+		functions[name] = fn
+		p.Function = append(p.Function, fn)
+		loc := &profile.Location{ID: uint64(len(locations) + 1), Line: []profile.Line{{Function: fn}}}
+		locations[name] = loc
+		p.Location = append(p.Location, loc)
+		return loc
+	}
+	for key, v := range samples {
+		sample := &profile.Sample{Value: []int64{1, v}}
+		for _, name := range stacks[key] {
+			sample.Location = append(sample.Location, getLocation(name))
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+	var buf bytes.Buffer
+	c.Assert(p.Write(&buf), check.IsNil)
+	return buf.Bytes()
+}
+
+func (t *testDiffSuite) TestTopDiffFunctions(c *check.C) {
+	stacks := map[string][]string{
+		"a": {"a", "main"},
+		"b": {"b", "main"},
+		"c": {"c", "b", "main"},
+	}
+	base := buildProfile(c, map[string]int64{"a": 100, "b": 50}, stacks)
+	target := buildProfile(c, map[string]int64{"a": 100, "b": 20, "c": 200}, stacks)
+
+	diff, err := diffProfiles(base, target)
+	c.Assert(err, check.IsNil)
+	resp := TopDiffFunctions(diff, 10)
+	c.Assert(resp.SampleType, check.Equals, "cpu")
+	c.Assert(resp.BaseTotal, check.Equals, int64(150))
+	c.Assert(resp.TargetTotal, check.Equals, int64(320))
+	c.Assert(resp.Functions, check.DeepEquals, []DiffFunction{
+		{Name: "c", TargetFlat: 200, DeltaFlat: 200, TargetCum: 200, DeltaCum: 200},
+		{Name: "b", BaseFlat: 50, TargetFlat: 20, DeltaFlat: -30, BaseCum: 50, TargetCum: 220, DeltaCum: 170},
+		{Name: "main", BaseCum: 150, TargetCum: 320, DeltaCum: 170},
+	})
+
+	resp = TopDiffFunctions(diff, 1)
+	c.Assert(resp.Functions, check.HasLen, 1)
+	c.Assert(resp.Functions[0].Name, check.Equals, "c")
+}
+
+func (t *testDiffSuite) TestDiffIncomparableProfiles(c *check.C) {
+	base := buildProfile(c, map[string]int64{"a": 1}, map[string][]string{"a": {"a"}})
+	p, err := profile.ParseData(base)
+	c.Assert(err, check.IsNil)
+	p.SampleType = p.SampleType[:1]
+	for _, s := range p.Sample {
+		s.Value = s.Value[:1]
+	}
+	var buf bytes.Buffer
+	c.Assert(p.Write(&buf), check.IsNil)
+
+	_, err = diffProfiles(base, buf.Bytes())
+	c.Assert(err, check.NotNil)
+}
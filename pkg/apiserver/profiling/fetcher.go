@@ -3,12 +3,10 @@
 package profiling
 
 import (
-	_ "embed"
+	"context"
 	"fmt"
 	"io"
 	"net"
-	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +29,7 @@ const (
 )
 
 type fetchOptions struct {
+	ctx  context.Context
 	ip   string
 	port int
 	path string
@@ -95,50 +94,16 @@ type tikvFetcher struct {
 	client *tikv.Client
 }
 
-//go:embed jeprof.in
-var jeprof string
-
 func (f *tikvFetcher) fetch(op *fetchOptions) ([]byte, error) {
 	if strings.HasSuffix(op.path, "heap") {
-		scheme := f.client.GetHTTPScheme()
-		cmd := exec.Command("perl", "/dev/stdin", "--raw", scheme+"://"+op.ip+":"+strconv.Itoa(op.port)+op.path) //nolint:gosec
-		cmd.Stdin = strings.NewReader(jeprof)
-		if f.client.GetTLSInfo() != nil {
-			cmd.Env = append(os.Environ(), fmt.Sprintf(
-				"URL_FETCHER=curl -s --cert %s --key %s --cacert %s",
-				f.client.GetTLSInfo().CertFile,
-				f.client.GetTLSInfo().KeyFile,
-				f.client.GetTLSInfo().TrustedCAFile,
-			))
-		}
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return nil, err
-		}
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			return nil, err
-		}
-		// use jeprof to fetch tikv heap profile
-		err = cmd.Start()
-		if err != nil {
-			return nil, err
-		}
-		data, err := io.ReadAll(stdout)
-		if err != nil {
-			return nil, err
-		}
-		errMsg, err := io.ReadAll(stderr)
-		if err != nil {
-			return nil, err
-		}
-		err = cmd.Wait()
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch tikv heap profile: %s", errMsg)
-		}
-		return data, nil
+		client := f.client.WithContext(op.ctx).WithTimeout(maxProfilingTimeout)
+		return fetchJemallocHeap(op.path, func(path string) ([]byte, error) {
+			return client.SendGetRequest(op.ip, op.port, path)
+		}, func(path string, body io.Reader) ([]byte, error) {
+			return client.SendPostRequest(op.ip, op.port, path, body)
+		})
 	}
-	return f.client.WithTimeout(maxProfilingTimeout).AddRequestHeader("Content-Type", "application/protobuf").SendGetRequest(op.ip, op.port, op.path)
+	return f.client.WithContext(op.ctx).WithTimeout(maxProfilingTimeout).AddRequestHeader("Content-Type", "application/protobuf").SendGetRequest(op.ip, op.port, op.path)
 }
 
 type tiflashFetcher struct {
@@ -147,37 +112,14 @@ type tiflashFetcher struct {
 
 func (f *tiflashFetcher) fetch(op *fetchOptions) ([]byte, error) {
 	if strings.HasSuffix(op.path, "heap") {
-		scheme := f.client.GetHTTPScheme()
-		cmd := exec.Command("perl", "/dev/stdin", "--raw", scheme+"://"+op.ip+":"+strconv.Itoa(op.port)+op.path) //nolint:gosec
-		cmd.Stdin = strings.NewReader(jeprof)
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return nil, err
-		}
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			return nil, err
-		}
-		// use jeprof to fetch tiflash heap profile
-		err = cmd.Start()
-		if err != nil {
-			return nil, err
-		}
-		data, err := io.ReadAll(stdout)
-		if err != nil {
-			return nil, err
-		}
-		errMsg, err := io.ReadAll(stderr)
-		if err != nil {
-			return nil, err
-		}
-		err = cmd.Wait()
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch tiflash heap profile: %s", errMsg)
-		}
-		return data, nil
+		client := f.client.WithContext(op.ctx).WithTimeout(maxProfilingTimeout)
+		return fetchJemallocHeap(op.path, func(path string) ([]byte, error) {
+			return client.SendGetRequest(op.ip, op.port, path)
+		}, func(path string, body io.Reader) ([]byte, error) {
+			return client.SendPostRequest(op.ip, op.port, path, body)
+		})
 	}
-	return f.client.WithTimeout(maxProfilingTimeout).AddRequestHeader("Content-Type", "application/protobuf").SendGetRequest(op.ip, op.port, op.path)
+	return f.client.WithContext(op.ctx).WithTimeout(maxProfilingTimeout).AddRequestHeader("Content-Type", "application/protobuf").SendGetRequest(op.ip, op.port, op.path)
 }
 
 type tidbFetcher struct {
@@ -185,7 +127,7 @@ type tidbFetcher struct {
 }
 
 func (f *tidbFetcher) fetch(op *fetchOptions) ([]byte, error) {
-	return f.client.WithEnforcedStatusAPIAddress(op.ip, op.port).WithStatusAPITimeout(maxProfilingTimeout).SendGetRequest(op.path)
+	return f.client.WithContext(op.ctx).WithEnforcedStatusAPIAddress(op.ip, op.port).WithStatusAPITimeout(maxProfilingTimeout).SendGetRequest(op.path)
 }
 
 type pdFetcher struct {
@@ -196,6 +138,7 @@ type pdFetcher struct {
 func (f *pdFetcher) fetch(op *fetchOptions) ([]byte, error) {
 	baseURL := fmt.Sprintf("%s://%s", f.statusAPIHTTPScheme, net.JoinHostPort(op.ip, strconv.Itoa(op.port)))
 	return f.client.
+		WithContext(op.ctx).
 		WithTimeout(maxProfilingTimeout).
 		WithBaseURL(baseURL).
 		WithoutPrefix(). // pprof API does not have /pd/api/v1 prefix
@@ -207,7 +150,7 @@ type ticdcFecther struct {
 }
 
 func (f *ticdcFecther) fetch(op *fetchOptions) ([]byte, error) {
-	return f.client.WithTimeout(maxProfilingTimeout).SendGetRequest(op.ip, op.port, op.path)
+	return f.client.WithContext(op.ctx).WithTimeout(maxProfilingTimeout).SendGetRequest(op.ip, op.port, op.path)
 }
 
 type tiproxyFecther struct {
@@ -215,7 +158,7 @@ type tiproxyFecther struct {
 }
 
 func (f *tiproxyFecther) fetch(op *fetchOptions) ([]byte, error) {
-	return f.client.WithTimeout(maxProfilingTimeout).SendGetRequest(op.ip, op.port, op.path)
+	return f.client.WithContext(op.ctx).WithTimeout(maxProfilingTimeout).SendGetRequest(op.ip, op.port, op.path)
 }
 
 type tsoFetcher struct {
@@ -223,7 +166,7 @@ type tsoFetcher struct {
 }
 
 func (f *tsoFetcher) fetch(op *fetchOptions) ([]byte, error) {
-	return f.client.WithTimeout(maxProfilingTimeout).SendGetRequest(op.ip, op.port, op.path)
+	return f.client.WithContext(op.ctx).WithTimeout(maxProfilingTimeout).SendGetRequest(op.ip, op.port, op.path)
 }
 
 type schedulingFetcher struct {
@@ -231,5 +174,5 @@ type schedulingFetcher struct {
 }
 
 func (f *schedulingFetcher) fetch(op *fetchOptions) ([]byte, error) {
-	return f.client.WithTimeout(maxProfilingTimeout).SendGetRequest(op.ip, op.port, op.path)
+	return f.client.WithContext(op.ctx).WithTimeout(maxProfilingTimeout).SendGetRequest(op.ip, op.port, op.path)
 }
@@ -0,0 +1,265 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/pprof/profile"
+
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	ViewOutputTypeFolded     ViewOutputType = "folded"
+	ViewOutputTypeSpeedscope ViewOutputType = "speedscope"
+	ViewOutputTypeTop        ViewOutputType = "top"
+)
+
+const (
+	defaultTopN = 20
+	maxTopN     = 500
+)
+
+func ParseTopLimit(c *gin.Context) (int, error) {
+	l := c.Query("limit")
+	if l == "" {
+		return defaultTopN, nil
+	}
+	limit, err := strconv.Atoi(l)
+	if err != nil || limit <= 0 {
+		return 0, rest.ErrBadRequest.New("Invalid limit %s", l)
+	}
+	if limit > maxTopN {
+		limit = maxTopN
+	}
+	return limit, nil
+}
+
+// foldedStack is a call stack ordered from the root to the leaf, with the value of its samples.
+type foldedStack struct {
+	frames []string
+	value  int64
+}
+
+// foldedProfile is the profile in Brendan Gregg's collapsed stack format.
+type foldedProfile struct {
+	sampleType string
+	unit       string
+	stacks     []foldedStack
+}
+
+// foldProtobuf folds the protobuf profile with the sample value used by pprof by default.
+func foldProtobuf(content []byte) (*foldedProfile, error) {
+	p, err := profile.ParseData(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %v", err)
+	}
+	return foldParsedProfile(p), nil
+}
+
+func foldParsedProfile(p *profile.Profile) *foldedProfile {
+	fp := &foldedProfile{}
+	if len(p.SampleType) == 0 {
+		return fp
+	}
+	idx := defaultSampleIndex(p)
+	fp.sampleType = p.SampleType[idx].Type
+	fp.unit = p.SampleType[idx].Unit
+
+	stacks := make(map[string]int)
+	for _, s := range p.Sample {
+		v := s.Value[idx]
+		if v == 0 {
+			continue
+		}
+		var frames []string
+		for i := len(s.Location) - 1; i >= 0; i-- {
+			lines := s.Location[i].Line
+			// Lines are ordered from the innermost inlined function to the caller.
+			for j := len(lines) - 1; j >= 0; j-- {
+				name := "unknown"
+				if lines[j].Function != nil {
+					name = lines[j].Function.Name
+				}
+				frames = append(frames, name)
+			}
+		}
+		key := strings.Join(frames, ";")
+		if i, ok := stacks[key]; ok {
+			fp.stacks[i].value += v
+			continue
+		}
+		stacks[key] = len(fp.stacks)
+		fp.stacks = append(fp.stacks, foldedStack{frames: frames, value: v})
+	}
+	return fp
+}
+
+func (fp *foldedProfile) total() int64 {
+	var total int64
+	for _, s := range fp.stacks {
+		total += s.value
+	}
+	return total
+}
+
+// Folded outputs the profile in the collapsed stack format, which is accepted by most flame graph tools.
+func (fp *foldedProfile) Folded() []byte {
+	var buf bytes.Buffer
+	for _, s := range fp.stacks {
+		buf.WriteString(strings.Join(s.frames, ";"))
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatInt(s.value, 10))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+type SpeedscopeFrame struct {
+	Name string `json:"name"`
+}
+
+type SpeedscopeProfile struct {
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	Unit       string  `json:"unit"`
+	StartValue int64   `json:"startValue"`
+	EndValue   int64   `json:"endValue"`
+	Samples    [][]int `json:"samples"`
+	Weights    []int64 `json:"weights"`
+}
+
+// SpeedscopeFile is the file format of https://www.speedscope.app.
+type SpeedscopeFile struct {
+	Schema   string `json:"$schema"`
+	Name     string `json:"name"`
+	Exporter string `json:"exporter"`
+	Shared   struct {
+		Frames []SpeedscopeFrame `json:"frames"`
+	} `json:"shared"`
+	Profiles []SpeedscopeProfile `json:"profiles"`
+}
+
+func speedscopeUnit(unit string) string {
+	switch unit {
+	case "nanoseconds", "microseconds", "milliseconds", "seconds", "bytes":
+		return unit
+	default:
+		return "none"
+	}
+}
+
+// Speedscope outputs the profile as a sampled speedscope profile.
+func (fp *foldedProfile) Speedscope(name string) *SpeedscopeFile {
+	f := &SpeedscopeFile{
+		Schema:   "https://www.speedscope.app/file-format-schema.json",
+		Name:     name,
+		Exporter: "tidb-dashboard",
+	}
+	f.Shared.Frames = []SpeedscopeFrame{}
+	p := SpeedscopeProfile{
+		Type:    "sampled",
+		Name:    name,
+		Unit:    speedscopeUnit(fp.unit),
+		Samples: make([][]int, 0, len(fp.stacks)),
+		Weights: make([]int64, 0, len(fp.stacks)),
+	}
+	frameIndex := make(map[string]int)
+	for _, s := range fp.stacks {
+		sample := make([]int, 0, len(s.frames))
+		for _, frame := range s.frames {
+			idx, ok := frameIndex[frame]
+			if !ok {
+				idx = len(f.Shared.Frames)
+				frameIndex[frame] = idx
+				f.Shared.Frames = append(f.Shared.Frames, SpeedscopeFrame{Name: frame})
+			}
+			sample = append(sample, idx)
+		}
+		p.Samples = append(p.Samples, sample)
+		p.Weights = append(p.Weights, s.value)
+		p.EndValue += s.value
+	}
+	f.Profiles = []SpeedscopeProfile{p}
+	return f
+}
+
+type TopFunction struct {
+	Name string `json:"name"`
+	Flat int64  `json:"flat"`
+	Cum  int64  `json:"cum"`
+}
+
+type TopFunctionsResponse struct {
+	SampleType string        `json:"sample_type"`
+	Unit       string        `json:"unit"`
+	Total      int64         `json:"total"`
+	ByFlat     []TopFunction `json:"by_flat"`
+	ByCum      []TopFunction `json:"by_cum"`
+}
+
+// TopFunctions returns the top n functions of the parsed profile ordered by flat and cum values.
+func TopFunctions(p *profile.Profile, n int) TopFunctionsResponse {
+	return foldParsedProfile(p).Top(n)
+}
+
+// Top returns the top n functions ordered by flat and cum values.
+func (fp *foldedProfile) Top(n int) TopFunctionsResponse {
+	functions := make(map[string]*TopFunction)
+	for _, s := range fp.stacks {
+		seen := make(map[string]struct{}, len(s.frames))
+		for i, frame := range s.frames {
+			f, ok := functions[frame]
+			if !ok {
+				f = &TopFunction{Name: frame}
+				functions[frame] = f
+			}
+			if i == len(s.frames)-1 {
+				f.Flat += s.value
+			}
+			if _, ok := seen[frame]; !ok {
+				seen[frame] = struct{}{}
+				f.Cum += s.value
+			}
+		}
+	}
+
+	all := make([]TopFunction, 0, len(functions))
+	for _, f := range functions {
+		all = append(all, *f)
+	}
+	topBy := func(less func(a, b TopFunction) bool) []TopFunction {
+		sorted := make([]TopFunction, len(all))
+		copy(sorted, all)
+		sort.Slice(sorted, func(i, j int) bool {
+			return less(sorted[i], sorted[j])
+		})
+		if len(sorted) > n {
+			sorted = sorted[:n]
+		}
+		return sorted
+	}
+	return TopFunctionsResponse{
+		SampleType: fp.sampleType,
+		Unit:       fp.unit,
+		Total:      fp.total(),
+		ByFlat: topBy(func(a, b TopFunction) bool {
+			if a.Flat != b.Flat {
+				return a.Flat > b.Flat
+			}
+			return a.Name < b.Name
+		}),
+		ByCum: topBy(func(a, b TopFunction) bool {
+			if a.Cum != b.Cum {
+				return a.Cum > b.Cum
+			}
+			return a.Name < b.Name
+		}),
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"github.com/pingcap/check"
+)
+
+var _ = check.Suite(&testFlameGraphSuite{})
+
+type testFlameGraphSuite struct{}
+
+func (t *testFlameGraphSuite) TestFoldProtobuf(c *check.C) {
+	content := buildProfile(c, map[string]int64{"a": 100, "c": 30}, map[string][]string{
+		"a": {"a", "main"},
+		"c": {"c", "b", "main"},
+	})
+	fp, err := foldProtobuf(content)
+	c.Assert(err, check.IsNil)
+	c.Assert(fp.sampleType, check.Equals, "cpu")
+	c.Assert(fp.unit, check.Equals, "nanoseconds")
+
+	c.Assert(string(fp.Folded()), check.Equals, "main;a 100\nmain;b;c 30\n")
+	c.Assert(fp.total(), check.Equals, int64(130))
+
+	top := fp.Top(2)
+	c.Assert(top.ByFlat, check.DeepEquals, []TopFunction{
+		{Name: "a", Flat: 100, Cum: 100},
+		{Name: "c", Flat: 30, Cum: 30},
+	})
+	c.Assert(top.ByCum, check.DeepEquals, []TopFunction{
+		{Name: "main", Flat: 0, Cum: 130},
+		{Name: "a", Flat: 100, Cum: 100},
+	})
+
+	ss := fp.Speedscope("cpu")
+	c.Assert(ss.Profiles, check.HasLen, 1)
+	c.Assert(ss.Profiles[0].EndValue, check.Equals, int64(130))
+	c.Assert(ss.Profiles[0].Samples, check.HasLen, 2)
+	for i, sample := range ss.Profiles[0].Samples {
+		c.Assert(ss.Shared.Frames[sample[0]].Name, check.Equals, "main")
+		c.Assert(sample, check.HasLen, len(fp.stacks[i].frames))
+	}
+}
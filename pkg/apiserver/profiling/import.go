@@ -0,0 +1,360 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/pprof/profile"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	importDirName = "profiling_imports"
+	// maxImportBytes limits the total size of uncompressed profiles in an upload.
+	maxImportBytes = 512 * 1024 * 1024
+)
+
+// importFileNameRE matches the names of files in the downloaded zip, e.g. "cpu_tidb_127.0.0.1_4000_123456.proto".
+var importFileNameRE = regexp.MustCompile(
+	`^(heap_diff|threadcreate|wallclock|goroutine|allocs|block|mutex|trace|heap|cpu)_` +
+		`(tidb|tikv|pd|tiflash|ticdc|tiproxy|tso|scheduling)_(.+?)(_base)?_\d+(\.[a-z.]+)$`)
+
+type importedFile struct {
+	name    string
+	content []byte
+}
+
+type importedTask struct {
+	task        TaskModel
+	ext         string
+	content     []byte
+	baseContent []byte
+}
+
+// readImportedFiles reads the profiles in the uploaded zip, or the uploaded profile itself.
+func readImportedFiles(fileName string, content []byte) ([]importedFile, error) {
+	if !strings.HasSuffix(strings.ToLower(fileName), ".zip") {
+		return []importedFile{{name: path.Base(fileName), content: content}}, nil
+	}
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, rest.ErrBadRequest.New("Invalid zip file: %v", err)
+	}
+	var files []importedFile
+	var total int64
+	for _, f := range zr.File {
+		name := path.Base(f.Name)
+		if f.FileInfo().IsDir() || name == "README.md" || strings.HasPrefix(name, ".") {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(r, maxImportBytes-total+1))
+		_ = r.Close()
+		if err != nil {
+			return nil, err
+		}
+		total += int64(len(data))
+		if total > maxImportBytes {
+			return nil, rest.ErrBadRequest.New("Profiles in the zip file exceed %d bytes", maxImportBytes)
+		}
+		files = append(files, importedFile{name: name, content: data})
+	}
+	if len(files) == 0 {
+		return nil, rest.ErrBadRequest.New("No profile in the zip file")
+	}
+	return files, nil
+}
+
+// detectRawDataType detects the raw data type of the profile by its extension and content.
+func detectRawDataType(name string, content []byte) (TaskRawDataType, error) {
+	switch {
+	case strings.HasSuffix(name, ".trace"):
+		return RawDataTypeTrace, nil
+	case strings.HasSuffix(name, ".txt"):
+		return RawDataTypeText, nil
+	case strings.HasSuffix(name, ".svg"):
+		return "", rest.ErrBadRequest.New("Rendered graph %s cannot be imported", name)
+	}
+	if bytes.Contains(content, []byte("heap_v2/")) {
+		return RawDataTypeJeprof, nil
+	}
+	if _, err := profile.ParseData(content); err == nil {
+		return RawDataTypeProtobuf, nil
+	}
+	return "", rest.ErrBadRequest.New("Unrecognized profile %s", name)
+}
+
+// guessProfilingType guesses the profiling type of a profile whose file name is not generated by TiDB Dashboard.
+func guessProfilingType(rawDataType TaskRawDataType, content []byte) TaskProfilingType {
+	switch rawDataType {
+	case RawDataTypeJeprof:
+		return ProfilingTypeHeap
+	case RawDataTypeText:
+		return ProfilingTypeGoroutine
+	case RawDataTypeTrace:
+		return ProfilingTypeTrace
+	}
+	p, err := profile.ParseData(content)
+	if err != nil || len(p.SampleType) == 0 {
+		return ProfilingTypeCPU
+	}
+	switch p.SampleType[len(p.SampleType)-1].Type {
+	case "inuse_space", "inuse_objects", "alloc_space", "alloc_objects":
+		return ProfilingTypeHeap
+	case "goroutine":
+		return ProfilingTypeGoroutine
+	case "contentions", "delay":
+		return ProfilingTypeMutex
+	case "wall":
+		return ProfilingTypeWallClock
+	default:
+		return ProfilingTypeCPU
+	}
+}
+
+// parseImportedTarget parses the display name in the file name, e.g. "127.0.0.1_4000", into the target.
+func parseImportedTarget(kind model.NodeKind, displayName string) model.RequestTargetNode {
+	target := model.RequestTargetNode{Kind: kind, DisplayName: displayName}
+	if i := strings.LastIndexByte(displayName, '_'); i > 0 {
+		if port, err := strconv.Atoi(displayName[i+1:]); err == nil {
+			target.IP = displayName[:i]
+			target.Port = port
+			target.DisplayName = fmt.Sprintf("%s:%d", target.IP, port)
+		}
+	}
+	return target
+}
+
+// buildImportedTasks builds tasks from the imported files. Targets and profiling types are parsed from
+// the file names generated by TiDB Dashboard, or guessed from the content with the default kind otherwise.
+func buildImportedTasks(files []importedFile, defaultKind model.NodeKind) ([]*importedTask, error) {
+	tasks := make([]*importedTask, 0, len(files))
+	heapDiffTasks := make(map[string]*importedTask)
+	var baseFiles []importedFile
+	for _, f := range files {
+		rawDataType, err := detectRawDataType(f.name, f.content)
+		if err != nil {
+			return nil, err
+		}
+		t := &importedTask{
+			task: TaskModel{
+				State:       TaskStateFinish,
+				RawDataType: rawDataType,
+				StartedAt:   time.Now().Unix(),
+			},
+			ext:     path.Ext(f.name),
+			content: f.content,
+		}
+		if m := importFileNameRE.FindStringSubmatch(f.name); m != nil {
+			if m[4] != "" {
+				baseFiles = append(baseFiles, f)
+				continue
+			}
+			t.task.ProfilingType = TaskProfilingType(m[1])
+			t.task.Target = parseImportedTarget(model.NodeKind(m[2]), m[3])
+			t.ext = m[5]
+			if t.task.ProfilingType == ProfilingTypeHeapDiff {
+				heapDiffTasks[m[2]+"_"+m[3]] = t
+			}
+		} else {
+			t.task.ProfilingType = guessProfilingType(rawDataType, f.content)
+			t.task.Target = model.RequestTargetNode{Kind: defaultKind, DisplayName: strings.TrimSuffix(f.name, path.Ext(f.name))}
+		}
+		tasks = append(tasks, t)
+	}
+	for _, f := range baseFiles {
+		m := importFileNameRE.FindStringSubmatch(f.name)
+		t, ok := heapDiffTasks[m[2]+"_"+m[3]]
+		if !ok {
+			return nil, rest.ErrBadRequest.New("Cannot find the heap profile of the baseline %s", f.name)
+		}
+		t.baseContent = f.content
+	}
+	return tasks, nil
+}
+
+func writeImportedFile(dir, pattern string, content []byte) (string, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.Write(content); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// findGroup returns the task group, or a not found error if it does not exist.
+func (s *Service) findGroup(taskGroupID uint) (*TaskGroupModel, error) {
+	var group TaskGroupModel
+	if err := s.params.LocalStore.Where("id = ?", taskGroupID).First(&group).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, rest.ErrNotFound.New("Task group %d is not found", taskGroupID)
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// checkGroupCancellable returns an error if the task group does not exist, or it is imported and thus has nothing
+// to cancel. Imported groups can still be unpinned and deleted like other groups.
+func (s *Service) checkGroupCancellable(taskGroupID uint) error {
+	group, err := s.findGroup(taskGroupID)
+	if err != nil {
+		return err
+	}
+	if group.Imported {
+		return rest.ErrBadRequest.New("Task group %d is imported and cannot be cancelled", taskGroupID)
+	}
+	return nil
+}
+
+// importGroup writes the imported profiles into the data dir, and registers them as a finished task group.
+func (s *Service) importGroup(tasks []*importedTask, source, description string) (*TaskGroupModel, error) {
+	dir := path.Join(s.params.Config.DataDir, importDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	var filePaths []string
+	removeFiles := func() {
+		for _, file := range filePaths {
+			_ = os.Remove(file)
+		}
+	}
+	targets := make([]model.RequestTargetNode, 0, len(tasks))
+	profilingTypes := TaskProfilingTypeList{}
+	seenTypes := make(map[TaskProfilingType]struct{})
+	for _, t := range tasks {
+		prefix := fmt.Sprintf("%s_%s", t.task.ProfilingType, t.task.Target.FileName())
+		filePath, err := writeImportedFile(dir, prefix+"_*"+t.ext, t.content)
+		if err != nil {
+			removeFiles()
+			return nil, err
+		}
+		filePaths = append(filePaths, filePath)
+		t.task.FilePath = filePath
+		if t.baseContent != nil {
+			if filePath, err = writeImportedFile(dir, prefix+"_base_*"+t.ext, t.baseContent); err != nil {
+				removeFiles()
+				return nil, err
+			}
+			filePaths = append(filePaths, filePath)
+			t.task.BaseFilePath = filePath
+		}
+		targets = append(targets, t.task.Target)
+		if _, ok := seenTypes[t.task.ProfilingType]; !ok {
+			seenTypes[t.task.ProfilingType] = struct{}{}
+			profilingTypes = append(profilingTypes, t.task.ProfilingType)
+		}
+	}
+
+	group := &TaskGroupModel{
+		State:                  TaskStateFinish,
+		TargetStats:            model.NewRequestTargetStatisticsFromArray(&targets),
+		StartedAt:              time.Now().Unix(),
+		RequstedProfilingTypes: profilingTypes,
+		// Imported profiles cannot be fetched again, so they are kept until the user unpins or deletes them.
+		Pinned:       true,
+		Imported:     true,
+		ImportSource: source,
+		Description:  description,
+	}
+	err := s.params.LocalStore.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		for _, t := range tasks {
+			t.task.TaskGroupID = group.ID
+			if err := tx.Create(&t.task).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		removeFiles()
+		return nil, err
+	}
+	return group, nil
+}
+
+// @ID importProfilingGroup
+// @Summary Import profiles as a task group
+// @Description Upload a zip file downloaded from the profiling page, or a single protobuf or jemalloc heap profile. The profiles are registered as a pinned task group, which can be viewed, unpinned and deleted like profiles fetched from the cluster.
+// @Accept multipart/form-data
+// @Param file formData file true "zip file or profile"
+// @Param kind formData string false "component kind of profiles whose file names do not contain the target"
+// @Param description formData string false "description of the imported profiles"
+// @Security JwtAuth
+// @Success 200 {object} TaskGroupModel
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/group/import [post]
+func (s *Service) importGroupHandler(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.New("File is required"))
+		return
+	}
+	if fileHeader.Size > maxImportBytes {
+		rest.Error(c, rest.ErrBadRequest.New("File exceeds %d bytes", maxImportBytes))
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	content, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	files, err := readImportedFiles(fileHeader.Filename, content)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	kind := model.NodeKind(c.PostForm("kind"))
+	if _, ok := supportedProfilingTypes(kind); kind != "" && !ok {
+		rest.Error(c, rest.ErrBadRequest.New("Unsupported kind %s", kind))
+		return
+	}
+	tasks, err := buildImportedTasks(files, kind)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	group, err := s.importGroup(tasks, path.Base(fileHeader.Filename), c.PostForm("description"))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path"
+
+	"github.com/pingcap/check"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+var _ = check.Suite(&testImportSuite{})
+
+type testImportSuite struct{}
+
+func (t *testImportSuite) TestImportZip(c *check.C) {
+	cpuProfile := buildProfile(c, map[string]int64{"a": 10}, map[string][]string{"a": {"a", "main"}})
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string][]byte{
+		"cpu_tidb_127.0.0.1_4000_123.proto":            cpuProfile,
+		"heap_diff_tikv_127.0.0.1_20180_456.prof":      []byte(testJeprofRaw),
+		"heap_diff_tikv_127.0.0.1_20180_base_789.prof": []byte(testJeprofRaw),
+		"goroutine_pd_127.0.0.1_2379_1011.txt":         []byte("goroutine profile: total 1"),
+		"README.md":                                    []byte("readme"),
+	} {
+		w, err := zw.Create(name)
+		c.Assert(err, check.IsNil)
+		_, err = w.Write(content)
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(zw.Close(), check.IsNil)
+
+	files, err := readImportedFiles("profiling.zip", buf.Bytes())
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 4)
+	tasks, err := buildImportedTasks(files, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(tasks, check.HasLen, 3)
+
+	dir := c.MkDir()
+	gormDB, err := gorm.Open(sqlite.Open(path.Join(dir, "test.sqlite.db")), &gorm.Config{})
+	c.Assert(err, check.IsNil)
+	db := &dbstore.DB{DB: gormDB}
+	c.Assert(autoMigrate(db), check.IsNil)
+	s := &Service{params: ServiceParams{LocalStore: db, Config: &config.Config{DataDir: dir}}}
+
+	group, err := s.importGroup(tasks, "profiling.zip", "from customer")
+	c.Assert(err, check.IsNil)
+	c.Assert(group.Imported, check.IsTrue)
+	c.Assert(group.Pinned, check.IsTrue)
+	c.Assert(group.TargetStats, check.DeepEquals, model.RequestTargetStatistics{NumTiDBNodes: 1, NumTiKVNodes: 1, NumPDNodes: 1})
+
+	var saved []TaskModel
+	c.Assert(db.Where("task_group_id = ?", group.ID).Order("id").Find(&saved).Error, check.IsNil)
+	c.Assert(saved, check.HasLen, 3)
+	byType := make(map[TaskProfilingType]TaskModel)
+	for _, task := range saved {
+		byType[task.ProfilingType] = task
+	}
+	cpu := byType[ProfilingTypeCPU]
+	c.Assert(cpu.RawDataType, check.Equals, RawDataTypeProtobuf)
+	c.Assert(cpu.Target, check.DeepEquals, model.RequestTargetNode{Kind: model.NodeKindTiDB, DisplayName: "127.0.0.1:4000", IP: "127.0.0.1", Port: 4000})
+	content, err := os.ReadFile(cpu.FilePath)
+	c.Assert(err, check.IsNil)
+	c.Assert(content, check.DeepEquals, cpuProfile)
+
+	heapDiff := byType[ProfilingTypeHeapDiff]
+	c.Assert(heapDiff.RawDataType, check.Equals, RawDataTypeJeprof)
+	c.Assert(heapDiff.BaseFilePath, check.Not(check.Equals), "")
+	content, err = os.ReadFile(heapDiff.FilePath)
+	c.Assert(err, check.IsNil)
+	_, _, err = ConvertProfile(heapDiff, content, ViewOutputTypeProtobuf)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(byType[ProfilingTypeGoroutine].RawDataType, check.Equals, RawDataTypeText)
+
+	c.Assert(group.Pinned, check.IsTrue)
+	c.Assert(s.checkGroupCancellable(group.ID), check.NotNil)
+	c.Assert(s.checkGroupCancellable(group.ID+1), check.NotNil)
+	_, err = s.findGroup(group.ID)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(s.removeGroup(group.ID), check.IsNil)
+	_, err = os.Stat(heapDiff.BaseFilePath)
+	c.Assert(os.IsNotExist(err), check.IsTrue)
+}
+
+func (t *testImportSuite) TestImportSingleProfile(c *check.C) {
+	files, err := readImportedFiles("/tmp/tikv.heap", []byte(testJeprofRaw))
+	c.Assert(err, check.IsNil)
+	tasks, err := buildImportedTasks(files, model.NodeKindTiKV)
+	c.Assert(err, check.IsNil)
+	c.Assert(tasks, check.HasLen, 1)
+	c.Assert(tasks[0].task.ProfilingType, check.Equals, ProfilingTypeHeap)
+	c.Assert(tasks[0].task.Target, check.DeepEquals, model.RequestTargetNode{Kind: model.NodeKindTiKV, DisplayName: "tikv"})
+
+	_, err = buildImportedTasks([]importedFile{{name: "unknown.bin", content: []byte("unknown")}}, "")
+	c.Assert(err, check.NotNil)
+	_, err = buildImportedTasks([]importedFile{{name: "heap_diff_tikv_127.0.0.1_20180_base_1.prof", content: []byte(testJeprofRaw)}}, "")
+	c.Assert(err, check.NotNil)
+}
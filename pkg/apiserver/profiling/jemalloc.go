@@ -0,0 +1,307 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/pprof/profile"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+var (
+	jemallocHeaderRE = regexp.MustCompile(`^heap_v2/(\d+)`)
+	jemallocSampleRE = regexp.MustCompile(`^t\*:\s+(\d+):\s+(\d+)\s+\[\s*(\d+):\s+(\d+)\]$`)
+)
+
+const jemallocMappedLibraries = "MAPPED_LIBRARIES:"
+
+// parseJemallocProfile converts a jemalloc heap profile into a pprof profile. The content can either be the
+// output of `jeprof --raw`, which contains a symbol section followed by the heap dump, or a bare heap dump.
+// Locations are symbolized with the symbol section if there is one, and then with the symbolizer if it is not nil.
+func parseJemallocProfile(content []byte, symbolizer *elfSymbolizer) (*profile.Profile, error) {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "inuse_objects", Unit: "count"},
+			{Type: "inuse_space", Unit: "bytes"},
+		},
+		DefaultSampleType: "inuse_space",
+		PeriodType:        &profile.ValueType{Type: "space", Unit: "bytes"},
+	}
+	symbols := make(map[uint64][]string)
+	locations := make(map[uint64]*profile.Location)
+	getLocation := func(addr uint64) *profile.Location {
+		loc, ok := locations[addr]
+		if !ok {
+			loc = &profile.Location{ID: uint64(len(p.Location) + 1), Address: addr}
+			locations[addr] = loc
+			p.Location = append(p.Location, loc)
+		}
+		return loc
+	}
+
+	var stack []*profile.Location
+	var memoryMap bytes.Buffer
+	inSymbols, inMemoryMap, hasHeader := false, false, false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case inMemoryMap:
+			memoryMap.WriteString(line)
+			memoryMap.WriteByte('\n')
+		case line == "--- symbol":
+			inSymbols = true
+		case inSymbols:
+			if line == "---" {
+				inSymbols = false
+				continue
+			}
+			// Each line is an address followed by its function names separated by "--",
+			// from the outermost inlined caller to the innermost callee.
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) != 2 || !strings.HasPrefix(fields[0], "0x") {
+				continue
+			}
+			addr, err := strconv.ParseUint(fields[0][2:], 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid symbol line: %s", line)
+			}
+			symbols[addr] = strings.Split(fields[1], "--")
+		case strings.HasPrefix(line, "--- "):
+			// The beginning of the heap dump, e.g. "--- heap"
+		case jemallocHeaderRE.MatchString(line):
+			rate, err := strconv.ParseInt(jemallocHeaderRE.FindStringSubmatch(line)[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jemalloc heap profile header: %s", line)
+			}
+			p.Period = rate
+			hasHeader = true
+		case strings.HasPrefix(line, "@"):
+			addrs, err := parseJemallocStack(line)
+			if err != nil {
+				return nil, err
+			}
+			stack = stack[:0]
+			for _, addr := range addrs {
+				stack = append(stack, getLocation(addr))
+			}
+		case strings.HasPrefix(line, "t*:"):
+			if len(stack) == 0 {
+				// The summary of all threads in the header
+				continue
+			}
+			m := jemallocSampleRE.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("invalid sample line: %s", line)
+			}
+			objects, _ := strconv.ParseInt(m[1], 10, 64)
+			space, _ := strconv.ParseInt(m[2], 10, 64)
+			objects, space = scaleJemallocSample(objects, space, p.Period)
+			s := &profile.Sample{
+				Location: make([]*profile.Location, len(stack)),
+				Value:    []int64{objects, space},
+			}
+			copy(s.Location, stack)
+			p.Sample = append(p.Sample, s)
+		case line == jemallocMappedLibraries:
+			inMemoryMap = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasHeader {
+		return nil, fmt.Errorf("unsupported jemalloc heap profile, expect a heap_v2 header")
+	}
+
+	if memoryMap.Len() > 0 {
+		if err := p.ParseMemoryMap(&memoryMap); err != nil {
+			return nil, fmt.Errorf("failed to parse memory map: %v", err)
+		}
+	}
+	symbolizeWithNames(p, symbols)
+	if symbolizer != nil {
+		symbolizer.symbolize(p)
+	}
+	if err := p.CheckValid(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseJemallocStack parses a stack line of the heap dump, e.g. "@ 0x1 0x2 0x3".
+func parseJemallocStack(line string) ([]uint64, error) {
+	fields := strings.Fields(strings.TrimPrefix(line, "@"))
+	addrs := make([]uint64, 0, len(fields))
+	for i, field := range fields {
+		addr, err := strconv.ParseUint(strings.TrimPrefix(field, "0x"), 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stack line: %s", line)
+		}
+		// Adjust the return addresses of callers to point to the call instructions, as jeprof does.
+		if i > 0 && addr > 0 {
+			addr--
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// fetchJemallocHeap fetches the jemalloc heap dump from the path, and resolves the addresses in it with the
+// `/debug/pprof/symbol` API of the same instance. The symbols are prepended to the heap dump in the same format as
+// `jeprof --raw`. The bare heap dump is returned if the instance cannot resolve symbols, which can still be
+// symbolized with an uploaded binary when viewing.
+func fetchJemallocHeap(path string, get func(path string) ([]byte, error), post func(path string, body io.Reader) ([]byte, error)) ([]byte, error) {
+	heap, err := get(path)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := jemallocHeapAddresses(heap)
+	if err != nil || len(addrs) == 0 {
+		return heap, nil
+	}
+	query := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		query = append(query, fmt.Sprintf("0x%x", addr))
+	}
+	symbols, err := post("/debug/pprof/symbol", strings.NewReader(strings.Join(query, "+")))
+	if err != nil {
+		log.Warn("failed to resolve symbols of jemalloc heap profile", zap.Error(err))
+		return heap, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("--- symbol\n")
+	for _, line := range strings.Split(string(symbols), "\n") {
+		// Each line is an address followed by its function name, e.g. "0x1234 foo".
+		if fields := strings.SplitN(strings.TrimSpace(line), " ", 2); len(fields) == 2 && strings.HasPrefix(fields[0], "0x") {
+			buf.WriteString(strings.TrimSpace(line))
+			buf.WriteByte('\n')
+		}
+	}
+	buf.WriteString("---\n--- heap\n")
+	buf.Write(heap)
+	return buf.Bytes(), nil
+}
+
+// jemallocHeapAddresses returns the distinct addresses in the stacks of the heap dump, in the order they appear.
+func jemallocHeapAddresses(heap []byte) ([]uint64, error) {
+	seen := make(map[uint64]struct{})
+	var addrs []uint64
+	scanner := bufio.NewScanner(bytes.NewReader(heap))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == jemallocMappedLibraries {
+			break
+		}
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		stack, err := parseJemallocStack(line)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range stack {
+			if _, ok := seen[addr]; !ok {
+				seen[addr] = struct{}{}
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs, scanner.Err()
+}
+
+// scaleJemallocSample estimates the actual number of allocations from the sampled ones. jemalloc samples
+// allocations in a Poisson process, so that an allocation of size X is sampled with probability 1-exp(-X/rate).
+func scaleJemallocSample(objects, space, rate int64) (int64, int64) {
+	if objects == 0 || rate <= 0 {
+		return objects, space
+	}
+	ratio := float64(space) / float64(objects) / float64(rate)
+	scale := 1 / (1 - math.Exp(-ratio))
+	return int64(float64(objects) * scale), int64(float64(space) * scale)
+}
+
+// symbolizeWithNames fills the locations with the function names from the symbol section.
+func symbolizeWithNames(p *profile.Profile, symbols map[uint64][]string) {
+	functions := make(map[string]*profile.Function)
+	for _, loc := range p.Location {
+		names, ok := symbols[loc.Address]
+		if !ok {
+			continue
+		}
+		loc.Line = loc.Line[:0]
+		// Lines are ordered from the innermost inlined function to the caller.
+		for i := len(names) - 1; i >= 0; i-- {
+			loc.Line = append(loc.Line, profile.Line{Function: getFunction(p, functions, demangleName(names[i]), "")})
+		}
+	}
+	if len(symbols) > 0 {
+		for _, m := range p.Mapping {
+			m.HasFunctions = true
+		}
+	}
+}
+
+// getFunction returns the function with the given name and file name, and adds it to the profile if it is new.
+func getFunction(p *profile.Profile, functions map[string]*profile.Function, name, fileName string) *profile.Function {
+	key := name + "\x00" + fileName
+	if f, ok := functions[key]; ok {
+		return f
+	}
+	f := &profile.Function{
+		ID:         uint64(len(p.Function) + 1),
+		Name:       name,
+		SystemName: name,
+		Filename:   fileName,
+	}
+	functions[key] = f
+	p.Function = append(p.Function, f)
+	return f
+}
+
+// jemallocToProfile converts the jemalloc heap profile of the task into a pprof profile. If the task has a
+// baseline profile, the result is the difference between them.
+func jemallocToProfile(task TaskModel, content []byte, symbolizer *elfSymbolizer) (*profile.Profile, error) {
+	p, err := parseJemallocProfile(content, symbolizer)
+	if err != nil {
+		return nil, err
+	}
+	if task.BaseFilePath == "" {
+		return p, nil
+	}
+	baseContent, err := os.ReadFile(task.BaseFilePath)
+	if err != nil {
+		return nil, err
+	}
+	base, err := parseJemallocProfile(baseContent, symbolizer)
+	if err != nil {
+		return nil, err
+	}
+	return DiffProfiles(base, p)
+}
+
+// jemallocToProtobuf is the same as jemallocToProfile, but returns the serialized profile.
+func jemallocToProtobuf(task TaskModel, content []byte, symbolizer *elfSymbolizer) ([]byte, error) {
+	p, err := jemallocToProfile(task, content, symbolizer)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
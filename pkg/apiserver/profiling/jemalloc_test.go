@@ -0,0 +1,199 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/pprof/profile"
+	"github.com/pingcap/check"
+)
+
+var _ = check.Suite(&testJemallocSuite{})
+
+type testJemallocSuite struct{}
+
+const testJeprofRaw = `--- symbol
+binary=http://127.0.0.1:20180/debug/pprof/heap
+0x0000000000001000 _ZN4tikv5alloc4main17h0123456789abcdefE
+0x0000000000001fff outer--inner
+---
+--- heap
+heap_v2/524288
+  t*: 3: 1050624 [0: 0]
+  t0: 3: 1050624 [0: 0]
+@ 0x1000 0x2000
+  t*: 1: 1048576 [0: 0]
+  t0: 1: 1048576 [0: 0]
+@ 0x1000
+  t*: 2: 2048 [0: 0]
+  t0: 2: 2048 [0: 0]
+
+MAPPED_LIBRARIES:
+00001000-00003000 r-xp 00000000 08:01 1234 /usr/bin/tikv-server
+`
+
+func (t *testJemallocSuite) TestParseJemallocProfile(c *check.C) {
+	p, err := parseJemallocProfile([]byte(testJeprofRaw), nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(p.Period, check.Equals, int64(524288))
+	c.Assert(p.DefaultSampleType, check.Equals, "inuse_space")
+	c.Assert(p.Mapping, check.HasLen, 1)
+	c.Assert(p.Mapping[0].File, check.Equals, "/usr/bin/tikv-server")
+	c.Assert(p.Sample, check.HasLen, 2)
+
+	objects, space := scaleJemallocSample(1, 1048576, 524288)
+	c.Assert(p.Sample[0].Value, check.DeepEquals, []int64{objects, space})
+	c.Assert(space > 1048576, check.IsTrue)
+
+	var names []string
+	for _, loc := range p.Sample[0].Location {
+		c.Assert(loc.Mapping, check.Equals, p.Mapping[0])
+		for _, line := range loc.Line {
+			names = append(names, line.Function.Name)
+		}
+	}
+	// The caller address is adjusted to 0x1fff, whose innermost inlined function comes first.
+	c.Assert(names, check.DeepEquals, []string{"tikv::alloc::main", "inner", "outer"})
+
+	fp := foldParsedProfile(p)
+	c.Assert(string(fp.Folded()), check.Equals, fmt.Sprintf("outer;inner;tikv::alloc::main %d\ntikv::alloc::main %d\n",
+		space, p.Sample[1].Value[1]))
+
+	_, err = parseJemallocProfile([]byte("heap profile: 1: 1 [ 0: 0] @ heap\n"), nil)
+	c.Assert(err, check.NotNil)
+}
+
+func (t *testJemallocSuite) TestFetchJemallocHeap(c *check.C) {
+	heap := testJeprofRaw[strings.Index(testJeprofRaw, "heap_v2"):]
+	get := func(path string) ([]byte, error) {
+		c.Assert(path, check.Equals, "/debug/pprof/heap")
+		return []byte(heap), nil
+	}
+	var query string
+	post := func(path string, body io.Reader) ([]byte, error) {
+		c.Assert(path, check.Equals, "/debug/pprof/symbol")
+		data, err := io.ReadAll(body)
+		query = string(data)
+		return []byte("0x1000 _ZN4tikv5alloc4main17h0123456789abcdefE\n0x1fff <T as core::Foo>::bar\n"), err
+	}
+	content, err := fetchJemallocHeap("/debug/pprof/heap", get, post)
+	c.Assert(err, check.IsNil)
+	// The caller address is adjusted in the same way as the parser.
+	c.Assert(query, check.Equals, "0x1000+0x1fff")
+
+	p, err := parseJemallocProfile(content, nil)
+	c.Assert(err, check.IsNil)
+	var names []string
+	for _, loc := range p.Sample[0].Location {
+		for _, line := range loc.Line {
+			names = append(names, line.Function.Name)
+		}
+	}
+	c.Assert(names, check.DeepEquals, []string{"tikv::alloc::main", "<T as core::Foo>::bar"})
+
+	// The bare heap dump is kept if symbols cannot be resolved.
+	content, err = fetchJemallocHeap("/debug/pprof/heap", get, func(string, io.Reader) ([]byte, error) {
+		return nil, fmt.Errorf("not found")
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(string(content), check.Equals, heap)
+}
+
+func (t *testJemallocSuite) TestJemallocHeapDiff(c *check.C) {
+	dir := c.MkDir()
+	basePath := path.Join(dir, "base.prof")
+	baseRaw := strings.Replace(testJeprofRaw, "t*: 1: 1048576 [0: 0]", "t*: 0: 0 [0: 0]", 1)
+	c.Assert(os.WriteFile(basePath, []byte(baseRaw), 0o600), check.IsNil)
+
+	task := TaskModel{RawDataType: RawDataTypeJeprof, BaseFilePath: basePath}
+	diff, err := jemallocToProfile(task, []byte(testJeprofRaw), nil)
+	c.Assert(err, check.IsNil)
+	resp := TopDiffFunctions(diff, 10)
+	c.Assert(resp.SampleType, check.Equals, "inuse_space")
+	c.Assert(resp.Functions, check.HasLen, 3)
+	_, space := scaleJemallocSample(1, 1048576, 524288)
+	c.Assert(resp.TargetTotal-resp.BaseTotal, check.Equals, space)
+
+	content, contentType, err := ConvertProfile(task, []byte(testJeprofRaw), ViewOutputTypeProtobuf)
+	c.Assert(err, check.IsNil)
+	c.Assert(contentType, check.Equals, "application/protobuf")
+	_, err = profile.ParseData(content)
+	c.Assert(err, check.IsNil)
+}
+
+func (t *testJemallocSuite) TestELFSymbolizer(c *check.C) {
+	// A position independent executable whose text segment is loaded at 0x7f0000001000.
+	symbolizer := &elfSymbolizer{
+		fileType: elf.ET_DYN,
+		loads:    []elf.ProgHeader{{Type: elf.PT_LOAD, Flags: elf.PF_R | elf.PF_X, Off: 0x1000, Vaddr: 0x401000, Filesz: 0x2000}},
+		symbols: []elf.Symbol{
+			{Name: "_ZN4tikv5alloc4main17h0123456789abcdefE", Value: 0x401000, Size: 0x100},
+			{Name: "malloc", Value: 0x402000, Size: 0x10},
+		},
+	}
+	mapping := &profile.Mapping{ID: 1, Start: 0x7f0000001000, Limit: 0x7f0000003000, Offset: 0x1000, File: "/usr/bin/tikv-server"}
+	c.Assert(symbolizer.toVirtualAddress(0x7f0000001010, mapping), check.Equals, uint64(0x401010))
+
+	p := &profile.Profile{
+		Mapping: []*profile.Mapping{mapping},
+		Location: []*profile.Location{
+			{ID: 1, Address: 0x7f0000001010, Mapping: mapping},
+			{ID: 2, Address: 0x7f0000002008, Mapping: mapping},
+			{ID: 3, Address: 0x7f0000002020, Mapping: mapping}, // beyond the size of malloc
+		},
+	}
+	symbolizer.symbolize(p)
+	c.Assert(p.Location[0].Line, check.HasLen, 1)
+	c.Assert(p.Location[0].Line[0].Function.Name, check.Equals, "tikv::alloc::main")
+	c.Assert(p.Location[1].Line[0].Function.Name, check.Equals, "malloc")
+	c.Assert(p.Location[2].Line, check.HasLen, 0)
+	c.Assert(mapping.HasFunctions, check.IsTrue)
+	c.Assert(p.CheckValid(), check.IsNil)
+
+	_, err := newELFSymbolizer(path.Join(c.MkDir(), "not_exist"))
+	c.Assert(err, check.NotNil)
+}
+
+func (t *testJemallocSuite) TestSymbolizerCache(c *check.C) {
+	// The test binary itself is an ELF file.
+	filePath, err := os.Executable()
+	c.Assert(err, check.IsNil)
+	binary := func(id uint, size int64) *BinaryModel {
+		return &BinaryModel{ID: id, FilePath: filePath, Size: size}
+	}
+
+	var cache symbolizerCache
+	s1, err := cache.get(binary(1, 100))
+	c.Assert(err, check.IsNil)
+	_, err = cache.get(binary(2, 100))
+	c.Assert(err, check.IsNil)
+	s, _ := cache.get(binary(1, 100))
+	c.Assert(s, check.Equals, s1)
+	// the least recently used one is evicted
+	_, err = cache.get(binary(3, 100))
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.recent, check.DeepEquals, []uint{1, 3})
+	c.Assert(cache.bytes, check.Equals, int64(200))
+
+	// evicted until the total size is within the limit
+	_, err = cache.get(binary(4, maxCachedSymbolizerBytes-100))
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.recent, check.DeepEquals, []uint{3, 4})
+	c.Assert(cache.bytes, check.Equals, int64(maxCachedSymbolizerBytes))
+
+	// too large to be cached
+	_, err = cache.get(binary(5, maxCachedSymbolizerBytes+1))
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.recent, check.DeepEquals, []uint{3, 4})
+
+	cache.remove(4)
+	c.Assert(cache.recent, check.DeepEquals, []uint{3})
+	c.Assert(cache.bytes, check.Equals, int64(100))
+	c.Assert(cache.symbolizers, check.HasLen, 1)
+}
@@ -33,6 +33,7 @@ const (
 	RawDataTypeJeprof   TaskRawDataType = "jeprof"
 	RawDataTypeProtobuf TaskRawDataType = "protobuf"
 	RawDataTypeText     TaskRawDataType = "text"
+	RawDataTypeTrace    TaskRawDataType = "trace" // Go execution trace, can only be viewed by `go tool trace`
 )
 
 type (
@@ -54,13 +55,32 @@ const (
 	ProfilingTypeHeap      TaskProfilingType = "heap"
 	ProfilingTypeGoroutine TaskProfilingType = "goroutine"
 	ProfilingTypeMutex     TaskProfilingType = "mutex"
+	// ProfilingTypeBlock, ProfilingTypeAllocs, ProfilingTypeThreadCreate and ProfilingTypeTrace
+	// are only supported by components written in Go.
+	ProfilingTypeBlock        TaskProfilingType = "block"
+	ProfilingTypeAllocs       TaskProfilingType = "allocs"
+	ProfilingTypeThreadCreate TaskProfilingType = "threadcreate"
+	ProfilingTypeTrace        TaskProfilingType = "trace"
+	// ProfilingTypeHeapDiff collects two jemalloc heap profiles at the beginning and the end of the
+	// profiling duration, and views the latter one based on the former one. Only supported by TiKV and TiFlash.
+	ProfilingTypeHeapDiff TaskProfilingType = "heap_diff"
+	// ProfilingTypeWallClock samples the stacks of all goroutines repeatedly during the profiling duration,
+	// which covers the time spent on waiting as well as running. Only supported by TiDB. Each sample stops
+	// the world of TiDB briefly, so that at most 30 samples are taken regardless of the duration.
+	ProfilingTypeWallClock TaskProfilingType = "wallclock"
 )
 
 var profilingTypeMap = map[TaskProfilingType]struct{}{
-	ProfilingTypeCPU:       {},
-	ProfilingTypeHeap:      {},
-	ProfilingTypeGoroutine: {},
-	ProfilingTypeMutex:     {},
+	ProfilingTypeCPU:          {},
+	ProfilingTypeHeap:         {},
+	ProfilingTypeGoroutine:    {},
+	ProfilingTypeMutex:        {},
+	ProfilingTypeBlock:        {},
+	ProfilingTypeAllocs:       {},
+	ProfilingTypeThreadCreate: {},
+	ProfilingTypeTrace:        {},
+	ProfilingTypeHeapDiff:     {},
+	ProfilingTypeWallClock:    {},
 }
 
 type TaskModel struct {
@@ -69,6 +89,7 @@ type TaskModel struct {
 	State         TaskState               `json:"state" gorm:"index"`
 	Target        model.RequestTargetNode `json:"target" gorm:"embedded;embedded_prefix:target_"`
 	FilePath      string                  `json:"-" gorm:"type:text"`
+	BaseFilePath  string                  `json:"-" gorm:"type:text"` // The baseline profile, only for heap_diff
 	Error         string                  `json:"error" gorm:"type:text"`
 	StartedAt     int64                   `json:"started_at"` // The start running time, reset when retry. Used to estimate approximate profiling progress.
 	RawDataType   TaskRawDataType         `json:"raw_data_type" gorm:"raw_data_type"`
@@ -79,6 +100,14 @@ func (TaskModel) TableName() string {
 	return "profiling_tasks"
 }
 
+// filePaths returns all files of the task, including the baseline profile if there is one.
+func (t *TaskModel) filePaths() []string {
+	if t.BaseFilePath != "" {
+		return []string{t.BaseFilePath, t.FilePath}
+	}
+	return []string{t.FilePath}
+}
+
 type TaskGroupModel struct {
 	ID                     uint                          `json:"id" gorm:"primary_key"`
 	State                  TaskState                     `json:"state" gorm:"index"`
@@ -86,6 +115,11 @@ type TaskGroupModel struct {
 	TargetStats            model.RequestTargetStatistics `json:"target_stats" gorm:"embedded;embedded_prefix:target_stats_"`
 	StartedAt              int64                         `json:"started_at"`
 	RequstedProfilingTypes TaskProfilingTypeList         `json:"requsted_profiling_types"`
+	TriggerName            string                        `json:"trigger_name"`                   // The trigger which starts the group, empty if started manually
+	Pinned                 bool                          `json:"pinned"`                         // Pinned groups are never removed by the retention policy
+	Imported               bool                          `json:"imported"`                       // Imported groups cannot be cancelled, whose profiles are uploaded instead of fetched
+	ImportSource           string                        `json:"import_source" gorm:"type:text"` // The name of the uploaded file
+	Description            string                        `json:"description" gorm:"type:text"`
 }
 
 func (TaskGroupModel) TableName() string {
@@ -93,7 +127,7 @@ func (TaskGroupModel) TableName() string {
 }
 
 func autoMigrate(db *dbstore.DB) error {
-	return db.AutoMigrate(&TaskModel{}, &TaskGroupModel{})
+	return db.AutoMigrate(&TaskModel{}, &TaskGroupModel{}, &BinaryModel{})
 }
 
 // Task is the unit to fetch profiling information.
@@ -125,7 +159,7 @@ func NewTask(ctx context.Context, taskGroup *TaskGroup, target model.RequestTarg
 
 func (t *Task) run() {
 	fileNameWithoutExt := fmt.Sprintf("%s_%s", t.ProfilingType, t.Target.FileName())
-	protoFilePath, rawDataType, err := profileAndWritePprof(t.ctx, t.fetchers, &t.Target, fileNameWithoutExt, t.taskGroup.ProfileDurationSecs, t.ProfilingType)
+	result, err := profileAndWritePprof(t.ctx, t.fetchers, &t.Target, fileNameWithoutExt, t.taskGroup.ProfileDurationSecs, t.ProfilingType)
 	if err != nil {
 		if errorx.IsOfType(err, ErrUnsupportedProfilingType) {
 			t.State = TaskStateSkipped
@@ -136,9 +170,10 @@ func (t *Task) run() {
 		t.taskGroup.db.Save(t.TaskModel)
 		return
 	}
-	t.FilePath = protoFilePath
+	t.FilePath = result.filePath
+	t.BaseFilePath = result.baseFilePath
 	t.State = TaskStateFinish
-	t.RawDataType = rawDataType
+	t.RawDataType = result.rawDataType
 	t.taskGroup.db.Save(t.TaskModel)
 }
 
@@ -153,7 +188,7 @@ type TaskGroup struct {
 }
 
 // NewTaskGroup create a new profiling task group.
-func NewTaskGroup(db *dbstore.DB, profileDurationSecs uint, stats model.RequestTargetStatistics, requestedProfilingTypes TaskProfilingTypeList) *TaskGroup {
+func NewTaskGroup(db *dbstore.DB, profileDurationSecs uint, stats model.RequestTargetStatistics, requestedProfilingTypes TaskProfilingTypeList, triggerName string) *TaskGroup {
 	return &TaskGroup{
 		TaskGroupModel: &TaskGroupModel{
 			State:                  TaskStateRunning,
@@ -161,6 +196,7 @@ func NewTaskGroup(db *dbstore.DB, profileDurationSecs uint, stats model.RequestT
 			TargetStats:            stats,
 			StartedAt:              time.Now().Unix(),
 			RequstedProfilingTypes: requestedProfilingTypes,
+			TriggerName:            triggerName,
 		},
 		db: db,
 	}
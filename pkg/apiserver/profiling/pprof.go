@@ -3,14 +3,17 @@
 package profiling
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
 )
 
 type pprofOptions struct {
+	ctx                context.Context
 	duration           uint
 	fileNameWithoutExt string
 
@@ -19,14 +22,20 @@ type pprofOptions struct {
 	profilingType TaskProfilingType
 }
 
-func fetchPprof(op *pprofOptions) (string, TaskRawDataType, error) {
+func fetchPprof(op *pprofOptions) (*profileResult, error) {
 	fetcher := &fetcher{profileFetcher: op.fetcher, target: op.target}
-	tmpPath, rawDataType, err := fetcher.FetchAndWriteToFile(op.duration, op.fileNameWithoutExt, op.profilingType)
+	if op.profilingType == ProfilingTypeHeapDiff {
+		return fetcher.FetchHeapDiff(op.ctx, op.duration, op.fileNameWithoutExt)
+	}
+	if op.profilingType == ProfilingTypeWallClock {
+		return fetcher.FetchWallClock(op.ctx, op.duration, op.fileNameWithoutExt)
+	}
+	tmpPath, rawDataType, err := fetcher.FetchAndWriteToFile(op.ctx, op.duration, op.fileNameWithoutExt, op.profilingType)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to fetch and write to temp file: %v", err)
+		return nil, fmt.Errorf("failed to fetch and write to temp file: %v", err)
 	}
 
-	return tmpPath, rawDataType, nil
+	return &profileResult{filePath: tmpPath, rawDataType: rawDataType}, nil
 }
 
 type fetcher struct {
@@ -34,7 +43,7 @@ type fetcher struct {
 	profileFetcher *profileFetcher
 }
 
-func (f *fetcher) FetchAndWriteToFile(duration uint, fileNameWithoutExt string, profilingType TaskProfilingType) (string, TaskRawDataType, error) {
+func (f *fetcher) FetchAndWriteToFile(ctx context.Context, duration uint, fileNameWithoutExt string, profilingType TaskProfilingType) (string, TaskRawDataType, error) {
 	var profilingRawDataType TaskRawDataType
 	var fileExtenstion string
 	secs := strconv.Itoa(int(duration))
@@ -62,6 +71,16 @@ func (f *fetcher) FetchAndWriteToFile(duration uint, fileNameWithoutExt string,
 		url = "/debug/pprof/mutex?debug=1"
 		profilingRawDataType = RawDataTypeText
 		fileExtenstion = "*.txt"
+	case ProfilingTypeBlock, ProfilingTypeAllocs, ProfilingTypeThreadCreate:
+		url = "/debug/pprof/" + string(profilingType)
+		profilingRawDataType = RawDataTypeProtobuf
+		fileExtenstion = "*.proto"
+	case ProfilingTypeTrace:
+		url = "/debug/pprof/trace?seconds=" + secs
+		profilingRawDataType = RawDataTypeTrace
+		fileExtenstion = "*.trace"
+	default:
+		return "", "", ErrUnsupportedProfilingType.NewWithNoMessage()
 	}
 
 	tmpfile, err := os.CreateTemp("", fileNameWithoutExt+"_"+fileExtenstion)
@@ -73,7 +92,7 @@ func (f *fetcher) FetchAndWriteToFile(duration uint, fileNameWithoutExt string,
 		_ = tmpfile.Close()
 	}()
 
-	resp, err := (*f.profileFetcher).fetch(&fetchOptions{ip: f.target.IP, port: f.target.Port, path: url})
+	resp, err := (*f.profileFetcher).fetch(&fetchOptions{ctx: ctx, ip: f.target.IP, port: f.target.Port, path: url})
 	if err != nil {
 		return "", "", fmt.Errorf("failed to fetch profile with %v format: %v", fileExtenstion, err)
 	}
@@ -85,3 +104,25 @@ func (f *fetcher) FetchAndWriteToFile(duration uint, fileNameWithoutExt string,
 
 	return tmpfile.Name(), profilingRawDataType, nil
 }
+
+// FetchHeapDiff fetches the jemalloc heap profile twice, at the beginning and the end of the duration.
+func (f *fetcher) FetchHeapDiff(ctx context.Context, duration uint, fileNameWithoutExt string) (*profileResult, error) {
+	basePath, _, err := f.FetchAndWriteToFile(ctx, duration, fileNameWithoutExt+"_base", ProfilingTypeHeap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch base heap profile: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		_ = os.Remove(basePath)
+		return nil, ctx.Err()
+	case <-time.After(time.Duration(duration) * time.Second):
+	}
+
+	path, rawDataType, err := f.FetchAndWriteToFile(ctx, duration, fileNameWithoutExt, ProfilingTypeHeap)
+	if err != nil {
+		_ = os.Remove(basePath)
+		return nil, fmt.Errorf("failed to fetch heap profile: %v", err)
+	}
+	return &profileResult{filePath: path, baseFilePath: basePath, rawDataType: rawDataType}, nil
+}
@@ -4,37 +4,103 @@ package profiling
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
 )
 
-func profileAndWritePprof(_ context.Context, fts *fetchers, target *model.RequestTargetNode, fileNameWithoutExt string, profileDurationSecs uint, profilingType TaskProfilingType) (string, TaskRawDataType, error) {
+// jemallocProfilingTypes are supported by TiKV and TiFlash, whose heap profiles are jemalloc heap dumps.
+var jemallocProfilingTypes = map[TaskProfilingType]struct{}{
+	ProfilingTypeCPU:      {},
+	ProfilingTypeHeap:     {},
+	ProfilingTypeHeapDiff: {},
+}
+
+// goProfilingTypes are supported by components written in Go.
+var goProfilingTypes = map[TaskProfilingType]struct{}{
+	ProfilingTypeCPU:          {},
+	ProfilingTypeHeap:         {},
+	ProfilingTypeGoroutine:    {},
+	ProfilingTypeMutex:        {},
+	ProfilingTypeBlock:        {},
+	ProfilingTypeAllocs:       {},
+	ProfilingTypeThreadCreate: {},
+	ProfilingTypeTrace:        {},
+}
+
+// tidbProfilingTypes are supported by TiDB.
+var tidbProfilingTypes = func() map[TaskProfilingType]struct{} {
+	types := map[TaskProfilingType]struct{}{ProfilingTypeWallClock: {}}
+	for t := range goProfilingTypes {
+		types[t] = struct{}{}
+	}
+	return types
+}()
+
+type profileResult struct {
+	filePath     string
+	baseFilePath string
+	rawDataType  TaskRawDataType
+}
+
+// supportedProfilingTypes returns the profiling types supported by the component, or false if the component cannot
+// be profiled.
+func supportedProfilingTypes(kind model.NodeKind) (map[TaskProfilingType]struct{}, bool) {
+	switch kind {
+	case model.NodeKindTiKV, model.NodeKindTiFlash:
+		return jemallocProfilingTypes, true
+	case model.NodeKindTiDB:
+		return tidbProfilingTypes, true
+	case model.NodeKindPD, model.NodeKindTiCDC, model.NodeKindTiProxy, model.NodeKindTSO, model.NodeKindScheduling:
+		return goProfilingTypes, true
+	default:
+		return nil, false
+	}
+}
+
+func profileAndWritePprof(ctx context.Context, fts *fetchers, target *model.RequestTargetNode, fileNameWithoutExt string, profileDurationSecs uint, profilingType TaskProfilingType) (*profileResult, error) {
+	var fetcher *profileFetcher
 	switch target.Kind {
 	case model.NodeKindTiKV:
-		// TiKV only supports CPU/heap Profiling
-		if profilingType != ProfilingTypeCPU && profilingType != ProfilingTypeHeap {
-			return "", "", ErrUnsupportedProfilingType.NewWithNoMessage()
-		}
-		return fetchPprof(&pprofOptions{duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: &fts.tikv, profilingType: profilingType})
+		fetcher = &fts.tikv
 	case model.NodeKindTiFlash:
-		// TiFlash only supports CPU/heap Profiling
-		if profilingType != ProfilingTypeCPU && profilingType != ProfilingTypeHeap {
-			return "", "", ErrUnsupportedProfilingType.NewWithNoMessage()
-		}
-		return fetchPprof(&pprofOptions{duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: &fts.tiflash, profilingType: profilingType})
+		fetcher = &fts.tiflash
 	case model.NodeKindTiDB:
-		return fetchPprof(&pprofOptions{duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: &fts.tidb, profilingType: profilingType})
+		fetcher = &fts.tidb
 	case model.NodeKindPD:
-		return fetchPprof(&pprofOptions{duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: &fts.pd, profilingType: profilingType})
+		fetcher = &fts.pd
 	case model.NodeKindTiCDC:
-		return fetchPprof(&pprofOptions{duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: &fts.ticdc, profilingType: profilingType})
+		fetcher = &fts.ticdc
 	case model.NodeKindTiProxy:
-		return fetchPprof(&pprofOptions{duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: &fts.tiproxy, profilingType: profilingType})
+		fetcher = &fts.tiproxy
 	case model.NodeKindTSO:
-		return fetchPprof(&pprofOptions{duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: &fts.tso, profilingType: profilingType})
+		fetcher = &fts.tso
 	case model.NodeKindScheduling:
-		return fetchPprof(&pprofOptions{duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: &fts.scheduling, profilingType: profilingType})
+		fetcher = &fts.scheduling
 	default:
-		return "", "", ErrUnsupportedProfilingTarget.New("%s", target.String()) // nolint: vet
+		return nil, ErrUnsupportedProfilingTarget.New("%s", target.String()) // nolint: vet
+	}
+	supportedTypes, _ := supportedProfilingTypes(target.Kind)
+	if _, ok := supportedTypes[profilingType]; !ok {
+		return nil, ErrUnsupportedProfilingType.NewWithNoMessage()
+	}
+	return fetchPprof(&pprofOptions{ctx: ctx, duration: profileDurationSecs, fileNameWithoutExt: fileNameWithoutExt, target: target, fetcher: fetcher, profilingType: profilingType})
+}
+
+// FetchProfile fetches a profile of the target into a temporary file without creating a task.
+// The caller is responsible for removing the returned files.
+func (s *Service) FetchProfile(ctx context.Context, target *model.RequestTargetNode, profilingType TaskProfilingType, durationSecs uint) (*TaskModel, error) {
+	fileNameWithoutExt := fmt.Sprintf("%s_%s", profilingType, target.FileName())
+	result, err := profileAndWritePprof(ctx, s.fetchers, target, fileNameWithoutExt, durationSecs, profilingType)
+	if err != nil {
+		return nil, err
 	}
+	return &TaskModel{
+		State:         TaskStateFinish,
+		Target:        *target,
+		FilePath:      result.filePath,
+		BaseFilePath:  result.baseFilePath,
+		RawDataType:   result.rawDataType,
+		ProfilingType: profilingType,
+	}, nil
 }
@@ -0,0 +1,96 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"context"
+	"os"
+
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+)
+
+var _ = check.Suite(&testProfileSuite{})
+
+type testProfileSuite struct{}
+
+type mockProfileFetcher struct {
+	paths []string
+}
+
+func (f *mockProfileFetcher) fetch(op *fetchOptions) ([]byte, error) {
+	if err := op.ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.paths = append(f.paths, op.path)
+	return []byte(op.path), nil
+}
+
+func (t *testProfileSuite) TestProfilingTypeSupport(c *check.C) {
+	tikv := &mockProfileFetcher{}
+	tidb := &mockProfileFetcher{}
+	fts := &fetchers{tikv: tikv, tidb: tidb}
+
+	cases := []struct {
+		kind          model.NodeKind
+		profilingType TaskProfilingType
+		path          string
+		rawDataType   TaskRawDataType
+	}{
+		{model.NodeKindTiDB, ProfilingTypeBlock, "/debug/pprof/block", RawDataTypeProtobuf},
+		{model.NodeKindTiDB, ProfilingTypeAllocs, "/debug/pprof/allocs", RawDataTypeProtobuf},
+		{model.NodeKindTiDB, ProfilingTypeThreadCreate, "/debug/pprof/threadcreate", RawDataTypeProtobuf},
+		{model.NodeKindTiDB, ProfilingTypeTrace, "/debug/pprof/trace?seconds=0", RawDataTypeTrace},
+		{model.NodeKindTiKV, ProfilingTypeHeap, "/debug/pprof/heap", RawDataTypeJeprof},
+	}
+	for _, cs := range cases {
+		target := &model.RequestTargetNode{Kind: cs.kind, IP: "127.0.0.1", Port: 4000}
+		result, err := profileAndWritePprof(context.Background(), fts, target, "test", 0, cs.profilingType)
+		c.Assert(err, check.IsNil)
+		c.Assert(result.rawDataType, check.Equals, cs.rawDataType)
+		c.Assert(result.baseFilePath, check.Equals, "")
+		c.Assert(readFetchedPaths(result), check.DeepEquals, []string{cs.path})
+	}
+
+	target := &model.RequestTargetNode{Kind: model.NodeKindTiKV, IP: "127.0.0.1", Port: 20160}
+	result, err := profileAndWritePprof(context.Background(), fts, target, "test", 0, ProfilingTypeHeapDiff)
+	c.Assert(err, check.IsNil)
+	c.Assert(result.rawDataType, check.Equals, RawDataTypeJeprof)
+	c.Assert(readFetchedPaths(result), check.DeepEquals, []string{"/debug/pprof/heap", "/debug/pprof/heap"})
+	c.Assert(tikv.paths, check.HasLen, 3)
+
+	_, err = profileAndWritePprof(context.Background(), fts, target, "test", 0, ProfilingTypeBlock)
+	c.Assert(errorx.IsOfType(err, ErrUnsupportedProfilingType), check.IsTrue)
+
+	target = &model.RequestTargetNode{Kind: model.NodeKindTiDB, IP: "127.0.0.1", Port: 10080}
+	_, err = profileAndWritePprof(context.Background(), fts, target, "test", 0, ProfilingTypeHeapDiff)
+	c.Assert(errorx.IsOfType(err, ErrUnsupportedProfilingType), check.IsTrue)
+}
+
+func (t *testProfileSuite) TestFetchWithContext(c *check.C) {
+	tidb := &mockProfileFetcher{}
+	fts := &fetchers{tidb: tidb}
+	target := &model.RequestTargetNode{Kind: model.NodeKindTiDB, IP: "127.0.0.1", Port: 10080}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, profilingType := range []TaskProfilingType{ProfilingTypeCPU, ProfilingTypeGoroutine, ProfilingTypeWallClock} {
+		_, err := profileAndWritePprof(ctx, fts, target, "test", 0, profilingType)
+		c.Assert(err, check.ErrorMatches, ".*context canceled.*")
+	}
+	c.Assert(tidb.paths, check.HasLen, 0)
+}
+
+// readFetchedPaths reads the fetched paths written by mockProfileFetcher and removes the files.
+func readFetchedPaths(result *profileResult) []string {
+	task := TaskModel{FilePath: result.filePath, BaseFilePath: result.baseFilePath}
+	paths := make([]string, 0, 2)
+	for _, file := range task.filePaths() {
+		content, _ := os.ReadFile(file)
+		_ = os.Remove(file)
+		paths = append(paths, string(content))
+	}
+	return paths
+}
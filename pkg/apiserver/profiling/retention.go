@@ -0,0 +1,212 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const retentionCheckInterval = 10 * time.Minute
+
+type GroupStorageUsage struct {
+	ID          uint      `json:"id"`
+	State       TaskState `json:"state"`
+	StartedAt   int64     `json:"started_at"`
+	Pinned      bool      `json:"pinned"`
+	TriggerName string    `json:"trigger_name"`
+	NumFiles    int       `json:"num_files"`
+	Bytes       int64     `json:"bytes"`
+}
+
+type StorageUsageResponse struct {
+	TotalBytes int64               `json:"total_bytes"`
+	Groups     []GroupStorageUsage `json:"groups"`
+}
+
+// storageUsage returns the size of profile files of all task groups, ordered from the newest to the oldest.
+func (s *Service) storageUsage() (*StorageUsageResponse, error) {
+	var groups []TaskGroupModel
+	if err := s.params.LocalStore.Order("id DESC").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	var tasks []TaskModel
+	if err := s.params.LocalStore.Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	usages := make(map[uint]*GroupStorageUsage, len(groups))
+	resp := &StorageUsageResponse{Groups: make([]GroupStorageUsage, len(groups))}
+	for i, g := range groups {
+		resp.Groups[i] = GroupStorageUsage{
+			ID:          g.ID,
+			State:       g.State,
+			StartedAt:   g.StartedAt,
+			Pinned:      g.Pinned,
+			TriggerName: g.TriggerName,
+		}
+		usages[g.ID] = &resp.Groups[i]
+	}
+	for _, task := range tasks {
+		usage, ok := usages[task.TaskGroupID]
+		if !ok {
+			continue
+		}
+		for _, file := range task.filePaths() {
+			if file == "" {
+				continue
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+			usage.NumFiles++
+			usage.Bytes += info.Size()
+		}
+	}
+	for _, g := range resp.Groups {
+		resp.TotalBytes += g.Bytes
+	}
+	return resp, nil
+}
+
+// retentionCandidates returns the groups to be removed by the retention policy. The usage must be ordered
+// from the newest to the oldest. Running and pinned groups are never removed, but count towards the limits.
+func retentionCandidates(usage *StorageUsageResponse, cfg *config.ProfilingConfig, now time.Time) []uint {
+	var candidates []uint
+	numGroups := uint(len(usage.Groups))
+	totalBytes := usage.TotalBytes
+	for i := len(usage.Groups) - 1; i >= 0; i-- {
+		g := usage.Groups[i]
+		if g.Pinned || g.State == TaskStateRunning {
+			continue
+		}
+		expired := cfg.RetentionMaxAgeSecs > 0 && now.Sub(time.Unix(g.StartedAt, 0)) > time.Duration(cfg.RetentionMaxAgeSecs)*time.Second
+		tooMany := cfg.RetentionMaxGroups > 0 && numGroups > cfg.RetentionMaxGroups
+		tooLarge := cfg.RetentionMaxBytes > 0 && totalBytes > int64(cfg.RetentionMaxBytes)
+		if !expired && !tooMany && !tooLarge {
+			continue
+		}
+		candidates = append(candidates, g.ID)
+		numGroups--
+		totalBytes -= g.Bytes
+	}
+	return candidates
+}
+
+func (s *Service) enforceRetention(cfg *config.ProfilingConfig) {
+	if cfg.RetentionMaxAgeSecs == 0 && cfg.RetentionMaxBytes == 0 && cfg.RetentionMaxGroups == 0 {
+		return
+	}
+	usage, err := s.storageUsage()
+	if err != nil {
+		log.Warn("failed to get profiling storage usage", zap.Error(err))
+		return
+	}
+	for _, id := range retentionCandidates(usage, cfg, time.Now()) {
+		if err := s.removeGroup(id); err != nil {
+			log.Warn("failed to remove expired profiling group", zap.Uint("id", id), zap.Error(err))
+			continue
+		}
+		log.Info("removed profiling group by retention policy", zap.Uint("id", id))
+	}
+}
+
+func (s *Service) retentionLoop(ctx context.Context) {
+	cfgCh := s.params.ConfigManager.NewPushChannel()
+	ticker := time.NewTicker(retentionCheckInterval)
+	defer ticker.Stop()
+
+	var profilingConfig *config.ProfilingConfig
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case dc, ok := <-cfgCh:
+			if !ok {
+				return
+			}
+			profilingConfig = &dc.Profiling
+			s.enforceRetention(profilingConfig)
+		case <-ticker.C:
+			if profilingConfig != nil {
+				s.enforceRetention(profilingConfig)
+			}
+		}
+	}
+}
+
+// @ID getProfilingStorageUsage
+// @Summary Get the storage usage of profiling groups
+// @Description Get the size of profiling results of each group, ordered from the newest to the oldest
+// @Security JwtAuth
+// @Success 200 {object} StorageUsageResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/storage [get]
+func (s *Service) getStorageUsage(c *gin.Context) {
+	usage, err := s.storageUsage()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+func (s *Service) setGroupPinned(c *gin.Context, pinned bool) {
+	taskGroupID, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	result := s.params.LocalStore.Model(&TaskGroupModel{}).Where("id = ?", taskGroupID).Update("pinned", pinned)
+	if result.Error != nil {
+		rest.Error(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		rest.Error(c, rest.ErrNotFound.New("Task group %d is not found", taskGroupID))
+		return
+	}
+	c.JSON(http.StatusOK, rest.EmptyResponse{})
+}
+
+// @ID pinProfilingGroup
+// @Summary Pin a profiling group
+// @Description Keep the profiling group forever, regardless of the retention policy
+// @Param groupId path string true "group ID"
+// @Security JwtAuth
+// @Success 200 {object} rest.EmptyResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/group/pin/{groupId} [post]
+func (s *Service) pinGroup(c *gin.Context) {
+	s.setGroupPinned(c, true)
+}
+
+// @ID unpinProfilingGroup
+// @Summary Unpin a profiling group
+// @Description Let the profiling group be removed by the retention policy
+// @Param groupId path string true "group ID"
+// @Security JwtAuth
+// @Success 200 {object} rest.EmptyResponse
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/group/unpin/{groupId} [post]
+func (s *Service) unpinGroup(c *gin.Context) {
+	s.setGroupPinned(c, false)
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"time"
+
+	"github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+)
+
+var _ = check.Suite(&testRetentionSuite{})
+
+type testRetentionSuite struct{}
+
+func (t *testRetentionSuite) TestRetentionCandidates(c *check.C) {
+	now := time.Unix(1700000000, 0)
+	hour := int64(time.Hour / time.Second)
+	usage := &StorageUsageResponse{
+		TotalBytes: 1000,
+		Groups: []GroupStorageUsage{
+			{ID: 5, State: TaskStateRunning, StartedAt: now.Unix(), Bytes: 0},
+			{ID: 4, State: TaskStateFinish, StartedAt: now.Unix() - 1*hour, Bytes: 100},
+			{ID: 3, State: TaskStateFinish, StartedAt: now.Unix() - 2*hour, Bytes: 200},
+			{ID: 2, State: TaskStateFinish, StartedAt: now.Unix() - 3*hour, Bytes: 300, Pinned: true},
+			{ID: 1, State: TaskStateError, StartedAt: now.Unix() - 4*hour, Bytes: 400},
+		},
+	}
+
+	cases := []struct {
+		cfg      config.ProfilingConfig
+		expected []uint
+	}{
+		{config.ProfilingConfig{}, nil},
+		{config.ProfilingConfig{RetentionMaxAgeSecs: uint(90 * time.Minute / time.Second)}, []uint{1, 3}},
+		{config.ProfilingConfig{RetentionMaxGroups: 4}, []uint{1}},
+		{config.ProfilingConfig{RetentionMaxGroups: 1}, []uint{1, 3, 4}},
+		{config.ProfilingConfig{RetentionMaxBytes: 500}, []uint{1, 3}},
+		{config.ProfilingConfig{RetentionMaxBytes: 700}, []uint{1}},
+		{config.ProfilingConfig{RetentionMaxBytes: 550, RetentionMaxGroups: 4}, []uint{1, 3}},
+	}
+	for _, cs := range cases {
+		c.Assert(retentionCandidates(usage, &cs.cfg, now), check.DeepEquals, cs.expected)
+	}
+}
@@ -8,10 +8,8 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,14 +25,23 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 	endpoint := r.Group("/profiling")
 	endpoint.GET("/group/list", auth.MWAuthRequired(), s.getGroupList)
 	endpoint.POST("/group/start", auth.MWAuthRequired(), s.handleStartGroup)
+	endpoint.POST("/group/import", auth.MWAuthRequired(), s.importGroupHandler)
 	endpoint.GET("/group/detail/:groupId", auth.MWAuthRequired(), s.getGroupDetail)
 	endpoint.POST("/group/cancel/:groupId", auth.MWAuthRequired(), s.handleCancelGroup)
 	endpoint.DELETE("/group/delete/:groupId", auth.MWAuthRequired(), s.deleteGroup)
+	endpoint.POST("/group/pin/:groupId", auth.MWAuthRequired(), s.pinGroup)
+	endpoint.POST("/group/unpin/:groupId", auth.MWAuthRequired(), s.unpinGroup)
+	endpoint.GET("/storage", auth.MWAuthRequired(), s.getStorageUsage)
+	endpoint.GET("/trigger/status", auth.MWAuthRequired(), s.getTriggerStatusHandler)
+	endpoint.POST("/binary/upload", auth.MWAuthRequired(), s.uploadBinary)
+	endpoint.GET("/binary/list", auth.MWAuthRequired(), s.getBinaryList)
+	endpoint.DELETE("/binary/delete/:binaryId", auth.MWAuthRequired(), s.deleteBinary)
 
 	endpoint.GET("/action_token", auth.MWAuthRequired(), s.getActionToken)
 	endpoint.GET("/group/download", s.downloadGroup)
 	endpoint.GET("/single/download", s.downloadSingle)
 	endpoint.GET("/single/view", s.viewSingle)
+	endpoint.GET("/diff", auth.MWAuthRequired(), s.viewDiff)
 
 	endpoint.GET("/config", auth.MWAuthRequired(), s.getDynamicConfig)
 	endpoint.PUT("/config", auth.MWAuthRequired(), auth.MWRequireWritePriv(), s.setDynamicConfig)
@@ -152,6 +159,7 @@ func (s *Service) getGroupDetail(c *gin.Context) {
 // @Success 200 {object} rest.EmptyResponse
 // @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
 // @Router /profiling/group/cancel/{groupId} [post]
 func (s *Service) handleCancelGroup(c *gin.Context) {
 	taskGroupID, err := strconv.Atoi(c.Param("groupId"))
@@ -159,6 +167,10 @@ func (s *Service) handleCancelGroup(c *gin.Context) {
 		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
 		return
 	}
+	if err := s.checkGroupCancellable(uint(taskGroupID)); err != nil {
+		rest.Error(c, err)
+		return
+	}
 	if err := s.cancelGroup(uint(taskGroupID)); err != nil {
 		rest.Error(c, err)
 		return
@@ -218,9 +230,9 @@ func (s *Service) downloadGroup(c *gin.Context) {
 		return
 	}
 
-	filePathes := make([]string, len(tasks))
-	for i, task := range tasks {
-		filePathes[i] = task.FilePath
+	filePathes := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		filePathes = append(filePathes, task.filePaths()...)
 	}
 
 	fileName := fmt.Sprintf("profiling_%s.zip", time.Now().Format("2006-01-02_15-04-05"))
@@ -282,7 +294,7 @@ func (s *Service) downloadSingle(c *gin.Context) {
 		_ = zw.Close()
 	}()
 
-	err = writeZipFromFiles(zw, []string{task.FilePath}, true)
+	err = writeZipFromFiles(zw, task.filePaths(), true)
 	if err != nil {
 		rest.Error(c, err)
 		return
@@ -347,6 +359,12 @@ $ go tool pprof --http=0.0.0.0:1234 cpu_xxx.proto
 
 To review the jemalloc profile data whose file name suffix is '.prof' interactively:
 $ jeprof --web profile_xxx.prof
+
+To review the jemalloc heap growth during profiling, whose baseline file name contains '_base':
+$ jeprof --web --base=heap_diff_xxx_base_xxx.prof heap_diff_xxx.prof
+
+To review the Go execution trace whose file name suffix is '.trace' interactively:
+$ go tool trace trace_xxx.trace
 `
 	zipFile, err := zw.CreateHeader(&zip.FileHeader{
 		Name:     "README.md",
@@ -376,8 +394,13 @@ const (
 // @Summary View the result of a task
 // @Description View the finished profiling result of a task
 // @Produce html
+// @Produce json
 // @Param token query string true "download token"
+// @Param output_type query string false "output type" Enums(protobuf, graph, text, folded, speedscope, top)
+// @Param limit query int false "number of functions returned in top output"
+// @Param binary_id query string false "uploaded binary to symbolize jemalloc heap profiles, the latest binary of the component by default"
 // @Security JwtAuth
+// @Success 200 {object} TopFunctionsResponse
 // @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
 // @Failure 500 {object} rest.ErrorResponse
@@ -408,72 +431,45 @@ func (s *Service) viewSingle(c *gin.Context) {
 		return
 	}
 
-	// set default content-type for legacy profiling content.
-	contentType := "image/svg+xml"
-
-	switch task.RawDataType {
-	case RawDataTypeProtobuf:
-		switch outputType {
-		case string(ViewOutputTypeGraph):
-			svgContent, err := convertProtobufToSVG(content, task)
-			if err != nil {
-				rest.Error(c, err)
-				return
-			}
-			content = svgContent
-			contentType = "image/svg+xml"
-		case string(ViewOutputTypeProtobuf):
-			contentType = "application/protobuf"
-		default:
-			// Will not handle converting protobuf to other formats except flamegraph and graph
-			rest.Error(c, rest.ErrBadRequest.New("Cannot output protobuf as %s", outputType))
-			return
-		}
-	case RawDataTypeJeprof:
-		// call jeprof to convert svg
-		switch outputType {
-		case string(ViewOutputTypeGraph):
-			cmd := exec.Command("perl", "/dev/stdin", "--dot", task.FilePath) //nolint:gosec
-			cmd.Stdin = strings.NewReader(jeprof)
-			dotContent, err := cmd.Output()
-			if err != nil {
-				rest.Error(c, err)
-				return
-			}
-			svgContent, err := convertDotToSVG(dotContent)
-			if err != nil {
-				rest.Error(c, err)
-				return
-			}
-			content = svgContent
-			contentType = "image/svg+xml"
-		case string(ViewOutputTypeText):
-			// Brendan Gregg's collapsed stack format
-			cmd := exec.Command("perl", "/dev/stdin", "--collapsed", task.FilePath) //nolint:gosec
-			cmd.Stdin = strings.NewReader(jeprof)
-			textContent, err := cmd.Output()
-			if err != nil {
-				rest.Error(c, err)
-				return
-			}
-			content = textContent
-			contentType = "text/plain"
-		default:
-			// Will not handle converting jeprof raw data to other formats except flamegraph and graph
-			rest.Error(c, rest.ErrBadRequest.New("Cannot output jeprof raw data as %s", outputType))
+	symbolizer, err := s.loadSymbolizer(task, c.Query("binary_id"))
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	switch ViewOutputType(outputType) {
+	case ViewOutputTypeFolded, ViewOutputTypeSpeedscope, ViewOutputTypeTop:
+		fp, err := foldProfile(task, content, symbolizer)
+		if err != nil {
+			rest.Error(c, err)
 			return
 		}
-	case RawDataTypeText:
-		switch outputType {
-		case string(ViewOutputTypeText):
-			contentType = "text/plain"
-		default:
-			// Will not handle converting text to other formats
-			rest.Error(c, rest.ErrBadRequest.New("Cannot output text as %s", outputType))
+		s.writeFoldedProfile(c, fp, task, ViewOutputType(outputType))
+		return
+	}
+
+	content, contentType, err := convertProfile(task, content, ViewOutputType(outputType), symbolizer)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, content)
+}
+
+func (s *Service) writeFoldedProfile(c *gin.Context, fp *foldedProfile, task TaskModel, outputType ViewOutputType) {
+	switch outputType {
+	case ViewOutputTypeFolded:
+		c.Data(http.StatusOK, "text/plain", fp.Folded())
+	case ViewOutputTypeSpeedscope:
+		c.JSON(http.StatusOK, fp.Speedscope(fmt.Sprintf("%s %s", task.ProfilingType, task.Target.String())))
+	case ViewOutputTypeTop:
+		limit, err := ParseTopLimit(c)
+		if err != nil {
+			rest.Error(c, err)
 			return
 		}
+		c.JSON(http.StatusOK, fp.Top(limit))
 	}
-	c.Data(http.StatusOK, contentType, content)
 }
 
 // @ID deleteProfilingGroup
@@ -484,6 +480,7 @@ func (s *Service) viewSingle(c *gin.Context) {
 // @Success 200 {object} rest.EmptyResponse
 // @Failure 400 {object} rest.ErrorResponse
 // @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
 // @Failure 500 {object} rest.ErrorResponse
 // @Router /profiling/group/delete/{groupId} [delete]
 func (s *Service) deleteGroup(c *gin.Context) {
@@ -492,16 +489,16 @@ func (s *Service) deleteGroup(c *gin.Context) {
 		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
 		return
 	}
-	if err := s.cancelGroup(uint(taskGroupID)); err != nil {
+	if _, err := s.findGroup(uint(taskGroupID)); err != nil {
 		rest.Error(c, err)
 		return
 	}
-
-	if err = s.params.LocalStore.Where("task_group_id = ?", taskGroupID).Delete(&TaskModel{}).Error; err != nil {
+	if err := s.cancelGroup(uint(taskGroupID)); err != nil {
 		rest.Error(c, err)
 		return
 	}
-	if err = s.params.LocalStore.Where("id = ?", taskGroupID).Delete(&TaskGroupModel{}).Error; err != nil {
+
+	if err := s.removeGroup(uint(taskGroupID)); err != nil {
 		rest.Error(c, err)
 		return
 	}
@@ -524,6 +521,7 @@ func (s *Service) getDynamicConfig(c *gin.Context) {
 }
 
 // @Summary Set Profiling Dynamic Config
+// @Description Fields omitted in the request body keep their current values, e.g. the triggers and the retention policy are kept when only the automatic collection is set
 // @Param request body config.ProfilingConfig true "Request body"
 // @Success 200 {object} config.ProfilingConfig
 // @Router /profiling/config [put]
@@ -532,11 +530,21 @@ func (s *Service) getDynamicConfig(c *gin.Context) {
 // @Failure 401 {object} rest.ErrorResponse
 // @Failure 500 {object} rest.ErrorResponse
 func (s *Service) setDynamicConfig(c *gin.Context) {
-	var req config.ProfilingConfig
+	dc, err := s.params.ConfigManager.Get()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	// Decode onto the current config, so that omitted fields are kept.
+	req := dc.Profiling
 	if err := c.ShouldBindJSON(&req); err != nil {
 		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
 		return
 	}
+	if err := validateTriggers(req.Triggers); err != nil {
+		rest.Error(c, err)
+		return
+	}
 	var opt config.DynamicConfigOption = func(dc *config.DynamicConfig) {
 		dc.Profiling = req
 	}
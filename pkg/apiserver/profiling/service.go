@@ -4,6 +4,7 @@ package profiling
 
 import (
 	"context"
+	"os"
 	"sync"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/metrics"
 	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
 	"github.com/pingcap/tidb-dashboard/pkg/config"
 	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
@@ -29,12 +31,15 @@ var (
 	ErrTimeout                    = ErrNS.NewType("timeout")
 	ErrUnsupportedProfilingType   = ErrNS.NewType("unsupported_profiling_type")
 	ErrUnsupportedProfilingTarget = ErrNS.NewType("unsupported_profiling_target")
+	ErrIncomparableProfiles       = ErrNS.NewType("incomparable_profiles")
+	ErrInvalidProfile             = ErrNS.NewType("invalid_profile")
 )
 
 type StartRequest struct {
 	Targets                []model.RequestTargetNode `json:"targets"`
 	DurationSecs           uint                      `json:"duration_secs"`
 	RequstedProfilingTypes TaskProfilingTypeList     `json:"requsted_profiling_types"`
+	triggerName            string
 }
 
 type StartRequestSession struct {
@@ -46,11 +51,13 @@ type StartRequestSession struct {
 
 type ServiceParams struct {
 	fx.In
+	Config        *config.Config
 	ConfigManager *config.DynamicConfigManager
 	LocalStore    *dbstore.DB
 
-	EtcdClient *clientv3.Client
-	PDClient   *pd.Client
+	EtcdClient     *clientv3.Client
+	PDClient       *pd.Client
+	MetricsService *metrics.Service
 }
 
 type Service struct {
@@ -62,19 +69,30 @@ type Service struct {
 	lastTaskGroup *TaskGroup
 	tasks         sync.Map
 	fetchers      *fetchers
+	symbolizers   symbolizerCache
+
+	triggerMu       sync.Mutex
+	triggerStatuses map[string]*TriggerStatus
 }
 
 var newService = fx.Provide(func(lc fx.Lifecycle, p ServiceParams, fts *fetchers) (*Service, error) {
 	if err := autoMigrate(p.LocalStore); err != nil {
 		return nil, err
 	}
-	s := &Service{params: p, fetchers: fts}
+	// The channel is never closed, since requests can be submitted by both the API and the trigger loop.
+	s := &Service{params: p, fetchers: fts, sessionCh: make(chan *StartRequestSession, 1000)}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			s.lifecycleCtx = ctx
 			s.wg.Go(func() {
 				s.serviceLoop(ctx)
 			})
+			s.wg.Go(func() {
+				s.triggerLoop(ctx)
+			})
+			s.wg.Go(func() {
+				s.retentionLoop(ctx)
+			})
 			return nil
 		},
 		OnStop: func(context.Context) error {
@@ -88,8 +106,6 @@ var newService = fx.Provide(func(lc fx.Lifecycle, p ServiceParams, fts *fetchers
 
 func (s *Service) serviceLoop(ctx context.Context) {
 	cfgCh := s.params.ConfigManager.NewPushChannel()
-	s.sessionCh = make(chan *StartRequestSession, 1000)
-	defer close(s.sessionCh)
 
 	var dc *config.DynamicConfig
 	var timeCh <-chan time.Time = make(chan time.Time, 1)
@@ -130,14 +146,36 @@ func (s *Service) serviceLoop(ctx context.Context) {
 
 func (s *Service) handleRequest(ctx context.Context, session *StartRequestSession, dc *config.DynamicConfig) {
 	defer close(session.ch)
-	if dc.Profiling.AutoCollectionDurationSecs > 0 {
+	if dc != nil && dc.Profiling.AutoCollectionDurationSecs > 0 {
 		session.err = ErrIgnoredRequest.New("automatic collection is enabled")
 		log.Warn("request is ignored", zap.Error(session.err))
 		return
 	}
+	if session.req.triggerName != "" {
+		// Triggers never preempt groups started by users.
+		if err := s.checkNoRunningUserGroup(); err != nil {
+			session.err = err
+			log.Info("triggered request is skipped", zap.String("trigger", session.req.triggerName), zap.Error(err))
+			return
+		}
+		session.taskGroup, session.err = s.startGroup(ctx, &session.req)
+		return
+	}
 	session.taskGroup, session.err = s.exclusiveExecute(ctx, &session.req)
 }
 
+// checkNoRunningUserGroup returns an error if a group started by the user is running.
+func (s *Service) checkNoRunningUserGroup() error {
+	var groups []TaskGroupModel
+	if err := s.params.LocalStore.Where("trigger_name = '' AND state = ?", TaskStateRunning).Limit(1).Find(&groups).Error; err != nil {
+		return err
+	}
+	if len(groups) > 0 {
+		return ErrIgnoredRequest.New("task group %d started by the user is running", groups[0].ID)
+	}
+	return nil
+}
+
 func (s *Service) exclusiveExecute(ctx context.Context, req *StartRequest) (*TaskGroup, error) {
 	if s.lastTaskGroup != nil {
 		if err := s.cancelGroup(s.lastTaskGroup.ID); err != nil {
@@ -149,7 +187,7 @@ func (s *Service) exclusiveExecute(ctx context.Context, req *StartRequest) (*Tas
 }
 
 func (s *Service) startGroup(ctx context.Context, req *StartRequest) (*TaskGroup, error) {
-	taskGroup := NewTaskGroup(s.params.LocalStore, req.DurationSecs, model.NewRequestTargetStatisticsFromArray(&req.Targets), req.RequstedProfilingTypes)
+	taskGroup := NewTaskGroup(s.params.LocalStore, req.DurationSecs, model.NewRequestTargetStatisticsFromArray(&req.Targets), req.RequstedProfilingTypes, req.triggerName)
 	if err := s.params.LocalStore.Create(taskGroup.TaskGroupModel).Error; err != nil {
 		log.Warn("failed to start task group", zap.Error(err))
 		return nil, err
@@ -238,3 +276,26 @@ func (s *Service) cancelGroup(taskGroupID uint) error {
 
 	return nil
 }
+
+// removeGroup deletes a finished task group with its tasks and profile files.
+func (s *Service) removeGroup(taskGroupID uint) error {
+	var tasks []TaskModel
+	if err := s.params.LocalStore.Where("task_group_id = ?", taskGroupID).Find(&tasks).Error; err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		for _, file := range task.filePaths() {
+			if file == "" {
+				continue
+			}
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				log.Warn("failed to remove profile file", zap.String("file", file), zap.Error(err))
+			}
+		}
+	}
+
+	if err := s.params.LocalStore.Where("task_group_id = ?", taskGroupID).Delete(&TaskModel{}).Error; err != nil {
+		return err
+	}
+	return s.params.LocalStore.Where("id = ?", taskGroupID).Delete(&TaskGroupModel{}).Error
+}
@@ -0,0 +1,203 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/google/pprof/profile"
+	"github.com/ianlancetaylor/demangle"
+)
+
+// rustHashSuffixRE matches the hash appended to legacy mangled Rust symbols, e.g. "::h0123456789abcdef".
+var rustHashSuffixRE = regexp.MustCompile(`::h[0-9a-f]{16}$`)
+
+func demangleName(name string) string {
+	return rustHashSuffixRE.ReplaceAllString(demangle.Filter(name, demangle.NoParams), "")
+}
+
+type sourceLine struct {
+	fileName string
+	line     int64
+}
+
+// elfSymbolizer resolves addresses into function names and source lines with the symbol table and the
+// DWARF debug info of an ELF binary, which can either be the executable itself or its separate debuginfo file.
+// It is cached and shared by concurrent requests, so the DWARF debug info is read with the lock held.
+type elfSymbolizer struct {
+	mu       sync.Mutex
+	fileType elf.Type
+	buildID  string
+	loads    []elf.ProgHeader
+	symbols  []elf.Symbol // sorted by address
+	dwarf    *dwarf.Data  // nil if there is no debug info
+}
+
+func newELFSymbolizer(filePath string) (*elfSymbolizer, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // #nosec
+
+	s := &elfSymbolizer{fileType: f.Type, buildID: elfBuildID(f)}
+	for _, p := range f.Progs {
+		if p.Type == elf.PT_LOAD && p.Flags&elf.PF_X != 0 {
+			s.loads = append(s.loads, p.ProgHeader)
+		}
+	}
+	symbols, _ := f.Symbols()
+	dynSymbols, _ := f.DynamicSymbols()
+	for _, sym := range append(symbols, dynSymbols...) {
+		if elf.ST_TYPE(sym.Info) == elf.STT_FUNC && sym.Value != 0 {
+			s.symbols = append(s.symbols, sym)
+		}
+	}
+	sort.Slice(s.symbols, func(i, j int) bool {
+		return s.symbols[i].Value < s.symbols[j].Value
+	})
+	if d, err := f.DWARF(); err == nil {
+		s.dwarf = d
+	}
+	return s, nil
+}
+
+// elfBuildID returns the GNU build ID of the ELF file, or an empty string if there is none.
+func elfBuildID(f *elf.File) string {
+	section := f.Section(".note.gnu.build-id")
+	if section == nil {
+		return ""
+	}
+	data, err := section.Data()
+	// The note consists of namesz, descsz and type, followed by the name "GNU\0" and the build ID.
+	if err != nil || len(data) < 16 {
+		return ""
+	}
+	nameSize := f.ByteOrder.Uint32(data[0:4])
+	descSize := f.ByteOrder.Uint32(data[4:8])
+	descStart := 12 + (nameSize+3)&^3
+	if uint64(descStart)+uint64(descSize) > uint64(len(data)) {
+		return ""
+	}
+	return hex.EncodeToString(data[descStart : descStart+descSize])
+}
+
+// toVirtualAddress translates the runtime address into the virtual address in the ELF file,
+// since position independent executables are loaded at random addresses.
+func (s *elfSymbolizer) toVirtualAddress(addr uint64, m *profile.Mapping) uint64 {
+	if m == nil || s.fileType == elf.ET_EXEC {
+		return addr
+	}
+	offset := addr - m.Start + m.Offset
+	for _, p := range s.loads {
+		if offset >= p.Off && offset < p.Off+p.Filesz {
+			return offset - p.Off + p.Vaddr
+		}
+	}
+	return addr
+}
+
+func (s *elfSymbolizer) functionName(addr uint64) (string, bool) {
+	i := sort.Search(len(s.symbols), func(i int) bool {
+		return s.symbols[i].Value > addr
+	}) - 1
+	if i < 0 {
+		return "", false
+	}
+	sym := s.symbols[i]
+	if sym.Size != 0 && addr >= sym.Value+sym.Size {
+		return "", false
+	}
+	return demangleName(sym.Name), true
+}
+
+// sourceLines resolves the sorted addresses into source lines with a single pass of the DWARF line tables,
+// which can be very large for binaries like TiKV.
+func (s *elfSymbolizer) sourceLines(addrs []uint64) map[uint64]sourceLine {
+	result := make(map[uint64]sourceLine)
+	if s.dwarf == nil || len(addrs) == 0 {
+		return result
+	}
+	r := s.dwarf.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagCompileUnit {
+			r.SkipChildren()
+			continue
+		}
+		lr, err := s.dwarf.LineReader(entry)
+		r.SkipChildren()
+		if err != nil || lr == nil {
+			continue
+		}
+		var prev, cur dwarf.LineEntry
+		hasPrev := false
+		for lr.Next(&cur) == nil {
+			// Each row covers the addresses from its own address to the address of the next row.
+			if hasPrev && !prev.EndSequence && cur.Address > prev.Address && prev.File != nil {
+				i := sort.Search(len(addrs), func(i int) bool {
+					return addrs[i] >= prev.Address
+				})
+				for ; i < len(addrs) && addrs[i] < cur.Address; i++ {
+					result[addrs[i]] = sourceLine{fileName: prev.File.Name, line: int64(prev.Line)}
+				}
+			}
+			prev, hasPrev = cur, true
+		}
+	}
+	return result
+}
+
+// symbolize fills the locations of the main binary with function names and source lines.
+func (s *elfSymbolizer) symbolize(p *profile.Profile) {
+	var mainMapping *profile.Mapping
+	if len(p.Mapping) > 0 {
+		mainMapping = p.Mapping[0]
+	}
+	var locations []*profile.Location
+	addrs := make([]uint64, 0, len(p.Location))
+	for _, loc := range p.Location {
+		if loc.Mapping != mainMapping {
+			continue
+		}
+		locations = append(locations, loc)
+		addrs = append(addrs, s.toVirtualAddress(loc.Address, loc.Mapping))
+	}
+	sortedAddrs := make([]uint64, len(addrs))
+	copy(sortedAddrs, addrs)
+	sort.Slice(sortedAddrs, func(i, j int) bool {
+		return sortedAddrs[i] < sortedAddrs[j]
+	})
+	s.mu.Lock()
+	lines := s.sourceLines(sortedAddrs)
+	s.mu.Unlock()
+
+	functions := make(map[string]*profile.Function)
+	for _, f := range p.Function {
+		functions[f.Name+"\x00"+f.Filename] = f
+	}
+	for i, loc := range locations {
+		name, ok := s.functionName(addrs[i])
+		if !ok {
+			continue
+		}
+		line := lines[addrs[i]]
+		loc.Line = []profile.Line{{Function: getFunction(p, functions, name, line.fileName), Line: line.line}}
+	}
+	if mainMapping != nil {
+		mainMapping.HasFunctions = true
+		mainMapping.HasFilenames = s.dwarf != nil
+		mainMapping.HasLineNumbers = s.dwarf != nil
+		if mainMapping.BuildID == "" {
+			mainMapping.BuildID = s.buildID
+		}
+	}
+}
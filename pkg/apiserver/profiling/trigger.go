@@ -0,0 +1,333 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/metrics"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	triggerEvaluateInterval = 30 * time.Second
+	triggerInstanceLabel    = "instance"
+)
+
+// instanceState tracks a trigger condition on a single instance.
+type instanceState struct {
+	exceededSince time.Time // zero if the value does not exceed the threshold
+	lastFiredAt   time.Time
+}
+
+// triggerEvaluator remembers how long each instance has exceeded the threshold of each trigger.
+type triggerEvaluator struct {
+	states map[string]map[string]*instanceState // trigger name -> instance -> state
+}
+
+func newTriggerEvaluator() *triggerEvaluator {
+	return &triggerEvaluator{states: make(map[string]map[string]*instanceState)}
+}
+
+// prune forgets the triggers which are no longer configured.
+func (e *triggerEvaluator) prune(triggers []config.ProfilingTriggerConfig) {
+	names := make(map[string]struct{}, len(triggers))
+	for _, t := range triggers {
+		names[t.Name] = struct{}{}
+	}
+	for name := range e.states {
+		if _, ok := names[name]; !ok {
+			delete(e.states, name)
+		}
+	}
+}
+
+// evaluate returns the targets to be profiled according to the latest metric samples of the trigger. The targets
+// are returned again in later evaluations until fired is called, so that a skipped firing is retried.
+func (e *triggerEvaluator) evaluate(t *config.ProfilingTriggerConfig, samples []metrics.VectorSample, now time.Time) []model.RequestTargetNode {
+	states, ok := e.states[t.Name]
+	if !ok {
+		states = make(map[string]*instanceState)
+		e.states[t.Name] = states
+	}
+
+	cooldown := time.Duration(t.CooldownSecs) * time.Second
+	if t.CooldownSecs == 0 {
+		cooldown = config.DefaultProfilingTriggerCooldownSecs * time.Second
+	}
+
+	exceeded := make(map[string]struct{}, len(samples))
+	var targets []model.RequestTargetNode
+	for _, sample := range samples {
+		instance := sample.Metric[triggerInstanceLabel]
+		if instance == "" || sample.Value <= t.Threshold {
+			continue
+		}
+		exceeded[instance] = struct{}{}
+		state, ok := states[instance]
+		if !ok {
+			state = &instanceState{}
+			states[instance] = state
+		}
+		if state.exceededSince.IsZero() {
+			state.exceededSince = now
+		}
+		if now.Sub(state.exceededSince) < time.Duration(t.ForSecs)*time.Second {
+			continue
+		}
+		if !state.lastFiredAt.IsZero() && now.Sub(state.lastFiredAt) < cooldown {
+			continue
+		}
+		target, err := newTriggerTarget(t.Component, instance)
+		if err != nil {
+			log.Warn("invalid instance of profiling trigger", zap.String("trigger", t.Name), zap.String("instance", instance), zap.Error(err))
+			continue
+		}
+		targets = append(targets, target)
+	}
+
+	for instance, state := range states {
+		if _, ok := exceeded[instance]; !ok {
+			state.exceededSince = time.Time{}
+		}
+	}
+	return targets
+}
+
+// fired starts the cooldown of the targets.
+func (e *triggerEvaluator) fired(name string, targets []model.RequestTargetNode, now time.Time) {
+	for _, target := range targets {
+		if state, ok := e.states[name][target.DisplayName]; ok {
+			state.lastFiredAt = now
+			state.exceededSince = time.Time{}
+		}
+	}
+}
+
+func newTriggerTarget(kind model.NodeKind, instance string) (model.RequestTargetNode, error) {
+	host, portStr, err := net.SplitHostPort(instance)
+	if err != nil {
+		return model.RequestTargetNode{}, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return model.RequestTargetNode{}, err
+	}
+	return model.RequestTargetNode{
+		Kind:        kind,
+		DisplayName: instance,
+		IP:          host,
+		Port:        port,
+	}, nil
+}
+
+func (s *Service) triggerLoop(ctx context.Context) {
+	cfgCh := s.params.ConfigManager.NewPushChannel()
+	ticker := time.NewTicker(triggerEvaluateInterval)
+	defer ticker.Stop()
+
+	evaluator := newTriggerEvaluator()
+	var profilingConfig config.ProfilingConfig
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case dc, ok := <-cfgCh:
+			if !ok {
+				return
+			}
+			profilingConfig = dc.Profiling
+			evaluator.prune(profilingConfig.Triggers)
+		case <-ticker.C:
+			if len(profilingConfig.Triggers) == 0 {
+				continue
+			}
+			started := false
+			for i := range profilingConfig.Triggers {
+				if s.evaluateTrigger(ctx, evaluator, &profilingConfig.Triggers[i]) {
+					started = true
+				}
+			}
+			if started {
+				s.removeExceededTriggeredGroups(profilingConfig.TriggerMaxGroups)
+			}
+		}
+	}
+}
+
+// evaluateTrigger queries the metric of the trigger, and starts a profiling group if needed.
+func (s *Service) evaluateTrigger(ctx context.Context, evaluator *triggerEvaluator, t *config.ProfilingTriggerConfig) bool {
+	samples, err := s.params.MetricsService.QueryInstant(ctx, t.Query)
+	if err != nil {
+		log.Warn("failed to evaluate profiling trigger", zap.String("trigger", t.Name), zap.Error(err))
+		return false
+	}
+	targets := evaluator.evaluate(t, samples, time.Now())
+	if len(targets) == 0 {
+		return false
+	}
+
+	durationSecs := t.DurationSecs
+	if durationSecs == 0 {
+		durationSecs = config.DefaultProfilingAutoCollectionDurationSecs
+	}
+	profilingTypes := make(TaskProfilingTypeList, 0, len(t.ProfilingTypes))
+	for _, typ := range t.ProfilingTypes {
+		profilingTypes = append(profilingTypes, TaskProfilingType(typ))
+	}
+	log.Info("profiling trigger fired", zap.String("trigger", t.Name), zap.Any("targets", targets))
+	taskGroup, err := s.submitRequest(ctx, StartRequest{
+		Targets:                targets,
+		DurationSecs:           durationSecs,
+		RequstedProfilingTypes: profilingTypes,
+		triggerName:            t.Name,
+	})
+	if err != nil {
+		// The targets are not in cooldown, so the trigger fires again in the next evaluation if the condition holds.
+		log.Warn("failed to start profiling by trigger", zap.String("trigger", t.Name), zap.Error(err))
+		s.recordTriggerSkipped(t.Name, err)
+		return false
+	}
+	evaluator.fired(t.Name, targets, time.Now())
+	s.recordTriggerFired(t.Name, taskGroup.ID)
+	return true
+}
+
+// submitRequest starts a profiling group through the service loop, like the requests from the API. Unlike the
+// requests from the API, it does not preempt running groups, see handleRequest.
+func (s *Service) submitRequest(ctx context.Context, req StartRequest) (*TaskGroup, error) {
+	session := &StartRequestSession{
+		req: req,
+		ch:  make(chan struct{}, 1),
+	}
+	select {
+	case s.sessionCh <- session:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case <-session.ch:
+		return session.taskGroup, session.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// validateTriggers checks the component and profiling types of the triggers, which cannot be checked by the config
+// package.
+func validateTriggers(triggers []config.ProfilingTriggerConfig) error {
+	for _, t := range triggers {
+		supportedTypes, ok := supportedProfilingTypes(t.Component)
+		if !ok {
+			return rest.ErrBadRequest.New("component %s of trigger %s cannot be profiled", t.Component, t.Name)
+		}
+		for _, typ := range t.ProfilingTypes {
+			if _, ok := supportedTypes[TaskProfilingType(typ)]; !ok {
+				return rest.ErrBadRequest.New("profiling type %s of trigger %s is not supported by %s", typ, t.Name, t.Component)
+			}
+		}
+	}
+	return nil
+}
+
+// removeExceededTriggeredGroups deletes the oldest finished groups started by triggers beyond the limit.
+func (s *Service) removeExceededTriggeredGroups(maxGroups uint) {
+	if maxGroups == 0 {
+		maxGroups = config.DefaultProfilingTriggerMaxGroups
+	}
+	var groups []TaskGroupModel
+	err := s.params.LocalStore.
+		Where("trigger_name <> '' AND state <> ? AND pinned = ?", TaskStateRunning, false).
+		Order("id DESC").
+		Offset(int(maxGroups)).
+		Find(&groups).Error
+	if err != nil {
+		log.Warn("failed to list triggered profiling groups", zap.Error(err))
+		return
+	}
+	for _, group := range groups {
+		if err := s.removeGroup(group.ID); err != nil {
+			log.Warn("failed to remove triggered profiling group", zap.Uint("id", group.ID), zap.Error(err))
+		}
+	}
+}
+
+// TriggerStatus is the latest result of a profiling trigger.
+type TriggerStatus struct {
+	Name        string `json:"name"`
+	LastFiredAt int64  `json:"last_fired_at,omitempty"`
+	LastGroupID uint   `json:"last_group_id,omitempty"`
+	// The last time the trigger fired but no profiling was started, and the reason
+	LastSkippedAt  int64  `json:"last_skipped_at,omitempty"`
+	LastSkipReason string `json:"last_skip_reason,omitempty"`
+}
+
+func (s *Service) triggerStatus(name string) *TriggerStatus {
+	if s.triggerStatuses == nil {
+		s.triggerStatuses = make(map[string]*TriggerStatus)
+	}
+	status, ok := s.triggerStatuses[name]
+	if !ok {
+		status = &TriggerStatus{Name: name}
+		s.triggerStatuses[name] = status
+	}
+	return status
+}
+
+func (s *Service) recordTriggerFired(name string, taskGroupID uint) {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+	status := s.triggerStatus(name)
+	status.LastFiredAt = time.Now().Unix()
+	status.LastGroupID = taskGroupID
+}
+
+func (s *Service) recordTriggerSkipped(name string, err error) {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+	status := s.triggerStatus(name)
+	status.LastSkippedAt = time.Now().Unix()
+	status.LastSkipReason = err.Error()
+	if e := errorx.Cast(err); e != nil {
+		status.LastSkipReason = e.Message()
+	}
+}
+
+// getTriggerStatuses returns the statuses of the configured triggers.
+func (s *Service) getTriggerStatuses(triggers []config.ProfilingTriggerConfig) []TriggerStatus {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+	statuses := make([]TriggerStatus, 0, len(triggers))
+	for _, t := range triggers {
+		statuses = append(statuses, *s.triggerStatus(t.Name))
+	}
+	return statuses
+}
+
+// @ID getProfilingTriggerStatus
+// @Summary Get the status of profiling triggers
+// @Description Get when each trigger started profiling last time, and when and why it was skipped last time
+// @Security JwtAuth
+// @Success 200 {array} TriggerStatus
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /profiling/trigger/status [get]
+func (s *Service) getTriggerStatusHandler(c *gin.Context) {
+	dc, err := s.params.ConfigManager.Get()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.getTriggerStatuses(dc.Profiling.Triggers))
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/check"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/metrics"
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+var _ = check.Suite(&testTriggerSuite{})
+
+type testTriggerSuite struct{}
+
+func newSamples(values map[string]float64) []metrics.VectorSample {
+	samples := make([]metrics.VectorSample, 0, len(values))
+	for instance, v := range values {
+		samples = append(samples, metrics.VectorSample{Metric: map[string]string{"instance": instance}, Value: v})
+	}
+	return samples
+}
+
+func (t *testTriggerSuite) TestEvaluate(c *check.C) {
+	trigger := &config.ProfilingTriggerConfig{
+		Name:         "tidb_cpu",
+		Component:    model.NodeKindTiDB,
+		Threshold:    0.8,
+		ForSecs:      120,
+		CooldownSecs: 600,
+	}
+	e := newTriggerEvaluator()
+	now := time.Unix(1700000000, 0)
+
+	c.Assert(e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9, "127.0.0.2:10080": 0.5}), now), check.HasLen, 0)
+	c.Assert(e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9}), now.Add(time.Minute)), check.HasLen, 0)
+	targets := e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9}), now.Add(2*time.Minute))
+	c.Assert(targets, check.DeepEquals, []model.RequestTargetNode{
+		{Kind: model.NodeKindTiDB, DisplayName: "127.0.0.1:10080", IP: "127.0.0.1", Port: 10080},
+	})
+
+	// Skipped firings are retried
+	c.Assert(e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9}), now.Add(2*time.Minute+30*time.Second)), check.HasLen, 1)
+	e.fired(trigger.Name, targets, now.Add(2*time.Minute))
+
+	// Cooldown
+	c.Assert(e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9}), now.Add(3*time.Minute)), check.HasLen, 0)
+	c.Assert(e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9}), now.Add(5*time.Minute)), check.HasLen, 0)
+
+	// The value must exceed the threshold continuously
+	c.Assert(e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.1}), now.Add(11*time.Minute)), check.HasLen, 0)
+	c.Assert(e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9}), now.Add(12*time.Minute)), check.HasLen, 0)
+	targets = e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9}), now.Add(14*time.Minute))
+	c.Assert(targets, check.HasLen, 1)
+	e.fired(trigger.Name, targets, now.Add(14*time.Minute))
+	c.Assert(e.evaluate(trigger, newSamples(map[string]float64{"127.0.0.1:10080": 0.9}), now.Add(15*time.Minute)), check.HasLen, 0)
+
+	e.prune(nil)
+	c.Assert(e.states, check.HasLen, 0)
+}
+
+func (t *testTriggerSuite) TestValidateTriggers(c *check.C) {
+	c.Assert(validateTriggers([]config.ProfilingTriggerConfig{
+		{Name: "tidb_cpu", Component: model.NodeKindTiDB, ProfilingTypes: []string{"cpu", "wallclock"}},
+		{Name: "tikv_cpu", Component: model.NodeKindTiKV, ProfilingTypes: []string{"cpu", "heap"}},
+	}), check.IsNil)
+	c.Assert(validateTriggers([]config.ProfilingTriggerConfig{
+		{Name: "unknown", Component: "tidbx", ProfilingTypes: []string{"cpu"}},
+	}), check.NotNil)
+	c.Assert(validateTriggers([]config.ProfilingTriggerConfig{
+		{Name: "tikv_goroutine", Component: model.NodeKindTiKV, ProfilingTypes: []string{"goroutine"}},
+	}), check.NotNil)
+}
+
+func (t *testTriggerSuite) TestRemoveExceededTriggeredGroups(c *check.C) {
+	gormDB, err := gorm.Open(sqlite.Open(path.Join(c.MkDir(), "test.sqlite.db")), &gorm.Config{})
+	c.Assert(err, check.IsNil)
+	db := &dbstore.DB{DB: gormDB}
+	c.Assert(autoMigrate(db), check.IsNil)
+	s := &Service{params: ServiceParams{LocalStore: db}}
+
+	for i, triggerName := range []string{"", "a", "a", "b", "a"} {
+		group := NewTaskGroup(db, 1, model.RequestTargetStatistics{}, nil, triggerName)
+		group.State = TaskStateFinish
+		if i == 1 {
+			group.State = TaskStateRunning
+		}
+		c.Assert(db.Create(group.TaskGroupModel).Error, check.IsNil)
+		c.Assert(db.Create(&TaskModel{TaskGroupID: group.ID, State: TaskStateFinish}).Error, check.IsNil)
+	}
+
+	s.removeExceededTriggeredGroups(2)
+	var groups []TaskGroupModel
+	c.Assert(db.Order("id").Find(&groups).Error, check.IsNil)
+	ids := make([]uint, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+	c.Assert(ids, check.DeepEquals, []uint{1, 2, 4, 5})
+	var tasks int64
+	c.Assert(db.Model(&TaskModel{}).Count(&tasks).Error, check.IsNil)
+	c.Assert(tasks, check.Equals, int64(4))
+}
+
+func (t *testTriggerSuite) TestHandleTriggeredRequest(c *check.C) {
+	gormDB, err := gorm.Open(sqlite.Open(path.Join(c.MkDir(), "test.sqlite.db")), &gorm.Config{})
+	c.Assert(err, check.IsNil)
+	db := &dbstore.DB{DB: gormDB}
+	c.Assert(autoMigrate(db), check.IsNil)
+	s := &Service{params: ServiceParams{LocalStore: db}}
+
+	userGroup := NewTaskGroup(db, 1, model.RequestTargetStatistics{}, nil, "")
+	c.Assert(db.Create(userGroup.TaskGroupModel).Error, check.IsNil)
+
+	session := &StartRequestSession{req: StartRequest{triggerName: "tidb_cpu"}, ch: make(chan struct{}, 1)}
+	s.handleRequest(context.Background(), session, nil)
+	c.Assert(errorx.IsOfType(session.err, ErrIgnoredRequest), check.IsTrue)
+	c.Assert(session.taskGroup, check.IsNil)
+	userGroup.State = TaskStateFinish
+	c.Assert(db.Save(userGroup.TaskGroupModel).Error, check.IsNil)
+	c.Assert(s.checkNoRunningUserGroup(), check.IsNil)
+
+	session = &StartRequestSession{req: StartRequest{triggerName: "tidb_cpu"}, ch: make(chan struct{}, 1)}
+	s.handleRequest(context.Background(), session, &config.DynamicConfig{Profiling: config.ProfilingConfig{AutoCollectionDurationSecs: 30}})
+	c.Assert(errorx.IsOfType(session.err, ErrIgnoredRequest), check.IsTrue)
+
+	s.recordTriggerSkipped("tidb_cpu", session.err)
+	s.recordTriggerFired("tikv_cpu", 3)
+	statuses := s.getTriggerStatuses([]config.ProfilingTriggerConfig{{Name: "tidb_cpu"}, {Name: "tikv_cpu"}})
+	c.Assert(statuses, check.HasLen, 2)
+	c.Assert(statuses[0].LastSkipReason, check.Equals, "automatic collection is enabled")
+	c.Assert(statuses[0].LastFiredAt, check.Equals, int64(0))
+	c.Assert(statuses[1].LastGroupID, check.Equals, uint(3))
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+// ConvertProfile converts the raw data of a finished task into the given output type,
+// and returns the converted content with its content type.
+func ConvertProfile(task TaskModel, content []byte, outputType ViewOutputType) ([]byte, string, error) {
+	return convertProfile(task, content, outputType, nil)
+}
+
+func convertProfile(task TaskModel, content []byte, outputType ViewOutputType, symbolizer *elfSymbolizer) ([]byte, string, error) {
+	switch task.RawDataType {
+	case RawDataTypeProtobuf:
+		switch outputType {
+		case ViewOutputTypeGraph:
+			svgContent, err := convertProtobufToSVG(content, task)
+			if err != nil {
+				return nil, "", err
+			}
+			return svgContent, "image/svg+xml", nil
+		case ViewOutputTypeProtobuf:
+			return content, "application/protobuf", nil
+		default:
+			// Will not handle converting protobuf to other formats except flamegraph and graph
+			return nil, "", rest.ErrBadRequest.New("Cannot output protobuf as %s", outputType)
+		}
+	case RawDataTypeJeprof:
+		switch outputType {
+		case ViewOutputTypeGraph, ViewOutputTypeProtobuf:
+			pbContent, err := jemallocToProtobuf(task, content, symbolizer)
+			if err != nil {
+				return nil, "", err
+			}
+			task.RawDataType = RawDataTypeProtobuf
+			return convertProfile(task, pbContent, outputType, nil)
+		case ViewOutputTypeText:
+			// Brendan Gregg's collapsed stack format
+			fp, err := foldProfile(task, content, symbolizer)
+			if err != nil {
+				return nil, "", err
+			}
+			return fp.Folded(), "text/plain", nil
+		default:
+			// Will not handle converting jeprof raw data to other formats except flamegraph and graph
+			return nil, "", rest.ErrBadRequest.New("Cannot output jeprof raw data as %s", outputType)
+		}
+	case RawDataTypeText:
+		switch outputType {
+		case ViewOutputTypeText:
+			return content, "text/plain", nil
+		default:
+			// Will not handle converting text to other formats
+			return nil, "", rest.ErrBadRequest.New("Cannot output text as %s", outputType)
+		}
+	case RawDataTypeTrace:
+		// Go execution traces can only be viewed by `go tool trace` after downloading.
+		return nil, "", rest.ErrBadRequest.New("Cannot output trace as %s", outputType)
+	}
+	// legacy profiling content is svg
+	return content, "image/svg+xml", nil
+}
+
+// foldProfile converts the raw data of a finished task into the collapsed stack format.
+func foldProfile(task TaskModel, content []byte, symbolizer *elfSymbolizer) (*foldedProfile, error) {
+	switch task.RawDataType {
+	case RawDataTypeProtobuf:
+		return foldProtobuf(content)
+	case RawDataTypeJeprof:
+		p, err := jemallocToProfile(task, content, symbolizer)
+		if err != nil {
+			return nil, err
+		}
+		return foldParsedProfile(p), nil
+	default:
+		return nil, rest.ErrBadRequest.New("Cannot fold %s raw data", task.RawDataType)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// Dumping goroutines with debug=2 stops the world for the whole dump, whose cost grows with the number of
+// goroutines, see https://github.com/pingcap/tidb/issues/48695. To bound the overhead on the target, goroutines
+// are sampled at most wallClockMaxSamples times, and no more often than wallClockMinSampleInterval. A longer
+// profiling duration spreads the samples instead of taking more of them.
+const (
+	wallClockMinSampleInterval = time.Second
+	wallClockMaxSamples        = 30
+)
+
+const wallClockStateLabel = "state"
+
+type goroutineFrame struct {
+	function string
+	file     string
+	line     int64
+}
+
+type goroutineStack struct {
+	state  string
+	frames []goroutineFrame // from the leaf to the root
+}
+
+// parseGoroutineDump parses the output of `/debug/pprof/goroutine?debug=2`.
+func parseGoroutineDump(content []byte) ([]goroutineStack, error) {
+	var stacks []goroutineStack
+	var cur *goroutineStack
+	skipNext := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "goroutine "):
+			// e.g. "goroutine 18 [IO wait, 5 minutes]:"
+			start, end := strings.IndexByte(line, '['), strings.LastIndexByte(line, ']')
+			if start < 0 || end < start {
+				return nil, fmt.Errorf("invalid goroutine header: %s", line)
+			}
+			state := line[start+1 : end]
+			if i := strings.IndexByte(state, ','); i >= 0 {
+				state = state[:i]
+			}
+			stacks = append(stacks, goroutineStack{state: state})
+			cur = &stacks[len(stacks)-1]
+		case cur == nil || line == "":
+			cur = nil
+		case skipNext:
+			skipNext = false
+		case strings.HasPrefix(line, "created by "):
+			// The creator is not a part of the stack, skip its file line as well.
+			skipNext = true
+		case strings.HasPrefix(line, "\t"):
+			if len(cur.frames) == 0 {
+				continue
+			}
+			// e.g. "\t/usr/local/go/src/runtime/netpoll.go:343 +0x85"
+			fileLine := strings.TrimSpace(line)
+			if i := strings.LastIndex(fileLine, " +0x"); i >= 0 {
+				fileLine = fileLine[:i]
+			}
+			frame := &cur.frames[len(cur.frames)-1]
+			if i := strings.LastIndexByte(fileLine, ':'); i >= 0 {
+				frame.file = fileLine[:i]
+				frame.line, _ = strconv.ParseInt(fileLine[i+1:], 10, 64)
+			}
+		case strings.HasPrefix(line, "..."):
+			// "...additional frames elided..."
+		default:
+			// e.g. "internal/poll.(*FD).Read(0xc000128000, {0xc000180000, 0x1000, 0x1000})"
+			function := line
+			if i := strings.LastIndexByte(function, '('); i > 0 {
+				function = function[:i]
+			}
+			cur.frames = append(cur.frames, goroutineFrame{function: function})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stacks, nil
+}
+
+// wallClockBuilder aggregates the sampled goroutine stacks into a profile, in which the value of each stack
+// is the number of samples it appears in, and samples are labeled with the goroutine state.
+type wallClockBuilder struct {
+	p         *profile.Profile
+	interval  time.Duration
+	samples   map[string]*profile.Sample
+	functions map[string]*profile.Function
+	locations map[goroutineFrame]*profile.Location
+}
+
+func newWallClockBuilder(interval time.Duration) *wallClockBuilder {
+	return &wallClockBuilder{
+		p: &profile.Profile{
+			SampleType: []*profile.ValueType{
+				{Type: "samples", Unit: "count"},
+				{Type: "wall", Unit: "nanoseconds"},
+			},
+			DefaultSampleType: "wall",
+			PeriodType:        &profile.ValueType{Type: "wall", Unit: "nanoseconds"},
+			Period:            interval.Nanoseconds(),
+			TimeNanos:         time.Now().UnixNano(),
+		},
+		interval:  interval,
+		samples:   make(map[string]*profile.Sample),
+		functions: make(map[string]*profile.Function),
+		locations: make(map[goroutineFrame]*profile.Location),
+	}
+}
+
+func (b *wallClockBuilder) location(frame goroutineFrame) *profile.Location {
+	if loc, ok := b.locations[frame]; ok {
+		return loc
+	}
+	loc := &profile.Location{
+		ID:   uint64(len(b.p.Location) + 1),
+		Line: []profile.Line{{Function: getFunction(b.p, b.functions, frame.function, frame.file), Line: frame.line}},
+	}
+	b.locations[frame] = loc
+	b.p.Location = append(b.p.Location, loc)
+	return loc
+}
+
+// add adds a goroutine dump as a sample round.
+func (b *wallClockBuilder) add(stacks []goroutineStack) {
+	for _, stack := range stacks {
+		if len(stack.frames) == 0 {
+			continue
+		}
+		var key strings.Builder
+		key.WriteString(stack.state)
+		for _, frame := range stack.frames {
+			fmt.Fprintf(&key, "\x00%s:%s:%d", frame.function, frame.file, frame.line)
+		}
+		if s, ok := b.samples[key.String()]; ok {
+			s.Value[0]++
+			s.Value[1] += b.interval.Nanoseconds()
+			continue
+		}
+		s := &profile.Sample{
+			Value: []int64{1, b.interval.Nanoseconds()},
+			Label: map[string][]string{wallClockStateLabel: {stack.state}},
+		}
+		for _, frame := range stack.frames {
+			s.Location = append(s.Location, b.location(frame))
+		}
+		b.samples[key.String()] = s
+		b.p.Sample = append(b.p.Sample, s)
+	}
+	b.p.DurationNanos += b.interval.Nanoseconds()
+}
+
+// wallClockSampling returns the sampling interval and the number of samples for the profiling duration.
+func wallClockSampling(duration time.Duration) (time.Duration, int) {
+	interval := duration / wallClockMaxSamples
+	if interval < wallClockMinSampleInterval {
+		interval = wallClockMinSampleInterval
+	}
+	rounds := int(duration / interval)
+	if rounds < 1 {
+		rounds = 1
+	}
+	return interval, rounds
+}
+
+// FetchWallClock samples the goroutines evenly during the duration, and writes the aggregated stacks as a
+// protobuf profile. See wallClockMaxSamples for the overhead.
+func (f *fetcher) FetchWallClock(ctx context.Context, duration uint, fileNameWithoutExt string) (*profileResult, error) {
+	interval, rounds := wallClockSampling(time.Duration(duration) * time.Second)
+	b := newWallClockBuilder(interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i := 0; i < rounds; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-ticker.C:
+			}
+		}
+		content, err := (*f.profileFetcher).fetch(&fetchOptions{ctx: ctx, ip: f.target.IP, port: f.target.Port, path: "/debug/pprof/goroutine?debug=2"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch goroutines: %v", err)
+		}
+		stacks, err := parseGoroutineDump(content)
+		if err != nil {
+			return nil, err
+		}
+		b.add(stacks)
+	}
+
+	tmpfile, err := os.CreateTemp("", fileNameWithoutExt+"_*.proto")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tmpfile to write profile: %v", err)
+	}
+	defer func() {
+		_ = tmpfile.Close()
+	}()
+	if err := b.p.Write(tmpfile); err != nil {
+		_ = os.Remove(tmpfile.Name())
+		return nil, fmt.Errorf("failed to write profile: %v", err)
+	}
+	return &profileResult{filePath: tmpfile.Name(), rawDataType: RawDataTypeProtobuf}, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package profiling
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/model"
+)
+
+var _ = check.Suite(&testWallClockSuite{})
+
+type testWallClockSuite struct{}
+
+const testGoroutineDump = `goroutine 1 [running]:
+main.main()
+	/src/main.go:10 +0x1d
+
+goroutine 18 [IO wait, 5 minutes]:
+internal/poll.runtime_pollWait(0x7f, 0x72)
+	/go/src/runtime/netpoll.go:343 +0x85
+internal/poll.(*FD).Read(0xc000128000, {0xc000180000, 0x1000, 0x1000})
+	/go/src/internal/poll/fd_unix.go:164 +0x27a
+created by net/http.(*Server).Serve in goroutine 1
+	/go/src/net/http/server.go:3086 +0x5cb
+
+goroutine 19 [select]:
+main.loop()
+	/src/main.go:20 +0x10
+...additional frames elided...
+`
+
+type goroutineDumpFetcher struct{}
+
+func (f *goroutineDumpFetcher) fetch(_ *fetchOptions) ([]byte, error) {
+	return []byte(testGoroutineDump), nil
+}
+
+func (t *testWallClockSuite) TestParseGoroutineDump(c *check.C) {
+	stacks, err := parseGoroutineDump([]byte(testGoroutineDump))
+	c.Assert(err, check.IsNil)
+	c.Assert(stacks, check.DeepEquals, []goroutineStack{
+		{state: "running", frames: []goroutineFrame{{"main.main", "/src/main.go", 10}}},
+		{state: "IO wait", frames: []goroutineFrame{
+			{"internal/poll.runtime_pollWait", "/go/src/runtime/netpoll.go", 343},
+			{"internal/poll.(*FD).Read", "/go/src/internal/poll/fd_unix.go", 164},
+		}},
+		{state: "select", frames: []goroutineFrame{{"main.loop", "/src/main.go", 20}}},
+	})
+
+	_, err = parseGoroutineDump([]byte("goroutine 1 running:\n"))
+	c.Assert(err, check.NotNil)
+}
+
+func (t *testWallClockSuite) TestWallClockBuilder(c *check.C) {
+	stacks, err := parseGoroutineDump([]byte(testGoroutineDump))
+	c.Assert(err, check.IsNil)
+	b := newWallClockBuilder(time.Second)
+	b.add(stacks)
+	b.add(stacks[1:])
+	c.Assert(b.p.CheckValid(), check.IsNil)
+	c.Assert(b.p.DurationNanos, check.Equals, 2*time.Second.Nanoseconds())
+	c.Assert(b.p.Sample, check.HasLen, 3)
+	c.Assert(b.p.Sample[0].Value, check.DeepEquals, []int64{1, time.Second.Nanoseconds()})
+	c.Assert(b.p.Sample[1].Value, check.DeepEquals, []int64{2, 2 * time.Second.Nanoseconds()})
+	c.Assert(b.p.Sample[1].Label, check.DeepEquals, map[string][]string{wallClockStateLabel: {"IO wait"}})
+
+	top := TopFunctions(b.p, 1)
+	c.Assert(top.SampleType, check.Equals, "wall")
+	c.Assert(top.ByCum[0].Name, check.Equals, "internal/poll.(*FD).Read")
+}
+
+func (t *testWallClockSuite) TestWallClockSampling(c *check.C) {
+	interval, rounds := wallClockSampling(0)
+	c.Assert(interval, check.Equals, time.Second)
+	c.Assert(rounds, check.Equals, 1)
+	interval, rounds = wallClockSampling(10 * time.Second)
+	c.Assert(interval, check.Equals, time.Second)
+	c.Assert(rounds, check.Equals, 10)
+	interval, rounds = wallClockSampling(5 * time.Minute)
+	c.Assert(interval, check.Equals, 10*time.Second)
+	c.Assert(rounds, check.Equals, wallClockMaxSamples)
+}
+
+func (t *testWallClockSuite) TestFetchWallClock(c *check.C) {
+	fts := &fetchers{tidb: &goroutineDumpFetcher{}, pd: &goroutineDumpFetcher{}}
+	target := &model.RequestTargetNode{Kind: model.NodeKindTiDB, IP: "127.0.0.1", Port: 10080}
+	result, err := profileAndWritePprof(context.Background(), fts, target, "test", 0, ProfilingTypeWallClock)
+	c.Assert(err, check.IsNil)
+	c.Assert(result.rawDataType, check.Equals, RawDataTypeProtobuf)
+	defer os.Remove(result.filePath)
+	content, err := os.ReadFile(result.filePath)
+	c.Assert(err, check.IsNil)
+	p, err := profile.ParseData(content)
+	c.Assert(err, check.IsNil)
+	c.Assert(p.Sample, check.HasLen, 3)
+
+	target = &model.RequestTargetNode{Kind: model.NodeKindPD, IP: "127.0.0.1", Port: 2379}
+	_, err = profileAndWritePprof(context.Background(), fts, target, "test", 0, ProfilingTypeWallClock)
+	c.Assert(errorx.IsOfType(err, ErrUnsupportedProfilingType), check.IsTrue)
+}
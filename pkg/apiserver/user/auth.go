@@ -258,6 +258,22 @@ func (s *AuthService) MWAuthRequired() gin.HandlerFunc {
 	return s.middleware.MiddlewareFunc()
 }
 
+// MWAuthOptional creates a middleware that attaches identity information in the context if the request carries a
+// valid authentication token. Unlike MWAuthRequired, requests without a valid token are not rejected, so that
+// handlers must check the session by themselves.
+func (s *AuthService) MWAuthOptional() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Expired tokens are rejected when parsing.
+		if claims, err := s.middleware.GetClaimsFromJWT(c); err == nil {
+			c.Set("JWT_PAYLOAD", claims)
+			if identity := s.middleware.IdentityHandler(c); identity != nil {
+				c.Set(s.middleware.IdentityKey, identity)
+			}
+		}
+		c.Next()
+	}
+}
+
 // TODO: Make these MWRequireXxxPriv more general to use.
 func (s *AuthService) MWRequireSharePriv() gin.HandlerFunc {
 	return func(c *gin.Context) {
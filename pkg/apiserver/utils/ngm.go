@@ -5,6 +5,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -52,12 +53,26 @@ type NgmProxy struct {
 	ngmReqGroup  singleflight.Group
 	ngmAddrCache atomic.Value
 	timeout      time.Duration
+	// httpClient is used by SendGetRequest, so that the connections are reused.
+	httpClient *http.Client
 }
 
 func NewNgmProxy(lc fx.Lifecycle, etcdClient *clientv3.Client, config *config.Config) (*NgmProxy, error) {
+	timeout := time.Duration(config.NgmTimeout) * time.Second
 	s := &NgmProxy{
 		etcdClient: etcdClient,
-		timeout:    time.Duration(config.NgmTimeout) * time.Second,
+		timeout:    timeout,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				DialContext: defaultTransportDialContext(&net.Dialer{
+					Timeout:   timeout,
+					KeepAlive: timeout,
+				}),
+				MaxIdleConns:    100,
+				IdleConnTimeout: 90 * time.Second,
+			},
+		},
 	}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -97,6 +112,45 @@ func (n *NgmProxy) Route(targetPath string) gin.HandlerFunc {
 	}
 }
 
+// IsDeployed returns whether NgMonitoring is deployed in the cluster. An error is returned if it can not be told,
+// e.g. the topology can not be fetched from etcd.
+func (n *NgmProxy) IsDeployed() (bool, error) {
+	_, err := n.getNgmAddrFromCache()
+	if err == nil {
+		return true, nil
+	}
+	// NgMonitoring is not registered in the topology if there is no cause
+	if e := errorx.Cast(err); e != nil && errorx.IsOfType(e, ErrNgmNotStart) && e.Cause() == nil {
+		return false, nil
+	}
+	return false, err
+}
+
+// SendGetRequest sends a GET request to NgMonitoring and returns the response body.
+func (n *NgmProxy) SendGetRequest(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	ngmAddr, err := n.getNgmAddrFromCache()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s%s?%s", ngmAddr, path, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NgMonitoring responds %d: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
+
 func (n *NgmProxy) getNgmAddrFromCache() (string, error) {
 	fn := func() (string, error) {
 		// Check whether cache is valid, and use the cache if possible.
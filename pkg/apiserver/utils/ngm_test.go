@@ -0,0 +1,36 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNgmProxyIsDeployed(t *testing.T) {
+	cases := []struct {
+		name     string
+		address  string
+		err      error
+		deployed bool
+		hasErr   bool
+	}{
+		{"deployed", "http://127.0.0.1:12020", nil, true, false},
+		{"not registered", "", ErrNgmNotStart.Wrap(nil, "NgMonitoring component is not started"), false, false},
+		{"etcd request failed", "", ErrNgmNotStart.Wrap(errors.New("etcd timeout"), "NgMonitoring component is not started"), false, true},
+	}
+	for _, c := range cases {
+		n := &NgmProxy{}
+		n.ngmAddrCache.Store(&ngmAddrCacheEntity{address: c.address, err: c.err, cacheAt: time.Now()})
+		deployed, err := n.IsDeployed()
+		require.Equal(t, c.deployed, deployed, c.name)
+		if c.hasErr {
+			require.Error(t, err, c.name)
+		} else {
+			require.NoError(t, err, c.name)
+		}
+	}
+}
@@ -12,23 +12,59 @@ const (
 	KeyVisualDBPolicy = "db"
 	KeyVisualKVPolicy = "kv"
 
+	KeyVisualKeyspacePolicy = "keyspace"
+
 	DefaultKeyVisualPolicy = KeyVisualDBPolicy
 
+	KeyVisualAllReplicaView = "all"
+	// KeyVisualTiFlashView shows the writes applied by TiFlash learner replicas. TiFlash does not report reads
+	// per region, so this view does not show TiFlash reads.
+	KeyVisualTiFlashView = "tiflash"
+
+	MaxKeyVisualLayers = 16
+
 	DefaultProfilingAutoCollectionDurationSecs = 30
 	MaxProfilingAutoCollectionDurationSecs     = 120
 	DefaultProfilingAutoCollectionIntervalSecs = 3600
+
+	DefaultProfilingTriggerCooldownSecs = 1800
+	DefaultProfilingTriggerMaxGroups    = 20
+
+	DefaultContinuousProfilingProfileSeconds       = 10
+	DefaultContinuousProfilingIntervalSeconds      = 60
+	DefaultContinuousProfilingTimeoutSeconds       = 120
+	DefaultContinuousProfilingDataRetentionSeconds = 3 * 24 * 60 * 60
 )
 
 var (
-	KeyVisualPolicies = []string{KeyVisualDBPolicy, KeyVisualKVPolicy}
+	KeyVisualPolicies     = []string{KeyVisualDBPolicy, KeyVisualKVPolicy, KeyVisualKeyspacePolicy}
+	KeyVisualReplicaViews = []string{KeyVisualAllReplicaView, KeyVisualTiFlashView}
+
+	DefaultKeyVisualLayers = []KeyVisualLayerConfig{
+		{Len: 60, Ratio: 2 / 1},                     // step 1 minutes, total 60, 1 hours (sum: 1 hours)
+		{Len: 60 / 2 * 7, Ratio: 6 / 2},             // step 2 minutes, total 210, 7 hours (sum: 8 hours)
+		{Len: 60 / 6 * 16, Ratio: 30 / 6},           // step 6 minutes, total 160, 16 hours (sum: 1 days)
+		{Len: 60 / 30 * 24 * 6, Ratio: 4 * 60 / 30}, // step 30 minutes, total 288, 6 days (sum: 1 weeks)
+		{Len: 24 / 4 * 28, Ratio: 0},                // step 4 hours, total 168, 4 weeks (sum: 5 weeks)
+	}
 
 	ErrVerificationFailed = ErrorNS.NewType("verification failed")
 )
 
+// KeyVisualLayerConfig describes one layer of the key visual storage pyramid. A layer keeps up to Len axes, and
+// when it is full, every Ratio axes are compacted into one axis of the next layer.
+type KeyVisualLayerConfig struct {
+	Len   int `json:"len"`
+	Ratio int `json:"ratio"`
+}
+
 type KeyVisualConfig struct {
-	AutoCollectionDisabled bool   `json:"auto_collection_disabled"`
-	Policy                 string `json:"policy"`
-	PolicyKVSeparator      string `json:"policy_kv_separator"`
+	AutoCollectionDisabled bool                   `json:"auto_collection_disabled"`
+	Policy                 string                 `json:"policy"`
+	PolicyKVSeparator      string                 `json:"policy_kv_separator"`
+	ReplicaView            string                 `json:"replica_view"`         // empty means all regions
+	Layers                 []KeyVisualLayerConfig `json:"layers"`               // storage layers from the latest to the oldest
+	StorageBudgetBytes     uint64                 `json:"storage_budget_bytes"` // 0 means unlimited
 }
 
 func (c *KeyVisualConfig) validatePolicy() error {
@@ -38,10 +74,106 @@ func (c *KeyVisualConfig) validatePolicy() error {
 	return ErrVerificationFailed.New("policy must be in %v", KeyVisualPolicies)
 }
 
+func (c *KeyVisualConfig) validateReplicaView() error {
+	if c.ReplicaView == "" || slices.Contains(KeyVisualReplicaViews, c.ReplicaView) {
+		return nil
+	}
+	return ErrVerificationFailed.New("replica_view must be in %v", KeyVisualReplicaViews)
+}
+
+func (c *KeyVisualConfig) validateLayers() error {
+	if len(c.Layers) == 0 {
+		return ErrVerificationFailed.New("layers cannot be empty")
+	}
+	if len(c.Layers) > MaxKeyVisualLayers {
+		return ErrVerificationFailed.New("layers cannot be more than %d", MaxKeyVisualLayers)
+	}
+	for i, layer := range c.Layers {
+		if layer.Len <= 0 {
+			return ErrVerificationFailed.New("len of layer %d must be greater than 0", i)
+		}
+		if layer.Ratio < 0 || layer.Ratio > layer.Len {
+			return ErrVerificationFailed.New("ratio of layer %d must be in [0, %d]", i, layer.Len)
+		}
+	}
+	return nil
+}
+
+// ProfilingTriggerConfig starts profiling on the instances whose metric value exceeds the threshold.
+type ProfilingTriggerConfig struct {
+	Name string `json:"name"`
+	// Query is a PromQL expression returning one series per instance, whose `instance` label is the
+	// address of the component, e.g. `rate(process_cpu_seconds_total{job="tidb"}[1m])`.
+	Query          string         `json:"query"`
+	Component      model.NodeKind `json:"component"`
+	Threshold      float64        `json:"threshold"`
+	ForSecs        uint           `json:"for_secs"` // the value must exceed the threshold for this long
+	ProfilingTypes []string       `json:"profiling_types"`
+	DurationSecs   uint           `json:"duration_secs"` // 0 means DefaultProfilingAutoCollectionDurationSecs
+	CooldownSecs   uint           `json:"cooldown_secs"` // 0 means DefaultProfilingTriggerCooldownSecs
+}
+
 type ProfilingConfig struct {
 	AutoCollectionTargets      []model.RequestTargetNode `json:"auto_collection_targets"`
 	AutoCollectionDurationSecs uint                      `json:"auto_collection_duration_secs"`
 	AutoCollectionIntervalSecs uint                      `json:"auto_collection_interval_secs"`
+	Triggers                   []ProfilingTriggerConfig  `json:"triggers"`
+	TriggerMaxGroups           uint                      `json:"trigger_max_groups"` // 0 means DefaultProfilingTriggerMaxGroups
+	// The retention policy of finished task groups which are not pinned. 0 means unlimited.
+	RetentionMaxAgeSecs uint   `json:"retention_max_age_secs"`
+	RetentionMaxBytes   uint64 `json:"retention_max_bytes"`
+	RetentionMaxGroups  uint   `json:"retention_max_groups"`
+}
+
+func (c *ProfilingConfig) validateTriggers() error {
+	names := make(map[string]struct{}, len(c.Triggers))
+	for i, t := range c.Triggers {
+		if t.Name == "" {
+			return ErrVerificationFailed.New("name of trigger %d cannot be empty", i)
+		}
+		if _, ok := names[t.Name]; ok {
+			return ErrVerificationFailed.New("duplicated trigger name %s", t.Name)
+		}
+		names[t.Name] = struct{}{}
+		if t.Query == "" {
+			return ErrVerificationFailed.New("query of trigger %s cannot be empty", t.Name)
+		}
+		if t.Component == "" {
+			return ErrVerificationFailed.New("component of trigger %s cannot be empty", t.Name)
+		}
+		if len(t.ProfilingTypes) == 0 {
+			return ErrVerificationFailed.New("profiling_types of trigger %s cannot be empty", t.Name)
+		}
+		if t.DurationSecs > MaxProfilingAutoCollectionDurationSecs {
+			return ErrVerificationFailed.New("duration_secs of trigger %s cannot be greater than %d", t.Name, MaxProfilingAutoCollectionDurationSecs)
+		}
+	}
+	return nil
+}
+
+// ContinuousProfilingConfig is used by the built-in continuous profiler when NgMonitoring is not deployed.
+type ContinuousProfilingConfig struct {
+	Enable               bool `json:"enable"`
+	ProfileSeconds       int  `json:"profile_seconds"`
+	IntervalSeconds      int  `json:"interval_seconds"`
+	TimeoutSeconds       int  `json:"timeout_seconds"`
+	DataRetentionSeconds int  `json:"data_retention_seconds"`
+}
+
+func (c *ContinuousProfilingConfig) validate() error {
+	if c.ProfileSeconds <= 0 || c.ProfileSeconds > MaxProfilingAutoCollectionDurationSecs {
+		return ErrVerificationFailed.New("profile_seconds must be in (0, %d]", MaxProfilingAutoCollectionDurationSecs)
+	}
+	if c.IntervalSeconds < c.ProfileSeconds {
+		return ErrVerificationFailed.New("interval_seconds cannot be less than profile_seconds")
+	}
+	if c.TimeoutSeconds < c.ProfileSeconds {
+		return ErrVerificationFailed.New("timeout_seconds cannot be less than profile_seconds")
+	}
+	if c.DataRetentionSeconds <= 0 {
+		return ErrVerificationFailed.New("data_retention_seconds must be greater than 0")
+	}
+	return nil
 }
 
 type SSOCoreConfig struct {
@@ -65,12 +197,21 @@ type DynamicConfig struct {
 	KeyVisual KeyVisualConfig `json:"keyvisual"`
 	Profiling ProfilingConfig `json:"profiling"`
 	SSO       SSOConfig       `json:"sso"`
+
+	ContinuousProfiling ContinuousProfilingConfig `json:"continuous_profiling"`
 }
 
 func (c *DynamicConfig) Clone() *DynamicConfig {
 	newCfg := *c
+	newCfg.KeyVisual.Layers = make([]KeyVisualLayerConfig, len(c.KeyVisual.Layers))
+	copy(newCfg.KeyVisual.Layers, c.KeyVisual.Layers)
 	newCfg.Profiling.AutoCollectionTargets = make([]model.RequestTargetNode, len(c.Profiling.AutoCollectionTargets))
 	copy(newCfg.Profiling.AutoCollectionTargets, c.Profiling.AutoCollectionTargets)
+	newCfg.Profiling.Triggers = make([]ProfilingTriggerConfig, len(c.Profiling.Triggers))
+	for i, t := range c.Profiling.Triggers {
+		newCfg.Profiling.Triggers[i] = t
+		newCfg.Profiling.Triggers[i].ProfilingTypes = slices.Clone(t.ProfilingTypes)
+	}
 	return &newCfg
 }
 
@@ -79,6 +220,12 @@ func (c *DynamicConfig) Validate() error {
 		if err := c.KeyVisual.validatePolicy(); err != nil {
 			return err
 		}
+		if err := c.KeyVisual.validateReplicaView(); err != nil {
+			return err
+		}
+		if err := c.KeyVisual.validateLayers(); err != nil {
+			return err
+		}
 	}
 
 	if len(c.Profiling.AutoCollectionTargets) > 0 {
@@ -99,6 +246,12 @@ func (c *DynamicConfig) Validate() error {
 			return ErrVerificationFailed.New("auto_collection_interval_secs must be 0")
 		}
 	}
+	if err := c.Profiling.validateTriggers(); err != nil {
+		return err
+	}
+	if err := c.ContinuousProfiling.validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -110,6 +263,13 @@ func (c *DynamicConfig) Adjust() {
 			c.KeyVisual.Policy = DefaultKeyVisualPolicy
 		}
 	}
+	if err := c.KeyVisual.validateReplicaView(); err != nil {
+		c.KeyVisual.ReplicaView = KeyVisualAllReplicaView
+	}
+	if err := c.KeyVisual.validateLayers(); err != nil {
+		c.KeyVisual.Layers = make([]KeyVisualLayerConfig, len(DefaultKeyVisualLayers))
+		copy(c.KeyVisual.Layers, DefaultKeyVisualLayers)
+	}
 
 	if len(c.Profiling.AutoCollectionTargets) > 0 {
 		if c.Profiling.AutoCollectionDurationSecs == 0 {
@@ -125,4 +285,15 @@ func (c *DynamicConfig) Adjust() {
 		c.Profiling.AutoCollectionDurationSecs = 0
 		c.Profiling.AutoCollectionIntervalSecs = 0
 	}
+
+	if err := c.ContinuousProfiling.validate(); err != nil {
+		enable := c.ContinuousProfiling.Enable
+		c.ContinuousProfiling = ContinuousProfilingConfig{
+			Enable:               enable,
+			ProfileSeconds:       DefaultContinuousProfilingProfileSeconds,
+			IntervalSeconds:      DefaultContinuousProfilingIntervalSeconds,
+			TimeoutSeconds:       DefaultContinuousProfilingTimeoutSeconds,
+			DataRetentionSeconds: DefaultContinuousProfilingDataRetentionSeconds,
+		}
+	}
 }
@@ -0,0 +1,91 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyVisualValidateLayers(t *testing.T) {
+	tooMany := make([]KeyVisualLayerConfig, MaxKeyVisualLayers+1)
+	for i := range tooMany {
+		tooMany[i] = KeyVisualLayerConfig{Len: 10, Ratio: 2}
+	}
+	cases := []struct {
+		name   string
+		layers []KeyVisualLayerConfig
+		valid  bool
+	}{
+		{"default", DefaultKeyVisualLayers, true},
+		{"single layer without compaction", []KeyVisualLayerConfig{{Len: 10, Ratio: 0}}, true},
+		{"ratio equal to len", []KeyVisualLayerConfig{{Len: 10, Ratio: 10}, {Len: 10, Ratio: 0}}, true},
+		{"empty", nil, false},
+		{"too many layers", tooMany, false},
+		{"zero len", []KeyVisualLayerConfig{{Len: 0, Ratio: 0}}, false},
+		{"negative len", []KeyVisualLayerConfig{{Len: -1, Ratio: 0}}, false},
+		{"negative ratio", []KeyVisualLayerConfig{{Len: 10, Ratio: -1}}, false},
+		{"ratio greater than len", []KeyVisualLayerConfig{{Len: 10, Ratio: 2}, {Len: 4, Ratio: 5}}, false},
+	}
+	for _, c := range cases {
+		cfg := KeyVisualConfig{Layers: c.layers}
+		err := cfg.validateLayers()
+		if c.valid {
+			require.NoError(t, err, c.name)
+		} else {
+			require.Error(t, err, c.name)
+		}
+	}
+}
+
+func TestKeyVisualAdjust(t *testing.T) {
+	cases := []struct {
+		name      string
+		keyVisual KeyVisualConfig
+		expected  KeyVisualConfig
+	}{
+		{
+			name:      "config of the old version",
+			keyVisual: KeyVisualConfig{},
+			expected:  KeyVisualConfig{Policy: DefaultKeyVisualPolicy, Layers: DefaultKeyVisualLayers},
+		},
+		{
+			name:      "invalid layers",
+			keyVisual: KeyVisualConfig{Policy: KeyVisualKVPolicy, Layers: []KeyVisualLayerConfig{{Len: 10, Ratio: 20}}},
+			expected:  KeyVisualConfig{Policy: KeyVisualKVPolicy, Layers: DefaultKeyVisualLayers},
+		},
+		{
+			name:      "invalid replica view",
+			keyVisual: KeyVisualConfig{Policy: KeyVisualKVPolicy, ReplicaView: "unknown", Layers: []KeyVisualLayerConfig{{Len: 10, Ratio: 0}}},
+			expected:  KeyVisualConfig{Policy: KeyVisualKVPolicy, ReplicaView: KeyVisualAllReplicaView, Layers: []KeyVisualLayerConfig{{Len: 10, Ratio: 0}}},
+		},
+		{
+			name: "valid",
+			keyVisual: KeyVisualConfig{
+				Policy:             KeyVisualKVPolicy,
+				ReplicaView:        KeyVisualTiFlashView,
+				Layers:             []KeyVisualLayerConfig{{Len: 10, Ratio: 2}, {Len: 5, Ratio: 0}},
+				StorageBudgetBytes: 1024,
+			},
+			expected: KeyVisualConfig{
+				Policy:             KeyVisualKVPolicy,
+				ReplicaView:        KeyVisualTiFlashView,
+				Layers:             []KeyVisualLayerConfig{{Len: 10, Ratio: 2}, {Len: 5, Ratio: 0}},
+				StorageBudgetBytes: 1024,
+			},
+		},
+	}
+	for _, c := range cases {
+		dc := DynamicConfig{KeyVisual: c.keyVisual}
+		dc.Adjust()
+		require.Equal(t, c.expected, dc.KeyVisual, c.name)
+		require.NoError(t, dc.Validate(), c.name)
+	}
+
+	// Adjusted layers do not share the default ones.
+	dc := DynamicConfig{}
+	dc.Adjust()
+	dc.KeyVisual.Layers[0].Len = 1
+	require.NotEqual(t, 1, DefaultKeyVisualLayers[0].Len)
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// KeyspaceLabelStrategy implements the LabelStrategy interface. It decodes RawKV and TxnKV keys, and labels keys
+// with the API v2 keyspace prefix by the keyspace names obtained from PD.
+func KeyspaceLabelStrategy(lc fx.Lifecycle, wg *sync.WaitGroup, pdClient *pd.Client) LabelStrategy {
+	s := &keyspaceLabelStrategy{
+		pdClient: pdClient,
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			wg.Go(func() {
+				s.Background(ctx)
+			})
+			return nil
+		},
+	})
+
+	return s
+}
+
+type keyspaceLabelStrategy struct {
+	pdClient *pd.Client
+
+	// KeyspaceMap maps keyspace ID to keyspace name.
+	KeyspaceMap sync.Map
+}
+
+type keyspaceLabeler struct {
+	KeyspaceMap *sync.Map
+	Buffer      model.KeyInfoBuffer
+}
+
+func (s *keyspaceLabelStrategy) ReloadConfig(_ *config.KeyVisualConfig) {}
+
+func (s *keyspaceLabelStrategy) Background(ctx context.Context) {
+	s.updateMap()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.updateMap()
+		}
+	}
+}
+
+func (s *keyspaceLabelStrategy) NewLabeler() Labeler {
+	return &keyspaceLabeler{
+		KeyspaceMap: &s.KeyspaceMap,
+	}
+}
+
+// CrossBorder does not allow cross modes or keyspaces. Inside TxnKV, it does not allow cross tables either.
+func (e *keyspaceLabeler) CrossBorder(startKey, endKey string) bool {
+	startInfo := DecodeKVKey(region.Bytes(startKey), &e.Buffer)
+	startIsMeta, startTableID := startInfo.TiDBKey().MetaOrTable()
+
+	endInfo := DecodeKVKey(region.Bytes(endKey), &e.Buffer)
+	if !startInfo.SameRange(endInfo) {
+		return true
+	}
+	endIsMeta, endTableID := endInfo.TiDBKey().MetaOrTable()
+	return startIsMeta != endIsMeta || startTableID != endTableID
+}
+
+// Label parses the mode and keyspace of the keys, as well as the table and index for TiDB keys.
+func (e *keyspaceLabeler) Label(keys []string) []LabelKey {
+	labelKeys := make([]LabelKey, len(keys))
+	for i, key := range keys {
+		if key == "" {
+			labelKeys[i] = LabelKey{Key: "", Labels: []string{}}
+			continue
+		}
+		labelKeys[i] = e.label(key)
+	}
+	return labelKeys
+}
+
+func (e *keyspaceLabeler) label(key string) (label LabelKey) {
+	keyBytes := region.Bytes(key)
+	label.Key = hex.EncodeToString(keyBytes)
+	info := DecodeKVKey(keyBytes, &e.Buffer)
+
+	if info.HasKeyspace {
+		if v, ok := e.KeyspaceMap.Load(info.KeyspaceID); ok {
+			label.Labels = append(label.Labels, v.(string))
+		} else {
+			label.Labels = append(label.Labels, fmt.Sprintf("keyspace_%d", info.KeyspaceID))
+		}
+	}
+	label.Labels = append(label.Labels, string(info.Mode))
+
+	tidbKey := info.TiDBKey()
+	isMeta, tableID := tidbKey.MetaOrTable()
+	switch {
+	case isMeta:
+		label.Labels = append(label.Labels, "meta")
+	case tableID != 0:
+		label.Labels = append(label.Labels, fmt.Sprintf("table_%d", tableID))
+		if isCommonHandle, rowID := tidbKey.RowInfo(); isCommonHandle {
+			label.Labels = append(label.Labels, "row")
+		} else if rowID != 0 {
+			label.Labels = append(label.Labels, fmt.Sprintf("row_%d", rowID))
+		} else if indexID := tidbKey.IndexInfo(); indexID != 0 {
+			label.Labels = append(label.Labels, fmt.Sprintf("index_%d", indexID))
+		}
+	case len(info.UserKey) > 0:
+		label.Labels = append(label.Labels, printableKey(info.UserKey))
+	}
+	return
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/tidb-dashboard/util/distro"
+)
+
+const (
+	keyspacesPath      = "/pd/api/v2/keyspaces"
+	keyspacesPageLimit = 1000
+)
+
+type keyspaceMeta struct {
+	ID    uint32 `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+type keyspacesPage struct {
+	Keyspaces     []keyspaceMeta `json:"keyspaces"`
+	NextPageToken string         `json:"next_page_token"`
+}
+
+func (s *keyspaceLabelStrategy) updateMap() {
+	pageToken := ""
+	for {
+		page, err := s.requestKeyspaces(pageToken)
+		if err != nil {
+			log.Debug("failed to get keyspaces, maybe API v2 is not enabled", zap.Error(err))
+			return
+		}
+		for _, keyspace := range page.Keyspaces {
+			s.KeyspaceMap.Store(keyspace.ID, keyspace.Name)
+		}
+		if len(page.Keyspaces) == 0 || page.NextPageToken == "" || page.NextPageToken == pageToken {
+			return
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func (s *keyspaceLabelStrategy) requestKeyspaces(pageToken string) (*keyspacesPage, error) {
+	values := url.Values{
+		"limit": {strconv.Itoa(keyspacesPageLimit)},
+	}
+	if pageToken != "" {
+		values.Set("page_token", pageToken)
+	}
+	data, err := s.pdClient.WithoutPrefix().SendGetRequest(keyspacesPath + "?" + values.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var page keyspacesPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, ErrInvalidData.Wrap(err, "%s keyspaces API unmarshal failed", distro.R().PD)
+	}
+	return &page, nil
+}
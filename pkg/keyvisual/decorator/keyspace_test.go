@@ -0,0 +1,62 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"sync"
+
+	"github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+var _ = check.Suite(&testKeyspaceSuite{})
+
+type testKeyspaceSuite struct{}
+
+func (s *testKeyspaceSuite) TestDecodeKVKey(c *check.C) {
+	var buf model.KeyInfoBuffer
+
+	info := DecodeKVKey([]byte("r\x00\x00\x01abc"), &buf)
+	c.Assert(info.Mode, check.Equals, RawKVMode)
+	c.Assert(info.HasKeyspace, check.IsTrue)
+	c.Assert(info.KeyspaceID, check.Equals, uint32(1))
+	c.Assert(string(info.UserKey), check.Equals, "abc")
+
+	info = DecodeKVKey(model.EncodeKey([]byte("x\x00\x01\x00abc")), &buf)
+	c.Assert(info.Mode, check.Equals, TxnKVMode)
+	c.Assert(info.HasKeyspace, check.IsTrue)
+	c.Assert(info.KeyspaceID, check.Equals, uint32(256))
+	c.Assert(string(info.UserKey), check.Equals, "abc")
+
+	info = DecodeKVKey([]byte("abc"), &buf)
+	c.Assert(info.Mode, check.Equals, RawKVMode)
+	c.Assert(info.HasKeyspace, check.IsFalse)
+	c.Assert(string(info.UserKey), check.Equals, "abc")
+}
+
+func (s *testKeyspaceSuite) TestKeyspaceLabeler(c *check.C) {
+	var keyspaceMap sync.Map
+	keyspaceMap.Store(uint32(1), "tenant_a")
+	labeler := &keyspaceLabeler{KeyspaceMap: &keyspaceMap}
+
+	// GenerateKey leaves the raw table key in buf
+	var buf model.KeyInfoBuffer
+	buf.GenerateKey(42, 0)
+	tableKey := append([]byte("x\x00\x00\x02"), buf...)
+
+	keys := []string{
+		"",
+		"r\x00\x00\x01key\x01",
+		string(model.EncodeKey(tableKey)),
+		"",
+	}
+	labelKeys := labeler.Label(keys)
+	c.Assert(labelKeys[0].Labels, check.HasLen, 0)
+	c.Assert(labelKeys[1].Labels, check.DeepEquals, []string{"tenant_a", "rawkv", `key\x01`})
+	c.Assert(labelKeys[2].Labels, check.DeepEquals, []string{"keyspace_2", "txnkv", "table_42"})
+	c.Assert(labelKeys[3].Labels, check.HasLen, 0)
+
+	c.Assert(labeler.CrossBorder(keys[1], keys[2]), check.IsTrue)
+	c.Assert(labeler.CrossBorder(keys[1], "r\x00\x00\x01zzz"), check.IsFalse)
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package decorator
+
+import (
+	"strconv"
+
+	"github.com/pingcap/tidb-dashboard/pkg/tidb/model"
+)
+
+// KVMode is the mode of a key in TiKV.
+type KVMode string
+
+const (
+	RawKVMode KVMode = "rawkv"
+	TxnKVMode KVMode = "txnkv"
+)
+
+// Prefixes of API v2 keys. A keyspace key is the mode prefix followed by the 3-byte keyspace ID.
+const (
+	rawKVKeyspacePrefix = 'r'
+	txnKVKeyspacePrefix = 'x'
+	keyspacePrefixLen   = 4
+)
+
+// KVKeyInfo is the decoded information of a RawKV or TxnKV key.
+type KVKeyInfo struct {
+	Mode        KVMode
+	HasKeyspace bool
+	KeyspaceID  uint32
+	// UserKey is the key without the memcomparable encoding and the keyspace prefix.
+	UserKey []byte
+}
+
+// DecodeKVKey decodes a region boundary key. Keys that are in memcomparable format are treated as TxnKV keys, and
+// others are treated as RawKV keys. The keyspace prefix is recognized for both modes.
+func DecodeKVKey(key []byte, buf *model.KeyInfoBuffer) KVKeyInfo {
+	info := KVKeyInfo{
+		Mode:    RawKVMode,
+		UserKey: key,
+	}
+	if decoded, err := buf.DecodeKey(key); err == nil {
+		info.Mode = TxnKVMode
+		info.UserKey = decoded
+	}
+
+	prefix := byte(rawKVKeyspacePrefix)
+	if info.Mode == TxnKVMode {
+		prefix = txnKVKeyspacePrefix
+	}
+	if len(info.UserKey) >= keyspacePrefixLen && info.UserKey[0] == prefix {
+		info.HasKeyspace = true
+		info.KeyspaceID = uint32(info.UserKey[1])<<16 | uint32(info.UserKey[2])<<8 | uint32(info.UserKey[3])
+		info.UserKey = info.UserKey[keyspacePrefixLen:]
+	}
+	return info
+}
+
+// SameRange checks whether two keys are in the same mode and keyspace.
+func (info KVKeyInfo) SameRange(other KVKeyInfo) bool {
+	return info.Mode == other.Mode && info.HasKeyspace == other.HasKeyspace && info.KeyspaceID == other.KeyspaceID
+}
+
+// TiDBKey returns the user key as a TiDB key, which is only meaningful for TxnKV keys.
+func (info KVKeyInfo) TiDBKey() model.KeyInfoBuffer {
+	if info.Mode != TxnKVMode {
+		return nil
+	}
+	return model.KeyInfoBuffer(info.UserKey)
+}
+
+// printableKey escapes the non-printable bytes of the key.
+func printableKey(key []byte) string {
+	quoted := strconv.QuoteToASCII(string(key))
+	return quoted[1 : len(quoted)-1]
+}
@@ -13,6 +13,7 @@ import (
 
 	regionpkg "github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
+	"github.com/pingcap/tidb-dashboard/pkg/utils/topology"
 	"github.com/pingcap/tidb-dashboard/util/distro"
 )
 
@@ -24,17 +25,27 @@ var (
 	ErrInvalidData = ErrNSInput.NewType("invalid_data")
 )
 
+// PeerInfo records a peer of the region.
+type PeerInfo struct {
+	ID        uint64 `json:"id"`
+	StoreID   uint64 `json:"store_id"`
+	RoleName  string `json:"role_name"`
+	IsLearner bool   `json:"is_learner"`
+}
+
 // RegionInfo records detail region info for api usage.
 type RegionInfo struct {
-	ID              uint64 `json:"id"`
-	StartKey        string `json:"start_key"`
-	EndKey          string `json:"end_key"`
-	WrittenBytes    uint64 `json:"written_bytes"`
-	ReadBytes       uint64 `json:"read_bytes"`
-	WrittenKeys     uint64 `json:"written_keys"`
-	ReadKeys        uint64 `json:"read_keys"`
-	ApproximateSize int64  `json:"approximate_size"`
-	ApproximateKeys int64  `json:"approximate_keys"`
+	ID              uint64      `json:"id"`
+	StartKey        string      `json:"start_key"`
+	EndKey          string      `json:"end_key"`
+	WrittenBytes    uint64      `json:"written_bytes"`
+	ReadBytes       uint64      `json:"read_bytes"`
+	WrittenKeys     uint64      `json:"written_keys"`
+	ReadKeys        uint64      `json:"read_keys"`
+	ApproximateSize int64       `json:"approximate_size"`
+	ApproximateKeys int64       `json:"approximate_keys"`
+	Peers           []*PeerInfo `json:"peers"`
+	Leader          *PeerInfo   `json:"leader"`
 }
 
 // RegionsInfo contains some regions with the detailed region info.
@@ -57,6 +68,21 @@ func (rs *RegionsInfo) GetKeys() []string {
 	return keys
 }
 
+func (rs *RegionsInfo) GetMetas() []regionpkg.RegionMeta {
+	metas := make([]regionpkg.RegionMeta, len(rs.Regions))
+	for i, region := range rs.Regions {
+		metas[i] = regionpkg.RegionMeta{
+			ID:       region.ID,
+			StartKey: region.StartKey,
+			EndKey:   region.EndKey,
+		}
+		if region.Leader != nil {
+			metas[i].LeaderStoreID = region.Leader.StoreID
+		}
+	}
+	return metas
+}
+
 func (rs *RegionsInfo) GetValues(tag regionpkg.StatTag) []uint64 {
 	values := make([]uint64, rs.Count)
 	switch tag {
@@ -145,6 +171,50 @@ func NewAPIPeriodicGetter(pdClient *pd.Client) regionpkg.RegionsInfoGenerator {
 	}
 }
 
+// NewTiFlashPeriodicGetter reports the traffic of learner replicas on TiFlash stores. Each learner applies the writes
+// of its region, so the written bytes and keys are the ones of the region times the number of its TiFlash learners.
+// TiFlash does not report reads per region, so the read values are zero. Regions without TiFlash learners are kept
+// with zero values to keep the key axis complete.
+func NewTiFlashPeriodicGetter(pdClient *pd.Client) regionpkg.RegionsInfoGenerator {
+	getter := NewAPIPeriodicGetter(pdClient)
+	return func() (regionpkg.RegionsInfo, error) {
+		_, tiFlashStores, err := topology.FetchStoreTopology(pdClient)
+		if err != nil {
+			return nil, err
+		}
+		tiFlashStoreIDs := make(map[uint64]struct{}, len(tiFlashStores))
+		for _, store := range tiFlashStores {
+			tiFlashStoreIDs[store.ID] = struct{}{}
+		}
+
+		regions, err := getter()
+		if err != nil {
+			return nil, err
+		}
+		for _, region := range regions.(*RegionsInfo).Regions {
+			learners := uint64(region.countLearnersOn(tiFlashStoreIDs))
+			region.WrittenBytes *= learners
+			region.WrittenKeys *= learners
+			region.ReadBytes = 0
+			region.ReadKeys = 0
+		}
+		return regions, nil
+	}
+}
+
+func (r *RegionInfo) countLearnersOn(storeIDs map[uint64]struct{}) int {
+	count := 0
+	for _, peer := range r.Peers {
+		if !peer.IsLearner && peer.RoleName != "Learner" {
+			continue
+		}
+		if _, ok := storeIDs[peer.StoreID]; ok {
+			count++
+		}
+	}
+	return count
+}
+
 func scanRegions(pdclient *pd.Client, key, endKey string, limit int) (*RegionsInfo, error) {
 	values := url.Values{
 		"key":     {key},
@@ -0,0 +1,93 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package input
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pingcap/check"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/httpc"
+	"github.com/pingcap/tidb-dashboard/pkg/pd"
+)
+
+func TestInput(t *testing.T) {
+	check.TestingT(t)
+}
+
+var _ = check.Suite(&testAPISuite{})
+
+type testAPISuite struct{}
+
+const testStoresResponse = `{
+  "count": 3,
+  "stores": [
+    {"store": {"id": 1, "address": "127.0.0.1:20160", "status_address": "127.0.0.1:20180", "state_name": "Up"}},
+    {"store": {"id": 2, "address": "127.0.0.1:3930", "status_address": "127.0.0.1:20292", "state_name": "Up",
+      "labels": [{"key": "engine", "value": "tiflash"}]}},
+    {"store": {"id": 3, "address": "127.0.0.2:3930", "status_address": "127.0.0.2:20292", "state_name": "Up",
+      "labels": [{"key": "engine", "value": "tiflash"}]}}
+  ]
+}`
+
+const testRegionsResponse = `{
+  "count": 2,
+  "regions": [
+    {"id": 10, "start_key": "", "end_key": "74", "written_bytes": 100, "read_bytes": 200, "written_keys": 1, "read_keys": 2,
+      "peers": [{"id": 11, "store_id": 1}], "leader": {"id": 11, "store_id": 1}},
+    {"id": 20, "start_key": "74", "end_key": "", "written_bytes": 300, "read_bytes": 400, "written_keys": 3, "read_keys": 4,
+      "peers": [{"id": 21, "store_id": 1}, {"id": 22, "store_id": 2, "role_name": "Learner", "is_learner": true},
+        {"id": 23, "store_id": 3, "role_name": "Learner", "is_learner": true}],
+      "leader": {"id": 21, "store_id": 1}}
+  ]
+}`
+
+func newTestPDClient(c *check.C, handler http.Handler) (*pd.Client, func()) {
+	ts := httptest.NewServer(handler)
+	lc := fxtest.NewLifecycle(c)
+	cfg := &config.Config{}
+	client := pd.NewPDClient(lc, httpc.NewHTTPClient(lc, cfg), cfg)
+	// The client keeps the start context for its requests, so it must outlive the start.
+	c.Assert(lc.Start(context.Background()), check.IsNil)
+	return client.WithBaseURL(ts.URL), func() {
+		c.Assert(lc.Stop(context.Background()), check.IsNil)
+		ts.Close()
+	}
+}
+
+func (s *testAPISuite) TestTiFlashPeriodicGetter(c *check.C) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pd/api/v1/stores", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testStoresResponse))
+	})
+	mux.HandleFunc("/pd/api/v1/regions/key", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testRegionsResponse))
+	})
+	client, cleanup := newTestPDClient(c, mux)
+	defer cleanup()
+
+	info, err := NewTiFlashPeriodicGetter(client)()
+	c.Assert(err, check.IsNil)
+	regions := info.(*RegionsInfo).Regions
+	c.Assert(regions, check.HasLen, 2)
+
+	// The region without a TiFlash learner is kept on the key axis with zero values.
+	c.Assert(regions[0].StartKey, check.Equals, "")
+	c.Assert(regions[0].EndKey, check.Equals, "t")
+	c.Assert(regions[0].WrittenBytes, check.Equals, uint64(0))
+	c.Assert(regions[0].ReadBytes, check.Equals, uint64(0))
+	c.Assert(regions[0].WrittenKeys, check.Equals, uint64(0))
+	c.Assert(regions[0].ReadKeys, check.Equals, uint64(0))
+
+	// Each TiFlash learner applies the writes of the region.
+	c.Assert(regions[1].StartKey, check.Equals, "t")
+	c.Assert(regions[1].WrittenBytes, check.Equals, uint64(600))
+	c.Assert(regions[1].ReadBytes, check.Equals, uint64(0))
+	c.Assert(regions[1].WrittenKeys, check.Equals, uint64(6))
+	c.Assert(regions[1].ReadKeys, check.Equals, uint64(0))
+}
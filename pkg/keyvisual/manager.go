@@ -5,6 +5,7 @@ package keyvisual
 import (
 	"context"
 	"net/http"
+	"slices"
 	"sync"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +14,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/pingcap/tidb-dashboard/pkg/config"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/storage"
 	"github.com/pingcap/tidb-dashboard/util/rest"
 )
 
@@ -50,8 +52,17 @@ func (s *Service) managerLoop(ctx context.Context) {
 }
 
 func (s *Service) resetKeyVisualConfig(ctx context.Context, cfg *config.DynamicConfig) {
+	if s.keyVisualCfg != nil && s.keyVisualCfg.ReplicaView != cfg.KeyVisual.ReplicaView {
+		// Axes of different replica views cannot be shown in one heatmap, so the stored axes are dropped.
+		s.stopService()
+		if err := storage.DropTableAxisModel(s.db); err != nil {
+			log.Warn("Failed to drop the stored axes of the previous replica view", zap.Error(err))
+		}
+	}
 	if !cfg.KeyVisual.AutoCollectionDisabled {
-		if s.keyVisualCfg != nil && s.keyVisualCfg.Policy != cfg.KeyVisual.Policy {
+		if s.keyVisualCfg != nil && (s.keyVisualCfg.Policy != cfg.KeyVisual.Policy ||
+			s.keyVisualCfg.ReplicaView != cfg.KeyVisual.ReplicaView ||
+			!slices.Equal(s.keyVisualCfg.Layers, cfg.KeyVisual.Layers)) {
 			s.stopService()
 		}
 		s.reloadKeyVisualConfig(&cfg.KeyVisual)
@@ -100,6 +111,7 @@ func (s *Service) getDynamicConfig(c *gin.Context) {
 }
 
 // @Summary Set Key Visual Dynamic Config
+// @Description Fields omitted in the request body keep their current values. Set storage_budget_bytes to 0 for an unlimited budget.
 // @Param request body config.KeyVisualConfig true "Request body"
 // @Success 200 {object} config.KeyVisualConfig
 // @Router /keyvisual/config [put]
@@ -108,11 +120,20 @@ func (s *Service) getDynamicConfig(c *gin.Context) {
 // @Failure 401 {object} rest.ErrorResponse
 // @Failure 500 {object} rest.ErrorResponse
 func (s *Service) setDynamicConfig(c *gin.Context) {
-	var req config.KeyVisualConfig
+	dc, err := s.cfgManager.Get()
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	// Decode onto the current config, so that omitted fields keep the current values, while zero values can be set.
+	req := dc.KeyVisual
 	if err := c.ShouldBindJSON(&req); err != nil {
 		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
 		return
 	}
+	if len(req.Layers) == 0 {
+		req.Layers = dc.KeyVisual.Layers
+	}
 	var opt config.DynamicConfigOption = func(dc *config.DynamicConfig) {
 		dc.KeyVisual = req
 	}
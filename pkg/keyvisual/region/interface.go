@@ -8,6 +8,19 @@ type RegionsInfo interface {
 	GetValues(tag StatTag) []uint64
 }
 
+// RegionMeta is the boundary and leader of a region, which is used to derive scheduling events.
+type RegionMeta struct {
+	ID            uint64
+	StartKey      string
+	EndKey        string
+	LeaderStoreID uint64
+}
+
+// RegionMetasInfo is implemented by RegionsInfo that also carries region metas, sorted by StartKey.
+type RegionMetasInfo interface {
+	GetMetas() []RegionMeta
+}
+
 type RegionsInfoGenerator func() (RegionsInfo, error)
 
 type DataProvider struct {
@@ -0,0 +1,140 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package keyvisual
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/render"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+// renderHeatmapQuery renders the heatmap specified by the query.
+func (s *Service) renderHeatmapQuery(c *gin.Context) ([]byte, render.Format, error) {
+	format := render.Format(c.DefaultQuery("format", string(render.FormatPNG)))
+	if format != render.FormatPNG && format != render.FormatSVG {
+		return nil, format, rest.ErrBadRequest.New("format must be %s or %s", render.FormatPNG, render.FormatSVG)
+	}
+	var opts render.Options
+	if width := c.Query("width"); width != "" {
+		w, err := strconv.Atoi(width)
+		if err != nil {
+			return nil, format, rest.ErrBadRequest.New("width must be an integer")
+		}
+		opts.Width = w
+	}
+	if height := c.Query("height"); height != "" {
+		h, err := strconv.Atoi(height)
+		if err != nil {
+			return nil, format, rest.ErrBadRequest.New("height must be an integer")
+		}
+		opts.Height = h
+	}
+	startTime, endTime, startKey, endKey, ok := parseRangeQuery(c)
+	if !ok {
+		return nil, format, rest.ErrBadRequest.NewWithNoMessage()
+	}
+
+	baseTag := region.IntoTag(c.Query("type"))
+	stat := s.getStat()
+	if stat == nil {
+		return nil, format, newServiceStoppedError()
+	}
+	mx := s.generateHeatmap(stat, startTime, endTime, startKey, endKey, baseTag)
+	var buf bytes.Buffer
+	if err := render.Render(&buf, format, mx, baseTag.String(), opts); err != nil {
+		return nil, format, ErrRenderFailed.WrapWithNoMessage(err)
+	}
+	return buf.Bytes(), format, nil
+}
+
+// @Summary Render Key Visual Heatmaps
+// @Description Render the heatmap in a given range into a PNG or SVG image
+// @Produce png
+// @Produce svg
+// @Param startkey query string false "The start of the key range"
+// @Param endkey query string false "The end of the key range"
+// @Param starttime query int false "The start of the time range (Unix)"
+// @Param endtime query int false "The end of the time range (Unix)"
+// @Param type query string false "Main types of data" Enums(written_bytes, read_bytes, written_keys, read_keys, integration)
+// @Param format query string false "Image format" Enums(png, svg)
+// @Param width query int false "Image width in pixels"
+// @Param height query int false "Image height in pixels"
+// @Success 200 {string} string
+// @Router /keyvisual/heatmaps/render [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) renderHeatmap(c *gin.Context) {
+	data, format, err := s.renderHeatmapQuery(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.Data(http.StatusOK, format.ContentType(), data)
+}
+
+// @Summary Render Key Visual Heatmaps and return a token for downloading
+// @Description Render the heatmap in a given range into a PNG or SVG image, which can be downloaded without authentication by the token later
+// @Param startkey query string false "The start of the key range"
+// @Param endkey query string false "The end of the key range"
+// @Param starttime query int false "The start of the time range (Unix)"
+// @Param endtime query int false "The end of the time range (Unix)"
+// @Param type query string false "Main types of data" Enums(written_bytes, read_bytes, written_keys, read_keys, integration)
+// @Param format query string false "Image format" Enums(png, svg)
+// @Param width query int false "Image width in pixels"
+// @Param height query int false "Image height in pixels"
+// @Success 200 {string} string
+// @Router /keyvisual/heatmaps/render/token [get]
+// @Security JwtAuth
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) getRenderedHeatmapToken(c *gin.Context) {
+	data, format, err := s.renderHeatmapQuery(c)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+
+	writer, err := s.fSwap.NewFileWriter("keyviz_heatmap")
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	defer func() {
+		_ = writer.Close()
+	}()
+	if _, err := writer.Write(data); err != nil {
+		writer.Remove()
+		rest.Error(c, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("heatmap_%d.%s", time.Now().Unix(), format)
+	downloadToken, err := writer.GetDownloadToken(fileName, time.Minute*5)
+	if err != nil {
+		// This shall never happen
+		rest.Error(c, err)
+		return
+	}
+	c.String(http.StatusOK, downloadToken)
+}
+
+// @Summary Download a rendered heatmap
+// @Param token query string true "download token"
+// @Success 200 {object} string
+// @Failure 400 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+// @Router /keyvisual/heatmaps/render/download [get]
+func (s *Service) downloadRenderedHeatmap(c *gin.Context) {
+	s.fSwap.HandleDownloadRequest(c)
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package render
+
+import (
+	"image/color"
+	"strings"
+)
+
+// palette is a color scale from the lowest value to the highest value.
+type palette []color.RGBA
+
+var (
+	// paletteBytes is similar to the inferno color map.
+	paletteBytes = palette{
+		{R: 0, G: 0, B: 4, A: 255},
+		{R: 40, G: 11, B: 84, A: 255},
+		{R: 101, G: 21, B: 110, A: 255},
+		{R: 159, G: 42, B: 99, A: 255},
+		{R: 212, G: 72, B: 66, A: 255},
+		{R: 245, G: 125, B: 21, A: 255},
+		{R: 250, G: 193, B: 39, A: 255},
+		{R: 252, G: 255, B: 164, A: 255},
+	}
+	// paletteKeys is similar to the viridis color map.
+	paletteKeys = palette{
+		{R: 68, G: 1, B: 84, A: 255},
+		{R: 70, G: 50, B: 127, A: 255},
+		{R: 54, G: 92, B: 141, A: 255},
+		{R: 39, G: 127, B: 142, A: 255},
+		{R: 31, G: 161, B: 135, A: 255},
+		{R: 74, G: 194, B: 109, A: 255},
+		{R: 159, G: 218, B: 58, A: 255},
+		{R: 253, G: 231, B: 37, A: 255},
+	}
+)
+
+func paletteOf(tag string) palette {
+	if strings.HasSuffix(tag, "_keys") {
+		return paletteKeys
+	}
+	return paletteBytes
+}
+
+// At returns the color at t in [0, 1]. The colors are quantized so that similar values can be merged.
+func (p palette) At(t float64) color.RGBA {
+	const levels = 256
+	t = float64(int(min(max(t, 0), 1)*levels)) / levels
+	pos := t * float64(len(p)-1)
+	i := min(int(pos), len(p)-2)
+	f := pos - float64(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*f)
+	}
+	return color.RGBA{
+		R: lerp(p[i].R, p[i+1].R),
+		G: lerp(p[i].G, p[i+1].G),
+		B: lerp(p[i].B, p[i+1].B),
+		A: 255,
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+type rasterCanvas struct {
+	img *image.RGBA
+}
+
+func newRasterCanvas(width, height int) *rasterCanvas {
+	return &rasterCanvas{
+		img: image.NewRGBA(image.Rect(0, 0, width, height)),
+	}
+}
+
+func (r *rasterCanvas) FillRect(x, y, w, h int, c color.RGBA) {
+	draw.Draw(r.img, image.Rect(x, y, x+w, y+h), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func (r *rasterCanvas) Text(x, y int, s string, anchor textAnchor, c color.RGBA) {
+	switch anchor {
+	case anchorMiddle:
+		x -= len(s) * charWidth / 2
+	case anchorEnd:
+		x -= len(s) * charWidth
+	}
+	d := &font.Drawer{
+		Dst:  r.img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y+basicfont.Face7x13.Ascent/2),
+	}
+	d.DrawString(s)
+}
+
+func (r *rasterCanvas) Encode(w io.Writer) error {
+	return png.Encode(w, r.img)
+}
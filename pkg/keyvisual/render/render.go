@@ -0,0 +1,256 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package render draws a heatmap Matrix into an image, with the key labels, the time axis and the color scale.
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/joomcode/errorx"
+
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/matrix"
+)
+
+var (
+	ErrNS                = errorx.NewNamespace("error.keyvisual")
+	ErrNSRender          = ErrNS.NewSubNamespace("render")
+	ErrUnsupportedFormat = ErrNSRender.NewType("unsupported_format")
+)
+
+// Format is the output image format.
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatSVG:
+		return "image/svg+xml"
+	default:
+		return "image/png"
+	}
+}
+
+const (
+	DefaultWidth  = 1200
+	DefaultHeight = 800
+	MinWidth      = 480
+	MinHeight     = 320
+	MaxWidth      = 4096
+	MaxHeight     = 4096
+
+	marginLeft   = 220
+	marginRight  = 112
+	marginTop    = 16
+	marginBottom = 44
+	legendWidth  = 16
+	legendSteps  = 64
+	timeTicks    = 6
+	minLabelGap  = 14
+
+	// The size of the built-in monospace font.
+	charWidth  = 7
+	charHeight = 13
+)
+
+// Options controls the size of the image.
+type Options struct {
+	Width  int
+	Height int
+}
+
+// Adjust fills the default size and clamps the size into the allowed range.
+func (o *Options) Adjust() {
+	if o.Width == 0 {
+		o.Width = DefaultWidth
+	}
+	if o.Height == 0 {
+		o.Height = DefaultHeight
+	}
+	o.Width = min(max(o.Width, MinWidth), MaxWidth)
+	o.Height = min(max(o.Height, MinHeight), MaxHeight)
+}
+
+type textAnchor int
+
+const (
+	anchorStart textAnchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+// canvas is implemented by each output format.
+type canvas interface {
+	FillRect(x, y, w, h int, c color.RGBA)
+	// Text draws a single line of text, which is vertically centered at y.
+	Text(x, y int, s string, anchor textAnchor, c color.RGBA)
+	Encode(w io.Writer) error
+}
+
+// Render draws the data of the tag in the matrix into w.
+func Render(w io.Writer, format Format, mx matrix.Matrix, tag string, opts Options) error {
+	opts.Adjust()
+	var c canvas
+	switch format {
+	case FormatPNG:
+		c = newRasterCanvas(opts.Width, opts.Height)
+	case FormatSVG:
+		c = newSVGCanvas(opts.Width, opts.Height)
+	default:
+		return ErrUnsupportedFormat.New("format must be %s or %s", FormatPNG, FormatSVG)
+	}
+	drawHeatmap(c, mx, tag, opts.Width, opts.Height)
+	return c.Encode(w)
+}
+
+var (
+	colorBackground = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	colorText       = color.RGBA{R: 51, G: 51, B: 51, A: 255}
+	colorTick       = color.RGBA{R: 153, G: 153, B: 153, A: 255}
+)
+
+func drawHeatmap(c canvas, mx matrix.Matrix, tag string, width, height int) {
+	c.FillRect(0, 0, width, height, colorBackground)
+
+	plotX, plotY := marginLeft, marginTop
+	plotW, plotH := width-marginLeft-marginRight, height-marginTop-marginBottom
+
+	data := mx.DataMap[tag]
+	if len(data) == 0 || len(data[0]) == 0 {
+		c.Text(plotX+plotW/2, plotY+plotH/2, "No data", anchorMiddle, colorText)
+		return
+	}
+	cols, rows := len(data), len(data[0])
+
+	var maxValue uint64
+	for _, values := range data {
+		for _, v := range values {
+			maxValue = max(maxValue, v)
+		}
+	}
+	palette := paletteOf(tag)
+
+	// cells, consecutive cells of the same color in a column are merged
+	for i, values := range data {
+		x0, x1 := plotX+i*plotW/cols, plotX+(i+1)*plotW/cols
+		for j := 0; j < rows; {
+			cellColor := palette.At(scale(values[j], maxValue))
+			k := j + 1
+			for k < rows && palette.At(scale(values[k], maxValue)) == cellColor {
+				k++
+			}
+			y0, y1 := plotY+j*plotH/rows, plotY+k*plotH/rows
+			c.FillRect(x0, y0, x1-x0, y1-y0, cellColor)
+			j = k
+		}
+	}
+
+	drawKeyAxis(c, mx, rows, plotX, plotY, plotH)
+	drawTimeAxis(c, mx, cols, plotX, plotY+plotH, plotW)
+	drawLegend(c, palette, tag, maxValue, plotX+plotW+24, plotY, plotH)
+}
+
+// drawKeyAxis labels the groups of consecutive buckets which belong to the same logical range.
+func drawKeyAxis(c canvas, mx matrix.Matrix, rows, x, y, h int) {
+	if len(mx.KeyAxis) < rows {
+		return
+	}
+	maxChars := (marginLeft - 12) / charWidth
+	lastLabelY := y - minLabelGap
+	for j := 0; j < rows; {
+		label := keyLabel(mx.KeyAxis[j].Labels)
+		k := j + 1
+		for k < rows && keyLabel(mx.KeyAxis[k].Labels) == label {
+			k++
+		}
+		y0, y1 := y+j*h/rows, y+k*h/rows
+		c.FillRect(x-4, y0, 4, 1, colorTick)
+		mid := (y0 + y1) / 2
+		if label != "" && y1-y0 >= minLabelGap/2 && mid-lastLabelY >= minLabelGap {
+			c.Text(x-8, mid, truncate(label, maxChars), anchorEnd, colorText)
+			lastLabelY = mid
+		}
+		j = k
+	}
+}
+
+func keyLabel(labels []string) string {
+	if len(labels) > 2 {
+		labels = labels[:2]
+	}
+	return strings.Join(labels, ".")
+}
+
+func drawTimeAxis(c canvas, mx matrix.Matrix, cols, x, y, w int) {
+	if len(mx.TimeAxis) < cols+1 {
+		return
+	}
+	c.FillRect(x, y, w, 1, colorTick)
+	n := min(timeTicks, cols)
+	for k := 0; k <= n; k++ {
+		idx := k * cols / n
+		tickX := x + idx*w/cols
+		c.FillRect(tickX, y, 1, 4, colorTick)
+		anchor := anchorMiddle
+		switch k {
+		case 0:
+			anchor = anchorStart
+		case n:
+			anchor = anchorEnd
+		}
+		label := time.Unix(mx.TimeAxis[idx], 0).UTC().Format("01-02 15:04")
+		c.Text(tickX, y+14, label, anchor, colorText)
+	}
+	c.Text(x, y+32, "UTC", anchorStart, colorTick)
+}
+
+func drawLegend(c canvas, palette palette, tag string, maxValue uint64, x, y, h int) {
+	for i := 0; i < legendSteps; i++ {
+		y0, y1 := y+i*h/legendSteps, y+(i+1)*h/legendSteps
+		c.FillRect(x, y0, legendWidth, y1-y0, palette.At(1-float64(i)/float64(legendSteps-1)))
+	}
+	c.Text(x+legendWidth+4, y+charHeight/2, formatValue(maxValue), anchorStart, colorText)
+	c.Text(x+legendWidth+4, y+h-charHeight/2, "0", anchorStart, colorText)
+	c.Text(x+legendWidth/2, y+h+32, truncate(tag, (marginRight-8)/charWidth), anchorMiddle, colorText)
+}
+
+// scale maps the value into [0, 1] in logarithmic scale.
+func scale(v, maxValue uint64) float64 {
+	if maxValue == 0 {
+		return 0
+	}
+	return math.Log1p(float64(v)) / math.Log1p(float64(maxValue))
+}
+
+func formatValue(v uint64) string {
+	units := []string{"", "K", "M", "G", "T", "P"}
+	f := float64(v)
+	i := 0
+	for f >= 1000 && i < len(units)-1 {
+		f /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d", v)
+	}
+	return fmt.Sprintf("%.1f%s", f, units[i])
+}
+
+func truncate(s string, maxChars int) string {
+	if len(s) <= maxChars {
+		return s
+	}
+	if maxChars <= 3 {
+		return s[:maxChars]
+	}
+	return s[:maxChars-3] + "..."
+}
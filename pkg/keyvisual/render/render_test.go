@@ -0,0 +1,67 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/decorator"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/matrix"
+)
+
+func TestRender(t *testing.T) {
+	check.TestingT(t)
+}
+
+var _ = check.Suite(&testRenderSuite{})
+
+type testRenderSuite struct{}
+
+func buildMatrix() matrix.Matrix {
+	return matrix.Matrix{
+		DataMap: map[string][][]uint64{
+			"written_bytes": {{0, 10, 1000}, {5, 2000, 0}},
+		},
+		KeyAxis: []decorator.LabelKey{
+			{Key: "", Labels: []string{"meta"}},
+			{Key: "74", Labels: []string{"test", "t1"}},
+			{Key: "75", Labels: []string{"test", "t2", "idx"}},
+			{Key: "", Labels: []string{}},
+		},
+		TimeAxis: []int64{0, 60, 120},
+	}
+}
+
+func (s *testRenderSuite) TestRenderPNG(c *check.C) {
+	var buf bytes.Buffer
+	err := Render(&buf, FormatPNG, buildMatrix(), "written_bytes", Options{Width: 640, Height: 480})
+	c.Assert(err, check.IsNil)
+	img, err := png.Decode(&buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(img.Bounds().Dx(), check.Equals, 640)
+	c.Assert(img.Bounds().Dy(), check.Equals, 480)
+}
+
+func (s *testRenderSuite) TestRenderSVG(c *check.C) {
+	var buf bytes.Buffer
+	err := Render(&buf, FormatSVG, buildMatrix(), "written_bytes", Options{})
+	c.Assert(err, check.IsNil)
+	svg := buf.String()
+	c.Assert(strings.HasPrefix(svg, "<svg "), check.IsTrue)
+	c.Assert(strings.Contains(svg, `width="1200"`), check.IsTrue)
+	c.Assert(strings.Contains(svg, ">test.t1</text>"), check.IsTrue)
+	c.Assert(strings.Contains(svg, ">2.0K</text>"), check.IsTrue)
+	c.Assert(strings.Contains(svg, ">01-01 00:02</text>"), check.IsTrue)
+}
+
+func (s *testRenderSuite) TestRenderUnsupportedFormat(c *check.C) {
+	var buf bytes.Buffer
+	err := Render(&buf, Format("gif"), buildMatrix(), "written_bytes", Options{})
+	c.Assert(errorx.IsOfType(err, ErrUnsupportedFormat), check.IsTrue)
+}
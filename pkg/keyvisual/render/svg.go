@@ -0,0 +1,56 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+type svgCanvas struct {
+	width  int
+	height int
+	body   strings.Builder
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	return &svgCanvas{
+		width:  width,
+		height: height,
+	}
+}
+
+func (s *svgCanvas) FillRect(x, y, w, h int, c color.RGBA) {
+	fmt.Fprintf(&s.body, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x, y, w, h, hexColor(c))
+}
+
+func (s *svgCanvas) Text(x, y int, str string, anchor textAnchor, c color.RGBA) {
+	textAnchor := "start"
+	switch anchor {
+	case anchorMiddle:
+		textAnchor = "middle"
+	case anchorEnd:
+		textAnchor = "end"
+	}
+	fmt.Fprintf(&s.body, `<text x="%d" y="%d" text-anchor="%s" dominant-baseline="central" fill="%s">`, x, y, textAnchor, hexColor(c))
+	_ = xml.EscapeText(&s.body, []byte(str))
+	s.body.WriteString("</text>\n")
+}
+
+func (s *svgCanvas) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" `+
+		`font-family="monospace" font-size="12" shape-rendering="crispEdges">`+"\n",
+		s.width, s.height, s.width, s.height)
+	_, _ = bw.WriteString(s.body.String())
+	_, _ = bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
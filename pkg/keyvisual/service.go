@@ -29,6 +29,8 @@ import (
 	"github.com/pingcap/tidb-dashboard/pkg/pd"
 	"github.com/pingcap/tidb-dashboard/pkg/tidb"
 	"github.com/pingcap/tidb-dashboard/pkg/utils"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+	"github.com/pingcap/tidb-dashboard/util/rest/fileswap"
 )
 
 const (
@@ -42,16 +44,7 @@ const (
 var (
 	ErrNS             = errorx.NewNamespace("error.keyvisual")
 	ErrServiceStopped = ErrNS.NewType("service_stopped")
-
-	defaultStatConfig = storage.StatConfig{
-		LayersConfig: []storage.LayerConfig{
-			{Len: 60, Ratio: 2 / 1},                     // step 1 minutes, total 60, 1 hours (sum: 1 hours)
-			{Len: 60 / 2 * 7, Ratio: 6 / 2},             // step 2 minutes, total 210, 7 hours (sum: 8 hours)
-			{Len: 60 / 6 * 16, Ratio: 30 / 6},           // step 6 minutes, total 160, 16 hours (sum: 1 days)
-			{Len: 60 / 30 * 24 * 6, Ratio: 4 * 60 / 30}, // step 30 minutes, total 288, 6 days (sum: 1 weeks)
-			{Len: 24 / 4 * 28, Ratio: 0},                // step 4 hours, total 168, 4 weeks (sum: 5 weeks)
-		},
-	}
+	ErrRenderFailed   = ErrNS.NewType("render_failed")
 )
 
 type Service struct {
@@ -70,6 +63,10 @@ type Service struct {
 	db             *dbstore.DB
 	tidbClient     *tidb.Client
 
+	fSwap *fileswap.Handler
+
+	// stat is replaced when the service restarts on config changes, while handlers read it concurrently.
+	statMu        sync.RWMutex
 	stat          *storage.Stat
 	strategy      *matrix.Strategy
 	labelStrategy decorator.LabelStrategy
@@ -95,6 +92,7 @@ func NewService(
 		pdClient:       pdClient,
 		db:             db,
 		tidbClient:     tidbClient,
+		fSwap:          fileswap.New(),
 	}
 
 	lc.Append(s.managerHook())
@@ -104,6 +102,10 @@ func NewService(
 
 func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 	endpoint := r.Group("/keyvisual")
+	// WebSocket clients can not set headers, so the subscription is authenticated by a token.
+	endpoint.GET("/heatmaps/subscribe", s.status.MWHandleStopped(stoppedHandler), s.subscribeHeatmaps)
+	endpoint.GET("/heatmaps/render/download", s.downloadRenderedHeatmap)
+
 	endpoint.Use(auth.MWAuthRequired())
 
 	endpoint.GET("/config", s.getDynamicConfig)
@@ -111,6 +113,11 @@ func RegisterRouter(r *gin.RouterGroup, auth *user.AuthService, s *Service) {
 
 	endpoint.Use(s.status.MWHandleStopped(stoppedHandler))
 	endpoint.GET("/heatmaps", s.heatmaps)
+	endpoint.GET("/heatmaps/subscribe_token", s.getSubscribeToken)
+	endpoint.GET("/heatmaps/render", s.renderHeatmap)
+	endpoint.GET("/heatmaps/render/token", s.getRenderedHeatmapToken)
+	endpoint.GET("/stats", s.storageStats)
+	endpoint.GET("/events", s.regionEvents)
 }
 
 func (s *Service) IsRunning() bool {
@@ -124,18 +131,20 @@ func (s *Service) Start(ctx context.Context) error {
 
 	s.ctx, s.cancel = context.WithCancel(ctx)
 
+	var stat *storage.Stat
 	s.app = fx.New(
 		fx.Logger(utils.NewFxPrinter()),
 		fx.Provide(
 			newWaitGroup,
 			newStrategy,
+			s.newStatConfig,
 			newStat,
 			s.provideLocals,
 			s.newProvider,
 			input.NewStatInput,
 			s.newLabelStrategy,
 		),
-		fx.Populate(&s.stat, &s.strategy, &s.labelStrategy),
+		fx.Populate(&stat, &s.strategy, &s.labelStrategy),
 		fx.Invoke(
 			// Must be at the end
 			s.status.Register,
@@ -146,14 +155,28 @@ func (s *Service) Start(ctx context.Context) error {
 		s.cleanAfterError()
 		return err
 	}
+	s.setStat(stat)
 
 	return nil
 }
 
+func (s *Service) getStat() *storage.Stat {
+	s.statMu.RLock()
+	defer s.statMu.RUnlock()
+	return s.stat
+}
+
+func (s *Service) setStat(stat *storage.Stat) {
+	s.statMu.Lock()
+	defer s.statMu.Unlock()
+	s.stat = stat
+}
+
 func (s *Service) newLabelStrategy(
 	lc fx.Lifecycle,
 	wg *sync.WaitGroup,
 	etcdClient *clientv3.Client,
+	pdClient *pd.Client,
 	tidbClient *tidb.Client,
 ) decorator.LabelStrategy {
 	switch s.keyVisualCfg.Policy {
@@ -164,6 +187,9 @@ func (s *Service) newLabelStrategy(
 		log.Debug("New LabelStrategy", zap.String("policy", s.keyVisualCfg.Policy),
 			zap.String("separator", s.keyVisualCfg.PolicyKVSeparator))
 		return decorator.SeparatorLabelStrategy(s.keyVisualCfg)
+	case config.KeyVisualKeyspacePolicy:
+		log.Debug("New LabelStrategy", zap.String("policy", s.keyVisualCfg.Policy))
+		return decorator.KeyspaceLabelStrategy(lc, wg, pdClient)
 	default:
 		panic("unreachable")
 	}
@@ -173,6 +199,11 @@ func (s *Service) newProvider(pdClient *pd.Client) *region.DataProvider {
 	if s.customProvider != nil {
 		return s.customProvider
 	}
+	if s.keyVisualCfg.ReplicaView == config.KeyVisualTiFlashView {
+		return &region.DataProvider{
+			PeriodicGetter: input.NewTiFlashPeriodicGetter(pdClient),
+		}
+	}
 	return &region.DataProvider{
 		PeriodicGetter: input.NewAPIPeriodicGetter(pdClient),
 	}
@@ -183,6 +214,20 @@ func (s *Service) reloadKeyVisualConfig(cfg *config.KeyVisualConfig) {
 	if s.labelStrategy != nil {
 		s.labelStrategy.ReloadConfig(s.keyVisualCfg)
 	}
+	if stat := s.getStat(); stat != nil {
+		stat.SetStorageBudget(s.keyVisualCfg.StorageBudgetBytes)
+	}
+}
+
+func (s *Service) newStatConfig() storage.StatConfig {
+	layersConfig := make([]storage.LayerConfig, 0, len(s.keyVisualCfg.Layers))
+	for _, layer := range s.keyVisualCfg.Layers {
+		layersConfig = append(layersConfig, storage.LayerConfig{Len: layer.Len, Ratio: layer.Ratio})
+	}
+	return storage.StatConfig{
+		LayersConfig:       layersConfig,
+		StorageBudgetBytes: s.keyVisualCfg.StorageBudgetBytes,
+	}
 }
 
 func (s *Service) cleanAfterError() {
@@ -190,7 +235,7 @@ func (s *Service) cleanAfterError() {
 
 	// drop
 	s.app = nil
-	s.stat = nil
+	s.setStat(nil)
 	s.strategy = nil
 	s.labelStrategy = nil
 	s.ctx = nil
@@ -207,7 +252,7 @@ func (s *Service) Stop(ctx context.Context) error {
 
 	// drop
 	s.app = nil
-	s.stat = nil
+	s.setStat(nil)
 	s.strategy = nil
 	s.labelStrategy = nil
 	s.ctx = nil
@@ -228,19 +273,98 @@ func (s *Service) Stop(ctx context.Context) error {
 // @Security JwtAuth
 // @Failure 401 {object} rest.ErrorResponse
 func (s *Service) heatmaps(c *gin.Context) {
-	startKey := c.Query("startkey")
-	endKey := c.Query("endkey")
+	typ := c.Query("type")
+	startTime, endTime, startKey, endKey, ok := parseRangeQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, "bad request")
+		return
+	}
+
+	stat := s.getStat()
+	if stat == nil {
+		stoppedHandler(c)
+		return
+	}
+	resp := s.generateHeatmap(stat, startTime, endTime, startKey, endKey, region.IntoTag(typ))
+	// TODO: An expedient to reduce data transmission, which needs to be deleted later.
+	resp.DataMap = map[string][][]uint64{
+		typ: resp.DataMap[typ],
+	}
+	// ----------
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Service) generateHeatmap(stat *storage.Stat, startTime, endTime time.Time, startKey, endKey string, baseTag region.StatTag) matrix.Matrix {
+	plane := stat.Range(startTime, endTime, startKey, endKey, baseTag)
+	resp := plane.Pixel(s.strategy, heatmapsMaxDisplayY, region.GetDisplayTags(baseTag))
+	resp.Range(startKey, endKey)
+	return resp
+}
+
+// @Summary Key Visual Storage Stats
+// @Description Row count, time coverage and bytes used of each storage layer
+// @Success 200 {object} storage.StorageStats
+// @Router /keyvisual/stats [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+func (s *Service) storageStats(c *gin.Context) {
+	stat := s.getStat()
+	if stat == nil {
+		stoppedHandler(c)
+		return
+	}
+	c.JSON(http.StatusOK, stat.Stats())
+}
+
+// @Summary Key Visual Region Events
+// @Description Region split, merge and leader transfer events in a given range, used as an overlay of heatmaps
+// @Param startkey query string false "The start of the key range"
+// @Param endkey query string false "The end of the key range"
+// @Param starttime query int false "The start of the time range (Unix)"
+// @Param endtime query int false "The end of the time range (Unix)"
+// @Success 200 {array} storage.RegionEvent
+// @Router /keyvisual/events [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 404 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) regionEvents(c *gin.Context) {
+	startTime, endTime, startKey, endKey, ok := parseRangeQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, "bad request")
+		return
+	}
+
+	stat := s.getStat()
+	if stat == nil {
+		stoppedHandler(c)
+		return
+	}
+	events, err := stat.RegionEvents(startTime, endTime, startKey, endKey)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	for i := range events {
+		events[i].StartKey = hex.EncodeToString(region.Bytes(events[i].StartKey))
+		events[i].EndKey = hex.EncodeToString(region.Bytes(events[i].EndKey))
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// parseRangeQuery parses the time range and the hex encoded key range from the query.
+func parseRangeQuery(c *gin.Context) (startTime, endTime time.Time, startKey, endKey string, ok bool) {
+	startKey = c.Query("startkey")
+	endKey = c.Query("endkey")
 	startTimeString := c.Query("starttime")
 	endTimeString := c.Query("endtime")
-	typ := c.Query("type")
 
-	endTime := time.Now()
-	startTime := endTime.Add(-360 * time.Minute)
+	endTime = time.Now()
+	startTime = endTime.Add(-360 * time.Minute)
 	if startTimeString != "" {
 		tsSec, err := strconv.ParseInt(startTimeString, 10, 64)
 		if err != nil {
 			log.Error("parse ts failed", zap.Error(err))
-			c.JSON(http.StatusBadRequest, "bad request")
 			return
 		}
 		startTime = time.Unix(tsSec, 0)
@@ -249,46 +373,30 @@ func (s *Service) heatmaps(c *gin.Context) {
 		tsSec, err := strconv.ParseInt(endTimeString, 10, 64)
 		if err != nil {
 			log.Error("parse ts failed", zap.Error(err))
-			c.JSON(http.StatusBadRequest, "bad request")
 			return
 		}
 		endTime = time.Unix(tsSec, 0)
 	}
 	if !startTime.Before(endTime) || (endKey != "" && startKey >= endKey) {
-		c.JSON(http.StatusBadRequest, "bad request")
 		return
 	}
 
-	log.Debug("Request matrix",
+	log.Debug("Request range",
 		zap.Time("start-time", startTime),
 		zap.Time("end-time", endTime),
 		zap.String("start-key", startKey),
 		zap.String("end-key", endKey),
-		zap.String("type", typ),
 	)
 
-	if startKeyBytes, err := hex.DecodeString(startKey); err == nil {
-		startKey = string(startKeyBytes)
-	} else {
-		c.JSON(http.StatusBadRequest, "bad request")
+	startKeyBytes, err := hex.DecodeString(startKey)
+	if err != nil {
 		return
 	}
-	if endKeyBytes, err := hex.DecodeString(endKey); err == nil {
-		endKey = string(endKeyBytes)
-	} else {
-		c.JSON(http.StatusBadRequest, "bad request")
+	endKeyBytes, err := hex.DecodeString(endKey)
+	if err != nil {
 		return
 	}
-	baseTag := region.IntoTag(typ)
-	plane := s.stat.Range(startTime, endTime, startKey, endKey, baseTag)
-	resp := plane.Pixel(s.strategy, heatmapsMaxDisplayY, region.GetDisplayTags(baseTag))
-	resp.Range(startKey, endKey)
-	// TODO: An expedient to reduce data transmission, which needs to be deleted later.
-	resp.DataMap = map[string][][]uint64{
-		typ: resp.DataMap[typ],
-	}
-	// ----------
-	c.JSON(http.StatusOK, resp)
+	return startTime, endTime, string(startKeyBytes), string(endKeyBytes), true
 }
 
 func (s *Service) provideLocals() (*config.Config, *clientv3.Client, *pd.Client, *dbstore.DB, *tidb.Client) {
@@ -325,8 +433,9 @@ func newStat(
 	db *dbstore.DB,
 	in input.StatInput,
 	strategy *matrix.Strategy,
+	statConfig storage.StatConfig,
 ) *storage.Stat {
-	stat := storage.NewStat(lc, wg, db, defaultStatConfig, strategy, in.GetStartTime())
+	stat := storage.NewStat(lc, wg, db, statConfig, strategy, in.GetStartTime())
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -340,6 +449,12 @@ func newStat(
 	return stat
 }
 
+// newServiceStoppedError returns an error that is responded with 404, as the data is not available until the service
+// is started again.
+func newServiceStoppedError() error {
+	return ErrServiceStopped.NewWithNoMessage().WithProperty(rest.HTTPCodeProperty(http.StatusNotFound))
+}
+
 func stoppedHandler(c *gin.Context) {
-	_ = c.AbortWithError(http.StatusNotFound, ErrServiceStopped.NewWithNoMessage())
+	_ = c.AbortWithError(http.StatusNotFound, newServiceStoppedError())
 }
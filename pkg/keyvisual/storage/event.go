@@ -0,0 +1,122 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
+)
+
+// RegionEventType is the type of scheduling events derived from region snapshots.
+type RegionEventType string
+
+const (
+	RegionEventSplit          RegionEventType = "split"
+	RegionEventMerge          RegionEventType = "merge"
+	RegionEventLeaderTransfer RegionEventType = "leader_transfer"
+)
+
+// RegionEvent is a scheduling event happened in a key range. For split events, the key range is the range of the
+// parent region before split. For merge events, the key range is the range of the merged region.
+type RegionEvent struct {
+	Type        RegionEventType `json:"type"`
+	Time        int64           `json:"time"`
+	StartKey    string          `json:"start_key"`
+	EndKey      string          `json:"end_key"`
+	RegionIDs   []uint64        `json:"region_ids"`
+	FromStoreID uint64          `json:"from_store_id,omitempty"`
+	ToStoreID   uint64          `json:"to_store_id,omitempty"`
+}
+
+// DiffRegionMetas derives the split, merge and leader transfer events between two consecutive snapshots.
+// Both snapshots must be sorted by StartKey.
+func DiffRegionMetas(prev, cur []region.RegionMeta, t time.Time) []RegionEvent {
+	prevByID := indexRegionMetas(prev)
+	curByID := indexRegionMetas(cur)
+
+	var events []RegionEvent
+	// A region whose range shrinks is split, and the new regions are inside its original range.
+	for _, p := range prev {
+		c, ok := curByID[p.ID]
+		if !ok || (c.StartKey == p.StartKey && c.EndKey == p.EndKey) {
+			continue
+		}
+		ids := append([]uint64{p.ID}, regionsInRange(cur, prevByID, p.StartKey, p.EndKey)...)
+		if len(ids) > 1 {
+			events = append(events, RegionEvent{
+				Type:      RegionEventSplit,
+				Time:      t.Unix(),
+				StartKey:  p.StartKey,
+				EndKey:    p.EndKey,
+				RegionIDs: ids,
+			})
+		}
+	}
+	// A region whose range grows is merged, and the vanished regions are inside its new range.
+	for _, c := range cur {
+		p, ok := prevByID[c.ID]
+		if !ok || (c.StartKey == p.StartKey && c.EndKey == p.EndKey) {
+			continue
+		}
+		ids := append([]uint64{c.ID}, regionsInRange(prev, curByID, c.StartKey, c.EndKey)...)
+		if len(ids) > 1 {
+			events = append(events, RegionEvent{
+				Type:      RegionEventMerge,
+				Time:      t.Unix(),
+				StartKey:  c.StartKey,
+				EndKey:    c.EndKey,
+				RegionIDs: ids,
+			})
+		}
+	}
+	for _, c := range cur {
+		p, ok := prevByID[c.ID]
+		if !ok || p.LeaderStoreID == 0 || c.LeaderStoreID == 0 || p.LeaderStoreID == c.LeaderStoreID {
+			continue
+		}
+		events = append(events, RegionEvent{
+			Type:        RegionEventLeaderTransfer,
+			Time:        t.Unix(),
+			StartKey:    c.StartKey,
+			EndKey:      c.EndKey,
+			RegionIDs:   []uint64{c.ID},
+			FromStoreID: p.LeaderStoreID,
+			ToStoreID:   c.LeaderStoreID,
+		})
+	}
+	return events
+}
+
+func indexRegionMetas(metas []region.RegionMeta) map[uint64]region.RegionMeta {
+	m := make(map[uint64]region.RegionMeta, len(metas))
+	for _, meta := range metas {
+		if meta.ID != 0 {
+			m[meta.ID] = meta
+		}
+	}
+	return m
+}
+
+// regionsInRange returns the IDs of the regions inside [startKey, endKey) which are absent in the other snapshot.
+func regionsInRange(metas []region.RegionMeta, other map[uint64]region.RegionMeta, startKey, endKey string) []uint64 {
+	var ids []uint64
+	i := sort.Search(len(metas), func(i int) bool {
+		return metas[i].StartKey >= startKey
+	})
+	for ; i < len(metas) && (endKey == "" || metas[i].StartKey < endKey); i++ {
+		if metas[i].ID == 0 {
+			continue
+		}
+		if _, ok := other[metas[i].ID]; !ok {
+			ids = append(ids, metas[i].ID)
+		}
+	}
+	return ids
+}
+
+// Overlaps checks whether the event overlaps with [startKey, endKey). An empty endKey means no upper bound.
+func (e *RegionEvent) Overlaps(startKey, endKey string) bool {
+	return (endKey == "" || e.StartKey < endKey) && (e.EndKey == "" || e.EndKey > startKey)
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+)
+
+const (
+	tableRegionEventModelName = "keyviz_region_event"
+	// The approximate size of the fixed-length columns of a region event in db.
+	regionEventFixedBytes = 40
+)
+
+var regionEventBytesExpr = fmt.Sprintf("LENGTH(start_key) + LENGTH(end_key) + LENGTH(region_ids) + %d", regionEventFixedBytes)
+
+type RegionEventModel struct {
+	ID          uint      `gorm:"primary_key"`
+	Time        time.Time `gorm:"index"`
+	Type        string
+	StartKey    []byte
+	EndKey      []byte
+	RegionIDs   string `gorm:"type:text"`
+	FromStoreID uint64
+	ToStoreID   uint64
+}
+
+func (RegionEventModel) TableName() string {
+	return tableRegionEventModelName
+}
+
+func NewRegionEventModel(event RegionEvent) (*RegionEventModel, error) {
+	regionIDs, err := json.Marshal(event.RegionIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &RegionEventModel{
+		Time:        time.Unix(event.Time, 0),
+		Type:        string(event.Type),
+		StartKey:    []byte(event.StartKey),
+		EndKey:      []byte(event.EndKey),
+		RegionIDs:   string(regionIDs),
+		FromStoreID: event.FromStoreID,
+		ToStoreID:   event.ToStoreID,
+	}, nil
+}
+
+// Size returns the approximate size of the event in db, which counts towards the storage budget.
+func (m *RegionEventModel) Size() int64 {
+	return int64(len(m.StartKey)+len(m.EndKey)+len(m.RegionIDs)) + regionEventFixedBytes
+}
+
+func (m *RegionEventModel) UnmarshalEvent() (RegionEvent, error) {
+	event := RegionEvent{
+		Type:        RegionEventType(m.Type),
+		Time:        m.Time.Unix(),
+		StartKey:    string(m.StartKey),
+		EndKey:      string(m.EndKey),
+		FromStoreID: m.FromStoreID,
+		ToStoreID:   m.ToStoreID,
+	}
+	err := json.Unmarshal([]byte(m.RegionIDs), &event.RegionIDs)
+	return event, err
+}
+
+func AutoMigrateRegionEventModel(db *dbstore.DB) error {
+	return db.AutoMigrate(&RegionEventModel{})
+}
+
+// InsertRegionEvents stores the events and returns their size in db.
+func InsertRegionEvents(db *dbstore.DB, events []RegionEvent) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	models := make([]*RegionEventModel, 0, len(events))
+	var size int64
+	for _, event := range events {
+		m, err := NewRegionEventModel(event)
+		if err != nil {
+			return 0, err
+		}
+		models = append(models, m)
+		size += m.Size()
+	}
+	return size, db.Create(&models).Error
+}
+
+// FindRegionEventsByTime returns the events in [startTime, endTime], ordered by time.
+func FindRegionEventsByTime(db *dbstore.DB, startTime, endTime time.Time) ([]*RegionEventModel, error) {
+	var models []*RegionEventModel
+	err := db.
+		Where("time >= ? AND time <= ?", startTime, endTime).
+		Order("time").
+		Find(&models).
+		Error
+	return models, err
+}
+
+func DeleteRegionEventsBefore(db *dbstore.DB, t time.Time) error {
+	return db.
+		Where("time < ?", t).
+		Delete(&RegionEventModel{}).
+		Error
+}
+
+// SumRegionEventBytes returns the total size of the events in db.
+func SumRegionEventBytes(db *dbstore.DB) (int64, error) {
+	var size int64
+	err := db.
+		Model(&RegionEventModel{}).
+		Select("COALESCE(SUM(" + regionEventBytesExpr + "), 0)").
+		Scan(&size).
+		Error
+	return size, err
+}
+
+// DeleteOldestRegionEvents deletes the oldest events whose total size is at least the specified bytes, and returns
+// the size of the deleted events.
+func DeleteOldestRegionEvents(db *dbstore.DB, bytes int64) (int64, error) {
+	var rows []struct {
+		ID   uint
+		Size int64
+	}
+	var deleted int64
+	for deleted < bytes {
+		err := db.
+			Model(&RegionEventModel{}).
+			Select("id, " + regionEventBytesExpr + " AS size").
+			Order("time, id").
+			Limit(1000).
+			Scan(&rows).
+			Error
+		if err != nil || len(rows) == 0 {
+			return deleted, err
+		}
+		ids := make([]uint, 0, len(rows))
+		var size int64
+		for _, row := range rows {
+			if deleted+size >= bytes {
+				break
+			}
+			ids = append(ids, row.ID)
+			size += row.Size
+		}
+		if err := db.Where("id IN ?", ids).Delete(&RegionEventModel{}).Error; err != nil {
+			return deleted, err
+		}
+		deleted += size
+	}
+	return deleted, nil
+}
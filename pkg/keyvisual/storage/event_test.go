@@ -0,0 +1,41 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"time"
+
+	"github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
+)
+
+var _ = check.Suite(&testEventSuite{})
+
+type testEventSuite struct{}
+
+func (s *testEventSuite) TestDiffRegionMetas(c *check.C) {
+	prev := []region.RegionMeta{
+		{ID: 1, StartKey: "", EndKey: "b", LeaderStoreID: 1},
+		{ID: 2, StartKey: "b", EndKey: "d", LeaderStoreID: 1},
+		{ID: 3, StartKey: "d", EndKey: "e", LeaderStoreID: 2},
+		{ID: 4, StartKey: "e", EndKey: "", LeaderStoreID: 2},
+	}
+	cur := []region.RegionMeta{
+		{ID: 1, StartKey: "", EndKey: "b", LeaderStoreID: 3},
+		{ID: 5, StartKey: "b", EndKey: "c", LeaderStoreID: 1},
+		{ID: 2, StartKey: "c", EndKey: "d", LeaderStoreID: 1},
+		{ID: 4, StartKey: "d", EndKey: "", LeaderStoreID: 2},
+	}
+	events := DiffRegionMetas(prev, cur, time.Unix(100, 0))
+	c.Assert(events, check.DeepEquals, []RegionEvent{
+		{Type: RegionEventSplit, Time: 100, StartKey: "b", EndKey: "d", RegionIDs: []uint64{2, 5}},
+		{Type: RegionEventMerge, Time: 100, StartKey: "d", EndKey: "", RegionIDs: []uint64{4, 3}},
+		{Type: RegionEventLeaderTransfer, Time: 100, StartKey: "", EndKey: "b", RegionIDs: []uint64{1}, FromStoreID: 1, ToStoreID: 3},
+	})
+
+	c.Assert(events[0].Overlaps("a", "c"), check.IsTrue)
+	c.Assert(events[0].Overlaps("d", ""), check.IsFalse)
+	c.Assert(events[1].Overlaps("x", ""), check.IsTrue)
+	c.Assert(DiffRegionMetas(cur, cur, time.Unix(100, 0)), check.HasLen, 0)
+}
@@ -51,6 +51,14 @@ func (a *AxisModel) Insert(db *dbstore.DB) error {
 	return db.Create(a).Error
 }
 
+func (a *AxisModel) Update(db *dbstore.DB) error {
+	return db.
+		Model(&AxisModel{}).
+		Where("layer_num = ? AND time = ?", a.LayerNum, a.Time).
+		Update("axis", a.Axis).
+		Error
+}
+
 func (a *AxisModel) Delete(db *dbstore.DB) error {
 	return db.
 		Where("layer_num = ? AND time = ?", a.LayerNum, a.Time).
@@ -67,6 +75,11 @@ func CreateTableAxisModelIfNotExists(db *dbstore.DB) (bool, error) {
 	return false, db.Migrator().CreateTable(&AxisModel{})
 }
 
+// DropTableAxisModel removes all axes, and the table is created again on the next restore.
+func DropTableAxisModel(db *dbstore.DB) error {
+	return db.Migrator().DropTable(&AxisModel{})
+}
+
 func ClearTableAxisModel(db *dbstore.DB) error {
 	return db.Session(&gorm.Session{AllowGlobalUpdate: true}).
 		Delete(&AxisModel{}).
@@ -9,7 +9,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pingcap/log"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
 	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
 	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/decorator"
@@ -17,6 +19,9 @@ import (
 	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
 )
 
+// minCompactedBuckets is the minimum number of buckets an axis keeps when it is compacted to fit the storage budget.
+const minCompactedBuckets = 64
+
 // LayerConfig is the configuration of layerStat.
 type LayerConfig struct {
 	Len   int
@@ -30,6 +35,9 @@ type layerStat struct {
 	EndTime   time.Time
 	RingAxes  []matrix.Axis
 	RingTimes []time.Time
+	RingBytes []int64
+	// Bytes is the total size of the axes stored in db.
+	Bytes int64
 
 	LayerNum uint8
 	Head     int
@@ -56,6 +64,7 @@ func newLayerStat(
 		EndTime:       startTime,
 		RingAxes:      make([]matrix.Axis, conf.Len),
 		RingTimes:     make([]time.Time, conf.Len),
+		RingBytes:     make([]int64, conf.Len),
 		LayerNum:      layerNum,
 		Head:          0,
 		Tail:          0,
@@ -71,11 +80,7 @@ func newLayerStat(
 // Reduce merges ratio axes and append to next layerStat.
 func (s *layerStat) Reduce(labeler decorator.Labeler) {
 	if s.Ratio == 0 || s.Next == nil {
-		_ = s.DeleteFirstAxisFromDb()
-
-		s.StartTime = s.RingTimes[s.Head]
-		s.RingAxes[s.Head] = matrix.Axis{}
-		s.Head = (s.Head + 1) % s.Len
+		s.popFront()
 		return
 	}
 
@@ -84,13 +89,8 @@ func (s *layerStat) Reduce(labeler decorator.Labeler) {
 	axes := make([]matrix.Axis, 0, s.Ratio)
 
 	for i := 0; i < s.Ratio; i++ {
-		_ = s.DeleteFirstAxisFromDb()
-
-		s.StartTime = s.RingTimes[s.Head]
+		axes = append(axes, s.popFront())
 		times = append(times, s.StartTime)
-		axes = append(axes, s.RingAxes[s.Head])
-		s.RingAxes[s.Head] = matrix.Axis{}
-		s.Head = (s.Head + 1) % s.Len
 	}
 
 	plane := matrix.CreatePlane(times, axes)
@@ -101,15 +101,80 @@ func (s *layerStat) Reduce(labeler decorator.Labeler) {
 	s.Next.Append(newAxis, s.StartTime, labeler)
 }
 
+// popFront removes the oldest axis from layerStat and returns it.
+func (s *layerStat) popFront() matrix.Axis {
+	_ = s.DeleteFirstAxisFromDb()
+
+	axis := s.RingAxes[s.Head]
+	s.StartTime = s.RingTimes[s.Head]
+	s.Bytes -= s.RingBytes[s.Head]
+	s.RingAxes[s.Head] = matrix.Axis{}
+	s.RingBytes[s.Head] = 0
+	s.Head = (s.Head + 1) % s.Len
+	return axis
+}
+
+// Evict drops the oldest axis of layerStat without passing it to the next layer.
+func (s *layerStat) Evict() {
+	if s.Empty {
+		return
+	}
+	s.popFront()
+	if s.Head == s.Tail {
+		s.Empty = true
+	}
+}
+
+// CompactHarder divides the axes of layerStat into fewer buckets, starting from the oldest one, until at least
+// excess bytes are released or no axis can be divided further. It returns the released bytes.
+func (s *layerStat) CompactHarder(labeler decorator.Labeler, excess int64) int64 {
+	var released int64
+	for i, n := 0, s.Count(); i < n && released < excess; i++ {
+		idx := (s.Head + i) % s.Len
+		axis := s.RingAxes[idx]
+		buckets := len(axis.Keys) - 1
+		if buckets <= minCompactedBuckets {
+			continue
+		}
+		newAxis := axis.Divide(labeler, max(buckets/2, minCompactedBuckets))
+		if len(newAxis.Keys) >= len(axis.Keys) {
+			continue
+		}
+		size, err := s.UpdateAxisInDb(newAxis, s.RingTimes[idx])
+		if err != nil {
+			continue
+		}
+		released += s.RingBytes[idx] - size
+		s.Bytes -= s.RingBytes[idx] - size
+		s.RingAxes[idx] = newAxis
+		s.RingBytes[idx] = size
+	}
+	return released
+}
+
+// Count returns the number of axes in layerStat.
+func (s *layerStat) Count() int {
+	if s.Empty {
+		return 0
+	}
+	size := s.Tail - s.Head
+	if size <= 0 {
+		size += s.Len
+	}
+	return size
+}
+
 // Append appends a key axis to layerStat.
 func (s *layerStat) Append(axis matrix.Axis, endTime time.Time, labeler decorator.Labeler) {
 	if s.Head == s.Tail && !s.Empty {
 		s.Reduce(labeler)
 	}
 
-	_ = s.InsertLastAxisToDb(axis, endTime)
+	size, _ := s.InsertLastAxisToDb(axis, endTime)
 
 	s.RingAxes[s.Tail] = axis
+	s.RingBytes[s.Tail] = size
+	s.Bytes += size
 	s.RingTimes[s.Tail] = endTime
 	s.Empty = false
 	s.EndTime = endTime
@@ -126,10 +191,7 @@ func (s *layerStat) Range(startTime, endTime time.Time) (times []time.Time, axes
 		return times, axes
 	}
 
-	size := s.Tail - s.Head
-	if size <= 0 {
-		size += s.Len
-	}
+	size := s.Count()
 
 	start := sort.Search(size, func(i int) bool {
 		return s.RingTimes[(s.Head+i)%s.Len].After(startTime)
@@ -169,6 +231,27 @@ func (s *layerStat) Range(startTime, endTime time.Time) (times []time.Time, axes
 // StatConfig is the configuration of Stat.
 type StatConfig struct {
 	LayersConfig []LayerConfig
+	// StorageBudgetBytes limits the total size of the axes stored in db. 0 means unlimited.
+	StorageBudgetBytes uint64
+}
+
+// LayerStats describes the data stored in a layer.
+type LayerStats struct {
+	LayerNum  uint8 `json:"layer_num"`
+	Len       int   `json:"len"`
+	Ratio     int   `json:"ratio"`
+	RowCount  int   `json:"row_count"`
+	StartTime int64 `json:"start_time"`
+	EndTime   int64 `json:"end_time"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// StorageStats describes the data stored in Stat.
+type StorageStats struct {
+	Layers             []LayerStats `json:"layers"`
+	RegionEventBytes   int64        `json:"region_event_bytes"`
+	TotalBytes         int64        `json:"total_bytes"` // Including region events
+	StorageBudgetBytes uint64       `json:"storage_budget_bytes"`
 }
 
 // Stat is composed of multiple layerStats.
@@ -178,6 +261,15 @@ type Stat struct {
 
 	keyMap   matrix.KeyMap
 	strategy *matrix.Strategy
+	budget   uint64
+
+	// lastMetas is the previous region snapshot, which is used to derive region events.
+	lastMetas []region.RegionMeta
+	// eventBytes is the size of region events in db, which counts towards the budget.
+	eventBytes int64
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan time.Time]struct{}
 
 	db *dbstore.DB
 }
@@ -201,7 +293,10 @@ func NewStat(
 	s := &Stat{
 		layers:   layers,
 		strategy: strategy,
+		budget:   cfg.StorageBudgetBytes,
 		db:       db,
+
+		subscribers: make(map[chan time.Time]struct{}),
 	}
 
 	lc.Append(fx.Hook{
@@ -214,6 +309,10 @@ func NewStat(
 			})
 			return nil
 		},
+		OnStop: func(context.Context) error {
+			s.closeSubscribers()
+			return nil
+		},
 	})
 
 	return s
@@ -245,6 +344,7 @@ func (s *Stat) rebuildRegularly(ctx context.Context) {
 			return
 		case <-ticker.C:
 			s.rebuildKeyMap()
+			s.deleteExpiredRegionEvents()
 		}
 	}
 }
@@ -264,6 +364,187 @@ func (s *Stat) Append(regions region.RegionsInfo, endTime time.Time) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.layers[0].Append(axis, endTime, labeler)
+	s.appendRegionEvents(regions, endTime)
+	s.enforceBudget(labeler)
+	s.notifySubscribers(endTime)
+}
+
+// Subscribe returns a channel receiving the end time of each appended axis, and a function to unsubscribe. The
+// channel is closed when Stat stops. Notifications are dropped if the subscriber is slow, so the subscriber should
+// range over all the axes it has not seen instead of counting notifications.
+func (s *Stat) Subscribe() (<-chan time.Time, func()) {
+	ch := make(chan time.Time, 1)
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	if s.subscribers == nil {
+		close(ch)
+		return ch, func() {}
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch, func() {
+		s.subscribersMu.Lock()
+		defer s.subscribersMu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *Stat) notifySubscribers(endTime time.Time) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- endTime:
+		default:
+		}
+	}
+}
+
+func (s *Stat) closeSubscribers() {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for ch := range s.subscribers {
+		close(ch)
+	}
+	s.subscribers = nil
+}
+
+func (s *Stat) appendRegionEvents(regions region.RegionsInfo, endTime time.Time) {
+	metasInfo, ok := regions.(region.RegionMetasInfo)
+	if !ok {
+		return
+	}
+	metas := metasInfo.GetMetas()
+	if s.lastMetas != nil {
+		events := DiffRegionMetas(s.lastMetas, metas, endTime)
+		size, err := InsertRegionEvents(s.db, events)
+		if err != nil {
+			log.Warn("Failed to insert region events", zap.Int("count", len(events)), zap.Error(err))
+		} else {
+			s.eventBytes += size
+		}
+	}
+	s.lastMetas = metas
+}
+
+// deleteExpiredRegionEvents removes the region events that are older than all axes, so that events are kept as long
+// as the axes.
+func (s *Stat) deleteExpiredRegionEvents() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := DeleteRegionEventsBefore(s.db, s.startTime()); err != nil {
+		log.Warn("Failed to delete expired region events", zap.Error(err))
+	}
+	s.refreshEventBytes()
+}
+
+func (s *Stat) refreshEventBytes() {
+	size, err := SumRegionEventBytes(s.db)
+	if err != nil {
+		log.Warn("Failed to get the size of region events", zap.Error(err))
+		return
+	}
+	s.eventBytes = size
+}
+
+func (s *Stat) startTime() time.Time {
+	startTime := s.layers[0].StartTime
+	for _, layer := range s.layers[1:] {
+		if layer.StartTime.Before(startTime) {
+			startTime = layer.StartTime
+		}
+	}
+	return startTime
+}
+
+// RegionEvents returns the region events overlapping with the specified time and key range.
+func (s *Stat) RegionEvents(startTime, endTime time.Time, startKey, endKey string) ([]RegionEvent, error) {
+	models, err := FindRegionEventsByTime(s.db, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]RegionEvent, 0, len(models))
+	for _, m := range models {
+		event, err := m.UnmarshalEvent()
+		if err != nil {
+			return nil, err
+		}
+		if event.Overlaps(startKey, endKey) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// SetStorageBudget changes the storage budget, which takes effect on the next Append.
+func (s *Stat) SetStorageBudget(budget uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.budget = budget
+}
+
+func (s *Stat) totalBytes() int64 {
+	total := s.eventBytes
+	for _, layer := range s.layers {
+		total += layer.Bytes
+	}
+	return total
+}
+
+// enforceBudget keeps the stored axes and region events within the storage budget. Older layers are compacted harder
+// first. When compaction is not enough, the oldest region events are dropped, and then the oldest axes, since the
+// heatmap is the primary data. The latest axis is always kept.
+func (s *Stat) enforceBudget(labeler decorator.Labeler) {
+	if s.budget == 0 {
+		return
+	}
+	excess := func() int64 {
+		return s.totalBytes() - int64(s.budget)
+	}
+	for i := len(s.layers) - 1; i > 0 && excess() > 0; i-- {
+		s.layers[i].CompactHarder(labeler, excess())
+	}
+	if excess() > 0 && s.eventBytes > 0 {
+		deleted, err := DeleteOldestRegionEvents(s.db, excess())
+		if err != nil {
+			log.Warn("Failed to delete region events", zap.Error(err))
+		}
+		s.eventBytes -= deleted
+	}
+	for i := len(s.layers) - 1; i >= 0 && excess() > 0; {
+		layer := s.layers[i]
+		if layer.Count() == 0 || (i == 0 && layer.Count() == 1) {
+			i--
+			continue
+		}
+		layer.Evict()
+	}
+}
+
+// Stats returns the statistics of the stored data.
+func (s *Stat) Stats() StorageStats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	stats := StorageStats{
+		Layers:             make([]LayerStats, 0, len(s.layers)),
+		RegionEventBytes:   s.eventBytes,
+		TotalBytes:         s.totalBytes(),
+		StorageBudgetBytes: s.budget,
+	}
+	for _, layer := range s.layers {
+		stats.Layers = append(stats.Layers, LayerStats{
+			LayerNum:  layer.LayerNum,
+			Len:       layer.Len,
+			Ratio:     layer.Ratio,
+			RowCount:  layer.Count(),
+			StartTime: layer.StartTime.Unix(),
+			EndTime:   layer.EndTime.Unix(),
+			Bytes:     layer.Bytes,
+		})
+	}
+	return stats
 }
 
 func (s *Stat) rangeRoot(startTime, endTime time.Time) ([]time.Time, []matrix.Axis) {
@@ -11,13 +11,24 @@ import (
 	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/matrix"
 )
 
-func (s *layerStat) InsertLastAxisToDb(axis matrix.Axis, endTime time.Time) error {
+// InsertLastAxisToDb stores the axis and returns its size in db.
+func (s *layerStat) InsertLastAxisToDb(axis matrix.Axis, endTime time.Time) (int64, error) {
 	log.Debug("Insert Axis", zap.Uint8("layer num", s.LayerNum), zap.Time("time", endTime))
 	axisModel, err := NewAxisModel(s.LayerNum, endTime, axis)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	return int64(len(axisModel.Axis)), axisModel.Insert(s.Db)
+}
+
+// UpdateAxisInDb replaces the stored axis at the given time and returns its new size in db.
+func (s *layerStat) UpdateAxisInDb(axis matrix.Axis, endTime time.Time) (int64, error) {
+	log.Debug("Update Axis", zap.Uint8("layer num", s.LayerNum), zap.Time("time", endTime))
+	axisModel, err := NewAxisModel(s.LayerNum, endTime, axis)
+	if err != nil {
+		return 0, err
 	}
-	return axisModel.Insert(s.Db)
+	return int64(len(axisModel.Axis)), axisModel.Update(s.Db)
 }
 
 func (s *layerStat) DeleteFirstAxisFromDb() error {
@@ -51,6 +62,11 @@ func (s *Stat) Restore() error {
 		return nil
 	}
 
+	if err := AutoMigrateRegionEventModel(s.db); err != nil {
+		return err
+	}
+	s.refreshEventBytes()
+
 	// table `AxisModel` preprocess
 	isExist, err := CreateTableAxisModelIfNotExists(s.db)
 	if err != nil {
@@ -87,21 +103,34 @@ func (s *Stat) Restore() error {
 		}
 		log.Debug("Load axisModels", zap.Uint8("layer num", layerNum), zap.Int("len", len(axisModels)-1))
 
-		// the first axisModel is only used to save starttime
-		s.layers[layerNum].StartTime = axisModels[0].Time
-		s.layers[layerNum].Head = 0
 		n := len(axisModels) - 1
 		if n > s.layers[layerNum].Len {
+			// The layer is shortened, keep the latest axes. The end time of the last dropped axis is the new start time.
 			log.Warn("The number of axisModel is longer than layer's len", zap.Int("number", n), zap.Int("layer len", s.layers[layerNum].Len), zap.Uint8("layer num", layerNum))
-			for _, p := range axisModels[s.layers[layerNum].Len+1:] {
+			drop := n - s.layers[layerNum].Len
+			for _, p := range axisModels[:drop] {
 				_ = p.Delete(s.db)
 			}
+			axisModels = axisModels[drop:]
+			startAxisModel, err := NewAxisModel(layerNum, axisModels[0].Time, matrix.Axis{})
+			if err != nil {
+				return err
+			}
+			if err := startAxisModel.Update(s.db); err != nil {
+				return err
+			}
 			n = s.layers[layerNum].Len
 		}
+
+		// the first axisModel is only used to save starttime
+		s.layers[layerNum].StartTime = axisModels[0].Time
+		s.layers[layerNum].Head = 0
 		s.layers[layerNum].EndTime = axisModels[n].Time
 		s.layers[layerNum].Tail = (s.layers[layerNum].Head + n) % s.layers[layerNum].Len
 		for i, axisModel := range axisModels[1 : n+1] {
 			s.layers[layerNum].RingTimes[i] = axisModel.Time
+			s.layers[layerNum].RingBytes[i] = int64(len(axisModel.Axis))
+			s.layers[layerNum].Bytes += int64(len(axisModel.Axis))
 			axis, err := axisModel.UnmarshalAxis()
 			if err != nil {
 				return err
@@ -3,9 +3,18 @@
 package storage
 
 import (
+	"fmt"
+	"path"
 	"testing"
+	"time"
 
 	"github.com/pingcap/check"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/pingcap/tidb-dashboard/pkg/dbstore"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/decorator"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/matrix"
 )
 
 func TestStat(t *testing.T) {
@@ -14,4 +23,178 @@ func TestStat(t *testing.T) {
 
 var _ = check.Suite(&testStatSuite{})
 
-type testStatSuite struct{}
+type testStatSuite struct {
+	db *dbstore.DB
+}
+
+func (t *testStatSuite) SetUpTest(c *check.C) {
+	dbPath := path.Join(c.MkDir(), "test.sqlite.db")
+	gormDB, err := gorm.Open(sqlite.Open(dbPath))
+	if err != nil {
+		c.Fatalf("Open %s error: %v", dbPath, err)
+	}
+	t.db = &dbstore.DB{DB: gormDB}
+	if _, err := CreateTableAxisModelIfNotExists(t.db); err != nil {
+		c.Fatalf("Create table AxisModel error: %v", err)
+	}
+}
+
+func (t *testStatSuite) newStat(budget uint64, layersConfig ...LayerConfig) *Stat {
+	startTime := time.Unix(0, 0)
+	layers := make([]*layerStat, len(layersConfig))
+	for i, conf := range layersConfig {
+		layers[i] = newLayerStat(uint8(i), conf, nil, startTime, t.db)
+		if i > 0 {
+			layers[i-1].Next = layers[i]
+		}
+	}
+	return &Stat{layers: layers, budget: budget, db: t.db}
+}
+
+func buildStorageAxis(buckets int) matrix.Axis {
+	keys := make([]string, 0, buckets+1)
+	keys = append(keys, "")
+	for i := 1; i < buckets; i++ {
+		keys = append(keys, fmt.Sprintf("key%06d", i))
+	}
+	keys = append(keys, "")
+	valuesList := make([][]uint64, 4)
+	for i := range valuesList {
+		values := make([]uint64, buckets)
+		for j := range values {
+			values[j] = uint64(j%7 + 1)
+		}
+		valuesList[i] = values
+	}
+	return matrix.CreateAxis(keys, valuesList)
+}
+
+func (t *testStatSuite) TestEnforceBudget(c *check.C) {
+	labeler := decorator.NaiveLabelStrategy().NewLabeler()
+	stat := t.newStat(0, LayerConfig{Len: 4, Ratio: 0}, LayerConfig{Len: 4, Ratio: 0})
+	for i := 0; i < 3; i++ {
+		stat.layers[1].Append(buildStorageAxis(1024), time.Unix(int64(i+1), 0), labeler)
+	}
+	stat.layers[0].Append(buildStorageAxis(1024), time.Unix(10, 0), labeler)
+	total := stat.totalBytes()
+	c.Assert(stat.layers[1].Count(), check.Equals, 3)
+
+	// compacting the older layer is enough
+	stat.budget = uint64(total - stat.layers[1].RingBytes[0]/2)
+	stat.enforceBudget(labeler)
+	c.Assert(stat.totalBytes() <= int64(stat.budget), check.IsTrue)
+	c.Assert(stat.layers[1].Count(), check.Equals, 3)
+	c.Assert(len(stat.layers[1].RingAxes[0].Keys) < 1025, check.IsTrue)
+	c.Assert(len(stat.layers[0].RingAxes[0].Keys), check.Equals, 1025)
+
+	// the oldest axes are dropped, but the latest one is always kept
+	stat.budget = 1
+	stat.enforceBudget(labeler)
+	c.Assert(stat.layers[1].Count(), check.Equals, 0)
+	c.Assert(stat.layers[0].Count(), check.Equals, 1)
+	c.Assert(stat.totalBytes(), check.Equals, stat.layers[0].Bytes)
+
+	stats := stat.Stats()
+	c.Assert(stats.Layers, check.HasLen, 2)
+	c.Assert(stats.Layers[0].RowCount, check.Equals, 1)
+	c.Assert(stats.Layers[0].EndTime, check.Equals, int64(10))
+	c.Assert(stats.Layers[1].RowCount, check.Equals, 0)
+	c.Assert(stats.TotalBytes, check.Equals, stat.layers[0].Bytes)
+}
+
+func (t *testStatSuite) TestEnforceBudgetWithRegionEvents(c *check.C) {
+	c.Assert(AutoMigrateRegionEventModel(t.db), check.IsNil)
+	labeler := decorator.NaiveLabelStrategy().NewLabeler()
+	stat := t.newStat(0, LayerConfig{Len: 4, Ratio: 0})
+	stat.layers[0].Append(buildStorageAxis(4), time.Unix(10, 0), labeler)
+	events := make([]RegionEvent, 0, 10)
+	for i := 0; i < 10; i++ {
+		events = append(events, RegionEvent{Type: RegionEventSplit, Time: int64(i), StartKey: "a", EndKey: "b", RegionIDs: []uint64{1, 2}})
+	}
+	size, err := InsertRegionEvents(t.db, events)
+	c.Assert(err, check.IsNil)
+	stat.eventBytes = size
+	sum, err := SumRegionEventBytes(t.db)
+	c.Assert(err, check.IsNil)
+	c.Assert(sum, check.Equals, size)
+	c.Assert(stat.Stats().TotalBytes, check.Equals, stat.layers[0].Bytes+size)
+
+	// the oldest region events are dropped before axes
+	stat.budget = uint64(stat.layers[0].Bytes + size/2)
+	stat.enforceBudget(labeler)
+	c.Assert(stat.totalBytes() <= int64(stat.budget), check.IsTrue)
+	c.Assert(stat.layers[0].Count(), check.Equals, 1)
+	remaining, err := stat.RegionEvents(time.Unix(0, 0), time.Unix(10, 0), "", "")
+	c.Assert(err, check.IsNil)
+	c.Assert(remaining, check.HasLen, 5)
+	c.Assert(remaining[0].Time, check.Equals, int64(5))
+	sum, err = SumRegionEventBytes(t.db)
+	c.Assert(err, check.IsNil)
+	c.Assert(sum, check.Equals, stat.eventBytes)
+}
+
+func (t *testStatSuite) TestRestoreShortenedLayer(c *check.C) {
+	labeler := decorator.NaiveLabelStrategy().NewLabeler()
+	stat := t.newStat(0, LayerConfig{Len: 4, Ratio: 0})
+	startAxisModel, err := NewAxisModel(0, time.Unix(0, 0), matrix.Axis{})
+	c.Assert(err, check.IsNil)
+	c.Assert(startAxisModel.Insert(t.db), check.IsNil)
+	for i := 1; i <= 4; i++ {
+		stat.layers[0].Append(buildStorageAxis(4), time.Unix(int64(i), 0), labeler)
+	}
+
+	// the latest axes are kept when the layer is shortened
+	stat = t.newStat(0, LayerConfig{Len: 2, Ratio: 0})
+	c.Assert(stat.Restore(), check.IsNil)
+	c.Assert(stat.layers[0].Count(), check.Equals, 2)
+	c.Assert(stat.layers[0].StartTime.Unix(), check.Equals, int64(2))
+	c.Assert(stat.layers[0].EndTime.Unix(), check.Equals, int64(4))
+	c.Assert(stat.layers[0].RingTimes[0].Unix(), check.Equals, int64(3))
+
+	axisModels, err := FindAxisModelsOrderByTime(t.db, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(axisModels, check.HasLen, 3)
+	c.Assert(axisModels[0].Time.Unix(), check.Equals, int64(2))
+	axis, err := axisModels[0].UnmarshalAxis()
+	c.Assert(err, check.IsNil)
+	c.Assert(axis.Keys, check.HasLen, 0)
+}
+
+func (t *testStatSuite) TestRestoreDroppedAxes(c *check.C) {
+	labeler := decorator.NaiveLabelStrategy().NewLabeler()
+	stat := t.newStat(0, LayerConfig{Len: 4, Ratio: 0})
+	stat.layers[0].Append(buildStorageAxis(4), time.Unix(1, 0), labeler)
+	c.Assert(DropTableAxisModel(t.db), check.IsNil)
+
+	stat = t.newStat(0, LayerConfig{Len: 4, Ratio: 0})
+	c.Assert(stat.Restore(), check.IsNil)
+	c.Assert(stat.layers[0].Count(), check.Equals, 0)
+	axisModels, err := FindAxisModelsOrderByTime(t.db, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(axisModels, check.HasLen, 1)
+}
+
+func (t *testStatSuite) TestSubscribe(c *check.C) {
+	stat := t.newStat(0, LayerConfig{Len: 4, Ratio: 0})
+	stat.subscribers = make(map[chan time.Time]struct{})
+
+	ch1, unsubscribe1 := stat.Subscribe()
+	ch2, _ := stat.Subscribe()
+	stat.notifySubscribers(time.Unix(1, 0))
+	// slow subscribers miss notifications instead of blocking
+	stat.notifySubscribers(time.Unix(2, 0))
+	c.Assert(<-ch1, check.Equals, time.Unix(1, 0))
+	c.Assert(<-ch2, check.Equals, time.Unix(1, 0))
+
+	unsubscribe1()
+	_, ok := <-ch1
+	c.Assert(ok, check.IsFalse)
+	unsubscribe1()
+
+	stat.closeSubscribers()
+	_, ok = <-ch2
+	c.Assert(ok, check.IsFalse)
+	ch3, _ := stat.Subscribe()
+	_, ok = <-ch3
+	c.Assert(ok, check.IsFalse)
+}
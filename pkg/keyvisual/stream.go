@@ -0,0 +1,168 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package keyvisual
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+
+	"github.com/pingcap/tidb-dashboard/pkg/apiserver/utils"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/matrix"
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/region"
+	"github.com/pingcap/tidb-dashboard/util/rest"
+)
+
+const (
+	subscribeTokenIssuer = "keyvisual/subscribe"
+	subscribeTokenExpire = time.Minute
+
+	HeatmapUpdateFull        = "full"
+	HeatmapUpdateIncremental = "incremental"
+)
+
+// HeatmapUpdate is pushed to the subscribers of heatmaps. The first update is a full matrix. The following updates are
+// incremental, whose TimeAxis only contains the end times of the new columns, and whose data are on the same keys as
+// the last full matrix. A full matrix is pushed again whenever the key axis changes with the new data.
+type HeatmapUpdate struct {
+	Type   string        `json:"type"`
+	Matrix matrix.Matrix `json:"matrix"`
+}
+
+// @Summary Get Key Visual Heatmaps Subscription Token
+// @Description Get a short-lived token to subscribe heatmaps over WebSocket
+// @Produce plain
+// @Success 200 {string} string
+// @Router /keyvisual/heatmaps/subscribe_token [get]
+// @Security JwtAuth
+// @Failure 401 {object} rest.ErrorResponse
+// @Failure 500 {object} rest.ErrorResponse
+func (s *Service) getSubscribeToken(c *gin.Context) {
+	token, err := utils.NewJWTStringWithExpire(subscribeTokenIssuer, "", subscribeTokenExpire)
+	if err != nil {
+		rest.Error(c, err)
+		return
+	}
+	c.String(http.StatusOK, token)
+}
+
+// @Summary Subscribe Key Visual Heatmaps
+// @Description Push the heatmap of a given range over WebSocket, followed by incremental columns whenever new data is collected, or by the full heatmap again if the key axis changes
+// @Param token query string true "subscription token"
+// @Param startkey query string false "The start of the key range"
+// @Param endkey query string false "The end of the key range"
+// @Param starttime query int false "The start of the time range (Unix)"
+// @Param type query string false "Main types of data" Enums(written_bytes, read_bytes, written_keys, read_keys, integration)
+// @Success 101 {object} HeatmapUpdate
+// @Router /keyvisual/heatmaps/subscribe [get]
+// @Failure 400 {object} rest.ErrorResponse
+func (s *Service) subscribeHeatmaps(c *gin.Context) {
+	if _, err := utils.ParseJWTString(subscribeTokenIssuer, c.Query("token")); err != nil {
+		rest.Error(c, rest.ErrBadRequest.NewWithNoMessage())
+		return
+	}
+	startTime, _, startKey, endKey, ok := parseRangeQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, "bad request")
+		return
+	}
+	baseTag := region.IntoTag(c.Query("type"))
+
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			s.streamHeatmaps(ws, startTime, startKey, endKey, baseTag)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (s *Service) streamHeatmaps(ws *websocket.Conn, startTime time.Time, startKey, endKey string, baseTag region.StatTag) {
+	stat := s.getStat()
+	if stat == nil {
+		return
+	}
+	updates, unsubscribe := stat.Subscribe()
+	defer unsubscribe()
+
+	// detect the closing of the connection, the client is not expected to send anything
+	closed := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, ws)
+		close(closed)
+	}()
+
+	typ := baseTag.String()
+	generate := func() matrix.Matrix {
+		resp := s.generateHeatmap(stat, startTime, time.Now(), startKey, endKey, baseTag)
+		resp.DataMap = map[string][][]uint64{
+			typ: resp.DataMap[typ],
+		}
+		return resp
+	}
+
+	sent := generate()
+	if err := websocket.JSON.Send(ws, HeatmapUpdate{Type: HeatmapUpdateFull, Matrix: sent}); err != nil {
+		log.Debug("Failed to send heatmap", zap.Error(err))
+		return
+	}
+
+	for {
+		select {
+		case <-closed:
+			return
+		case _, ok := <-updates:
+			if !ok {
+				return
+			}
+			// Regenerate the whole heatmap so that the new columns are pixeled in the same way as a refetch.
+			resp := generate()
+			update, ok := nextHeatmapUpdate(sent, resp)
+			if !ok {
+				continue
+			}
+			if err := websocket.JSON.Send(ws, update); err != nil {
+				log.Debug("Failed to send heatmap", zap.Error(err))
+				return
+			}
+			sent = resp
+		}
+	}
+}
+
+// nextHeatmapUpdate returns the update from the heatmap sent before to the regenerated one. It is incremental if the
+// key axis stays the same, otherwise it is full. False is returned if there is nothing new.
+func nextHeatmapUpdate(sent, resp matrix.Matrix) (HeatmapUpdate, bool) {
+	if !slices.Equal(sent.Keys, resp.Keys) {
+		return HeatmapUpdate{Type: HeatmapUpdateFull, Matrix: resp}, true
+	}
+
+	lastTime := sent.TimeAxis[len(sent.TimeAxis)-1]
+	update := HeatmapUpdate{
+		Type: HeatmapUpdateIncremental,
+		Matrix: matrix.Matrix{
+			Keys:    resp.Keys,
+			DataMap: make(map[string][][]uint64, len(resp.DataMap)),
+		},
+	}
+	// TimeAxis[i+1] is the end time of the i-th column
+	for i, endTime := range resp.TimeAxis[1:] {
+		if endTime <= lastTime {
+			continue
+		}
+		update.Matrix.TimeAxis = append(update.Matrix.TimeAxis, endTime)
+		for typ, data := range resp.DataMap {
+			update.Matrix.DataMap[typ] = append(update.Matrix.DataMap[typ], data[i])
+		}
+	}
+	if len(update.Matrix.TimeAxis) == 0 {
+		return HeatmapUpdate{}, false
+	}
+	return update, true
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package keyvisual
+
+import (
+	"testing"
+
+	"github.com/pingcap/check"
+
+	"github.com/pingcap/tidb-dashboard/pkg/keyvisual/matrix"
+)
+
+func TestStream(t *testing.T) {
+	check.TestingT(t)
+}
+
+var _ = check.Suite(&testStreamSuite{})
+
+type testStreamSuite struct{}
+
+func (t *testStreamSuite) TestNextHeatmapUpdate(c *check.C) {
+	sent := matrix.Matrix{
+		Keys:     []string{"", "b", ""},
+		DataMap:  map[string][][]uint64{"written_bytes": {{1, 2}}},
+		TimeAxis: []int64{0, 60},
+	}
+
+	// new columns on the same keys are pushed incrementally, with the values of the regenerated heatmap
+	resp := matrix.Matrix{
+		Keys:     []string{"", "b", ""},
+		DataMap:  map[string][][]uint64{"written_bytes": {{1, 2}, {3, 4}, {5, 6}}},
+		TimeAxis: []int64{0, 60, 120, 180},
+	}
+	update, ok := nextHeatmapUpdate(sent, resp)
+	c.Assert(ok, check.IsTrue)
+	c.Assert(update.Type, check.Equals, HeatmapUpdateIncremental)
+	c.Assert(update.Matrix.Keys, check.DeepEquals, resp.Keys)
+	c.Assert(update.Matrix.TimeAxis, check.DeepEquals, []int64{120, 180})
+	c.Assert(update.Matrix.DataMap, check.DeepEquals, map[string][][]uint64{"written_bytes": {{3, 4}, {5, 6}}})
+
+	// nothing new
+	_, ok = nextHeatmapUpdate(resp, resp)
+	c.Assert(ok, check.IsFalse)
+
+	// the key axis changes with the new data
+	resp = matrix.Matrix{
+		Keys:     []string{"", "a", "b", ""},
+		DataMap:  map[string][][]uint64{"written_bytes": {{1, 1, 1}, {2, 2, 2}}},
+		TimeAxis: []int64{0, 60, 120},
+	}
+	update, ok = nextHeatmapUpdate(sent, resp)
+	c.Assert(ok, check.IsTrue)
+	c.Assert(update.Type, check.Equals, HeatmapUpdateFull)
+	c.Assert(update.Matrix, check.DeepEquals, resp)
+}
+
+func (t *testStreamSuite) TestNextHeatmapUpdateFromEmpty(c *check.C) {
+	// subscribed before any data is collected, so the heatmap is a single bucket
+	sent := matrix.Matrix{
+		Keys:     []string{"", ""},
+		DataMap:  map[string][][]uint64{"written_bytes": {{0}}},
+		TimeAxis: []int64{0, 30},
+	}
+	resp := matrix.Matrix{
+		Keys:     []string{"", "b", "d", ""},
+		DataMap:  map[string][][]uint64{"written_bytes": {{1, 2, 3}}},
+		TimeAxis: []int64{0, 60},
+	}
+	update, ok := nextHeatmapUpdate(sent, resp)
+	c.Assert(ok, check.IsTrue)
+	c.Assert(update.Type, check.Equals, HeatmapUpdateFull)
+	c.Assert(update.Matrix.Keys, check.DeepEquals, resp.Keys)
+
+	// following columns on the new keys are incremental
+	next := matrix.Matrix{
+		Keys:     []string{"", "b", "d", ""},
+		DataMap:  map[string][][]uint64{"written_bytes": {{1, 2, 3}, {4, 5, 6}}},
+		TimeAxis: []int64{0, 60, 120},
+	}
+	update, ok = nextHeatmapUpdate(resp, next)
+	c.Assert(ok, check.IsTrue)
+	c.Assert(update.Type, check.Equals, HeatmapUpdateIncremental)
+	c.Assert(update.Matrix.DataMap["written_bytes"], check.DeepEquals, [][]uint64{{4, 5, 6}})
+}
@@ -67,6 +67,11 @@ func (c Client) WithTimeout(timeout time.Duration) *Client {
 	return &c
 }
 
+func (c Client) WithContext(ctx context.Context) *Client {
+	c.lifecycleCtx = ctx
+	return &c
+}
+
 func (c Client) WithoutPrefix() *Client {
 	c.withoutPrefix = true
 	return &c
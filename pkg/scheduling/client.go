@@ -54,6 +54,11 @@ func (c Client) WithTimeout(timeout time.Duration) *Client {
 	return &c
 }
 
+func (c Client) WithContext(ctx context.Context) *Client {
+	c.lifecycleCtx = ctx
+	return &c
+}
+
 func (c Client) AddRequestHeader(key, value string) *Client {
 	c.httpClient = c.httpClient.CloneAndAddRequestHeader(key, value)
 	return &c
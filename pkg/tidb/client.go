@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
@@ -91,6 +92,11 @@ func (c Client) WithStatusAPITimeout(timeout time.Duration) *Client {
 	return &c
 }
 
+func (c Client) WithContext(ctx context.Context) *Client {
+	c.lifecycleCtx = ctx
+	return &c
+}
+
 func (c Client) WithStatusAPIAddress(host string, statusPort int) *Client {
 	c.statusAPIAddress = net.JoinHostPort(host, strconv.Itoa(statusPort))
 	return &c
@@ -175,6 +181,10 @@ func (c *Client) OpenSQLConn(user string, pass string) (*gorm.DB, error) {
 }
 
 func (c *Client) Get(relativeURI string) (*httpc.Response, error) {
+	return c.send(http.MethodGet, relativeURI, nil)
+}
+
+func (c *Client) send(method string, relativeURI string, body io.Reader) (*httpc.Response, error) {
 	var err error
 
 	overrideEndpoint := os.Getenv(tidbOverrideStatusEndpointEnvVar)
@@ -202,7 +212,7 @@ func (c *Client) Get(relativeURI string) (*httpc.Response, error) {
 	uri := fmt.Sprintf("%s://%s%s", c.statusAPIHTTPScheme, addr, relativeURI)
 	res, err := c.statusAPIHTTPClient.
 		WithTimeout(c.statusAPITimeout).
-		Send(c.lifecycleCtx, uri, http.MethodGet, nil, ErrTiDBClientRequestFailed, distro.R().TiDB)
+		Send(c.lifecycleCtx, uri, method, body, ErrTiDBClientRequestFailed, distro.R().TiDB)
 	if err != nil && c.forwarder.statusProxy.noAliveRemote.Load() {
 		return nil, ErrNoAliveTiDB.NewWithNoMessage()
 	}
@@ -217,3 +227,11 @@ func (c *Client) SendGetRequest(relativeURI string) ([]byte, error) {
 	}
 	return res.Body()
 }
+
+func (c *Client) SendPostRequest(relativeURI string, body io.Reader) ([]byte, error) {
+	res, err := c.send(http.MethodPost, relativeURI, body)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body()
+}
@@ -107,6 +107,11 @@ func (buf *KeyInfoBuffer) GenerateKey(tableID, rowID int64) Key {
 	return encodeBytes(data)
 }
 
+// EncodeKey encodes a raw key into the memcomparable format used by TiKV transactional keys.
+func EncodeKey(key []byte) Key {
+	return encodeBytes(key)
+}
+
 var pads = make([]byte, encGroupSize)
 
 // decodeBytes decodes bytes which is encoded by encodeBytes before,
@@ -54,6 +54,11 @@ func (c Client) WithTimeout(timeout time.Duration) *Client {
 	return &c
 }
 
+func (c Client) WithContext(ctx context.Context) *Client {
+	c.lifecycleCtx = ctx
+	return &c
+}
+
 func (c Client) AddRequestHeader(key, value string) *Client {
 	c.httpClient = c.httpClient.CloneAndAddRequestHeader(key, value)
 	return &c
@@ -76,3 +81,8 @@ func (c *Client) SendPostRequest(host string, statusPort int, relativeURI string
 	uri := fmt.Sprintf("%s://%s%s", c.httpScheme, net.JoinHostPort(host, strconv.Itoa(statusPort)), relativeURI)
 	return c.httpClient.WithTimeout(c.timeout).SendRequest(c.lifecycleCtx, uri, http.MethodPost, body, ErrTiProxyClientRequestFailed, distro.R().TiProxy)
 }
+
+func (c *Client) SendPutRequest(host string, statusPort int, relativeURI string, body io.Reader) ([]byte, error) {
+	uri := fmt.Sprintf("%s://%s%s", c.httpScheme, net.JoinHostPort(host, strconv.Itoa(statusPort)), relativeURI)
+	return c.httpClient.WithTimeout(c.timeout).SendRequest(c.lifecycleCtx, uri, http.MethodPut, body, ErrTiProxyClientRequestFailed, distro.R().TiProxy)
+}
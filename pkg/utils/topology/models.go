@@ -78,6 +78,7 @@ type SchedulingInfo struct {
 
 // Store may be a TiKV store or TiFlash store.
 type StoreInfo struct {
+	ID             uint64            `json:"id"`
 	GitHash        string            `json:"git_hash"`
 	Version        string            `json:"version"`
 	IP             string            `json:"ip"`
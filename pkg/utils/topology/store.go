@@ -92,6 +92,7 @@ func buildStoreTopology(stores []store) []StoreInfo {
 			version = "v" + version
 		}
 		node := StoreInfo{
+			ID:             v.ID,
 			Version:        version,
 			IP:             hostname,
 			Port:           port,
@@ -113,7 +114,7 @@ func buildStoreTopology(stores []store) []StoreInfo {
 
 type store struct {
 	Address string `json:"address"`
-	ID      int    `json:"id"`
+	ID      uint64 `json:"id"`
 	Labels  []struct {
 		Key   string `json:"key"`
 		Value string `json:"value"`
@@ -421,6 +421,91 @@ func NewPDServerFixture() (mockTransport *httpmock.MockTransport) {
   "strictly-match-label": "false",
   "enable-placement-rules": "false"
 }
+`))
+	mockTransport.RegisterResponderWithQuery("GET", "http://172.16.6.171:2379/pd/api/v1/regions/key",
+		map[string]string{"key": "", "limit": "2"},
+		httpmockutil.StringResponder(`
+{
+  "count": 2,
+  "regions": [
+    {
+      "id": 2,
+      "start_key": "",
+      "end_key": "7431",
+      "peers": [
+        {"id": 3, "store_id": 1, "role_name": "Voter"},
+        {"id": 46, "store_id": 4, "role_name": "Voter"},
+        {"id": 64, "store_id": 5, "role_name": "Voter"}
+      ],
+      "leader": {"id": 3, "store_id": 1, "role_name": "Voter"}
+    },
+    {
+      "id": 8,
+      "start_key": "7431",
+      "end_key": "7432",
+      "peers": [
+        {"id": 9, "store_id": 1, "role_name": "Voter"},
+        {"id": 47, "store_id": 4, "role_name": "Voter"},
+        {"id": 65, "store_id": 5, "role_name": "Voter"}
+      ],
+      "leader": {"id": 47, "store_id": 4, "role_name": "Voter"}
+    }
+  ]
+}
+`))
+	mockTransport.RegisterResponderWithQuery("GET", "http://172.16.6.171:2379/pd/api/v1/regions/key",
+		map[string]string{"key": "t2", "limit": "2"},
+		httpmockutil.StringResponder(`
+{
+  "count": 1,
+  "regions": [
+    {
+      "id": 12,
+      "start_key": "7432",
+      "end_key": "",
+      "peers": [
+        {"id": 13, "store_id": 1, "role_name": "Voter"},
+        {"id": 48, "store_id": 4, "role_name": "Voter"},
+        {"id": 66, "store_id": 5, "role_name": "Voter"}
+      ],
+      "leader": {"id": 66, "store_id": 5, "role_name": "Voter"}
+    }
+  ]
+}
+`))
+	mockTransport.RegisterResponderWithQuery("GET", "http://172.16.6.171:2379/pd/api/v1/regions/key",
+		map[string]string{"key": "t2", "limit": "1"},
+		httpmockutil.StringResponder(`
+{
+  "count": 1,
+  "regions": [
+    {
+      "id": 12,
+      "start_key": "7432",
+      "end_key": "",
+      "peers": [
+        {"id": 13, "store_id": 1, "role_name": "Voter"},
+        {"id": 48, "store_id": 4, "role_name": "Voter"},
+        {"id": 66, "store_id": 5, "role_name": "Voter"}
+      ],
+      "leader": {"id": 66, "store_id": 5, "role_name": "Voter"}
+    }
+  ]
+}
+`))
+	mockTransport.RegisterResponder("GET", "http://172.16.6.171:2379/pd/api/v1/config/rules",
+		httpmockutil.StringResponder(`
+[
+  {
+    "group_id": "pd",
+    "id": "default",
+    "start_key": "",
+    "end_key": "",
+    "role": "voter",
+    "is_witness": false,
+    "count": 3
+  }
+]
 `))
 	return
 }
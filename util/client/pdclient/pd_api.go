@@ -4,6 +4,7 @@ package pdclient
 
 import (
 	"context"
+	"strconv"
 )
 
 // TODO: Switch to use swagger.
@@ -96,3 +97,60 @@ func (api *APIClient) GetStores(ctx context.Context) (resp *GetStoresResponse, e
 	_, err = api.LR().SetContext(ctx).Get(APIPrefix + "/stores").ReadBodyAsJSON(&resp)
 	return
 }
+
+type GetRegionsResponsePeer struct {
+	ID       uint64 `json:"id"`
+	StoreID  uint64 `json:"store_id"`
+	RoleName string `json:"role_name"`
+}
+
+type GetRegionsResponseRegion struct {
+	ID       uint64                   `json:"id"`
+	StartKey string                   `json:"start_key"` // Hex encoded
+	EndKey   string                   `json:"end_key"`   // Hex encoded
+	Peers    []GetRegionsResponsePeer `json:"peers"`
+	Leader   *GetRegionsResponsePeer  `json:"leader"`
+}
+
+type GetRegionsResponse struct {
+	Count   int                        `json:"count"`
+	Regions []GetRegionsResponseRegion `json:"regions"`
+}
+
+// GetRegionsByKey returns the content from /regions/key PD API. The key is the raw key to start with.
+// You must specify the base URL by calling SetDefaultBaseURL() before using this function.
+func (api *APIClient) GetRegionsByKey(ctx context.Context, key []byte, limit int) (resp *GetRegionsResponse, err error) {
+	_, err = api.LR().SetContext(ctx).
+		SetQueryParam("key", string(key)).
+		SetQueryParam("limit", strconv.Itoa(limit)).
+		Get(APIPrefix + "/regions/key").ReadBodyAsJSON(&resp)
+	return
+}
+
+type GetConfigRulesResponseLabelConstraint struct {
+	Key    string   `json:"key"`
+	Op     string   `json:"op"`
+	Values []string `json:"values"`
+}
+
+type GetConfigRulesResponseRule struct {
+	GroupID          string                                  `json:"group_id"`
+	ID               string                                  `json:"id"`
+	Index            int                                     `json:"index"`
+	StartKey         string                                  `json:"start_key"` // Hex encoded
+	EndKey           string                                  `json:"end_key"`   // Hex encoded
+	Role             string                                  `json:"role"`
+	Count            int                                     `json:"count"`
+	LabelConstraints []GetConfigRulesResponseLabelConstraint `json:"label_constraints"`
+	LocationLabels   []string                                `json:"location_labels"`
+	IsolationLevel   string                                  `json:"isolation_level"`
+}
+
+type GetConfigRulesResponse []GetConfigRulesResponseRule
+
+// GetConfigRules returns the content from /config/rules PD API.
+// You must specify the base URL by calling SetDefaultBaseURL() before using this function.
+func (api *APIClient) GetConfigRules(ctx context.Context) (resp *GetConfigRulesResponse, err error) {
+	_, err = api.LR().SetContext(ctx).Get(APIPrefix + "/config/rules").ReadBodyAsJSON(&resp)
+	return
+}
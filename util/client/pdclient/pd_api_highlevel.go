@@ -6,6 +6,7 @@ package pdclient
 
 import (
 	"context"
+	"encoding/hex"
 	"sort"
 	"strings"
 )
@@ -81,3 +82,41 @@ func (api *APIClient) HLGetStoreLocations(ctx context.Context) (*StoreLocations,
 		Stores:         nodes,
 	}, nil
 }
+
+// HLScanRegions returns regions from the beginning of the key space in pages, until all regions are scanned or
+// maxRegions regions are returned. truncated is true if the scan stops at maxRegions while more regions remain.
+// You must specify the base URL by calling SetDefaultBaseURL() before using this function.
+func (api *APIClient) HLScanRegions(ctx context.Context, pageSize int, maxRegions int) (regions []GetRegionsResponseRegion, truncated bool, err error) {
+	regions = make([]GetRegionsResponseRegion, 0)
+	key := []byte{}
+	for {
+		limit := pageSize
+		if maxRegions-len(regions) < limit {
+			limit = maxRegions - len(regions)
+		}
+		if limit <= 0 {
+			// Only truncated when there are regions after the scanned ones.
+			resp, err := api.GetRegionsByKey(ctx, key, 1)
+			if err != nil {
+				return nil, false, err
+			}
+			return regions, len(resp.Regions) > 0, nil
+		}
+		resp, err := api.GetRegionsByKey(ctx, key, limit)
+		if err != nil {
+			return nil, false, err
+		}
+		regions = append(regions, resp.Regions...)
+		if len(resp.Regions) == 0 {
+			return regions, false, nil
+		}
+		endKey := resp.Regions[len(resp.Regions)-1].EndKey
+		if endKey == "" {
+			return regions, false, nil
+		}
+		key, err = hex.DecodeString(endKey)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+}
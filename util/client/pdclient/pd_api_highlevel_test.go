@@ -73,3 +73,22 @@ func TestAPIClient_HLGetStores(t *testing.T) {
 		},
 	}, resp)
 }
+
+func TestAPIClient_HLScanRegions(t *testing.T) {
+	apiClient := fixture.NewAPIClientFixture()
+	regions, truncated, err := apiClient.HLScanRegions(context.Background(), 2, 10)
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Len(t, regions, 3)
+	require.Equal(t, []uint64{2, 8, 12}, []uint64{regions[0].ID, regions[1].ID, regions[2].ID})
+
+	regions, truncated, err = apiClient.HLScanRegions(context.Background(), 2, 2)
+	require.NoError(t, err)
+	require.True(t, truncated)
+	require.Len(t, regions, 2)
+
+	regions, truncated, err = apiClient.HLScanRegions(context.Background(), 2, 3)
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Len(t, regions, 3)
+}
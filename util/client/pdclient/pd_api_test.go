@@ -118,3 +118,34 @@ func TestAPIClient_GetStores(t *testing.T) {
 		},
 	}, resp)
 }
+
+func TestAPIClient_GetRegionsByKey(t *testing.T) {
+	apiClient := fixture.NewAPIClientFixture()
+	resp, err := apiClient.GetRegionsByKey(context.Background(), []byte("t2"), 2)
+	require.NoError(t, err)
+	require.Equal(t, &pdclient.GetRegionsResponse{
+		Count: 1,
+		Regions: []pdclient.GetRegionsResponseRegion{
+			{
+				ID:       12,
+				StartKey: "7432",
+				EndKey:   "",
+				Peers: []pdclient.GetRegionsResponsePeer{
+					{ID: 13, StoreID: 1, RoleName: "Voter"},
+					{ID: 48, StoreID: 4, RoleName: "Voter"},
+					{ID: 66, StoreID: 5, RoleName: "Voter"},
+				},
+				Leader: &pdclient.GetRegionsResponsePeer{ID: 66, StoreID: 5, RoleName: "Voter"},
+			},
+		},
+	}, resp)
+}
+
+func TestAPIClient_GetConfigRules(t *testing.T) {
+	apiClient := fixture.NewAPIClientFixture()
+	resp, err := apiClient.GetConfigRules(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &pdclient.GetConfigRulesResponse{
+		{GroupID: "pd", ID: "default", Role: "voter", Count: 3},
+	}, resp)
+}